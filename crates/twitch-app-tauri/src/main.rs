@@ -9,6 +9,8 @@ use tauri::{Listener, Manager};
 use tokio::sync::mpsc;
 use tracing_subscriber::{layer::SubscriberExt, util::SubscriberInitExt, EnvFilter};
 
+use twitch_backend::app_services::AppServices;
+use twitch_backend::config::MenuToggle;
 use twitch_backend::{AuthCommand, BackendEvent};
 use twitch_menu_tauri::display::DisplayBackend;
 use twitch_menu_tauri::display_state::DisplayState;
@@ -22,6 +24,16 @@ fn main() {
         .with(tracing_subscriber::fmt::layer())
         .init();
 
+    // `--test-notification` sends one notification and exits, so users can
+    // check notifications work without waiting for someone to go live
+    if std::env::args().any(|arg| arg == "--test-notification") {
+        if let Err(e) = twitch_backend::send_test_notification() {
+            eprintln!("Test notification failed: {e:#}");
+            std::process::exit(1);
+        }
+        return;
+    }
+
     tracing::info!("Starting Twitch Tray");
 
     // Build the Tauri application
@@ -35,6 +47,7 @@ fn main() {
             twitch_settings_tauri::commands::is_debug_build,
             twitch_settings_tauri::commands::get_debug_schedule_data,
             twitch_settings_tauri::commands::get_debug_hotness_data,
+            twitch_settings_tauri::commands::save_state_snapshot,
         ])
         .setup(|app| {
             // Enter the Tauri-managed tokio runtime so tokio::spawn works
@@ -103,6 +116,8 @@ fn main() {
             if let tauri::RunEvent::ExitRequested { ref api, code, .. } = event {
                 if code.is_none() {
                     api.prevent_exit();
+                } else if let Some(services) = app.try_state::<Arc<dyn AppServices>>() {
+                    services.shutdown();
                 }
             }
 
@@ -122,6 +137,80 @@ fn main() {
                         let _ = tx.send(AuthCommand::Logout);
                     }
                 });
+
+                let app_handle3 = app.clone();
+                app.listen("test-notification-requested", move |_| {
+                    if let Some(services) = app_handle3.try_state::<Arc<dyn AppServices>>() {
+                        if let Err(e) = services.send_test_notification() {
+                            tracing::error!("Test notification failed: {:#}", e);
+                        }
+                    }
+                });
+
+                let app_handle4 = app.clone();
+                app.listen("unmute-requested", move |event| {
+                    let Ok(user_login) = serde_json::from_str::<String>(event.payload()) else {
+                        return;
+                    };
+                    if let Some(services) = app_handle4.try_state::<Arc<dyn AppServices>>() {
+                        services.clear_temporary_mute(&user_login);
+                    }
+                });
+
+                let app_handle5 = app.clone();
+                app.listen("open-in-player-requested", move |event| {
+                    let Ok(user_login) = serde_json::from_str::<String>(event.payload()) else {
+                        return;
+                    };
+                    if let Some(services) = app_handle5.try_state::<Arc<dyn AppServices>>() {
+                        services.open_in_player(&user_login);
+                    }
+                });
+
+                let app_handle6 = app.clone();
+                app.listen("setting-toggled", move |event| {
+                    let Some(toggle) = serde_json::from_str::<String>(event.payload())
+                        .ok()
+                        .and_then(|key| MenuToggle::from_key(&key))
+                    else {
+                        return;
+                    };
+                    if let Some(services) = app_handle6.try_state::<Arc<dyn AppServices>>() {
+                        services.toggle_setting(toggle);
+                    }
+                });
+
+                let app_handle7 = app.clone();
+                app.listen("favourite-requested", move |event| {
+                    let Ok((user_login, favourite)) =
+                        serde_json::from_str::<(String, bool)>(event.payload())
+                    else {
+                        return;
+                    };
+                    if let Some(services) = app_handle7.try_state::<Arc<dyn AppServices>>() {
+                        services.set_favourite(&user_login, favourite);
+                    }
+                });
+
+                let app_handle8 = app.clone();
+                app.listen("mute-requested", move |event| {
+                    let Ok(user_login) = serde_json::from_str::<String>(event.payload()) else {
+                        return;
+                    };
+                    if let Some(services) = app_handle8.try_state::<Arc<dyn AppServices>>() {
+                        services.mute_today(&user_login);
+                    }
+                });
+
+                let app_handle9 = app.clone();
+                app.listen("add-to-calendar-requested", move |event| {
+                    let Ok(scheduled_id) = serde_json::from_str::<String>(event.payload()) else {
+                        return;
+                    };
+                    if let Some(services) = app_handle9.try_state::<Arc<dyn AppServices>>() {
+                        services.add_to_calendar(&scheduled_id);
+                    }
+                });
             }
         });
 }
@@ -25,6 +25,8 @@ pub fn make_stream(user_id: &str, user_name: &str) -> Stream {
         started_at: Utc::now() - Duration::hours(1),
         thumbnail_url: "https://example.com/thumb.jpg".to_string(),
         tags: vec![],
+        stream_type: "live".to_string(),
+        is_mature: false,
         profile_image_url: String::new(),
     }
 }
@@ -45,6 +47,8 @@ pub fn make_stream_with_game(user_id: &str, game_id: &str, game_name: &str) -> S
         started_at: Utc::now() - Duration::hours(1),
         thumbnail_url: "https://example.com/thumb.jpg".to_string(),
         tags: vec![],
+        stream_type: "live".to_string(),
+        is_mature: false,
         profile_image_url: String::new(),
     }
 }
@@ -2,7 +2,7 @@
 
 mod common;
 
-use twitch_tray::state::AppState;
+use twitch_tray::state::{AppState, ChangeType};
 
 #[tokio::test]
 async fn state_tracks_newly_live_streams() {
@@ -67,7 +67,7 @@ async fn state_authentication_flow() {
 #[tokio::test]
 async fn state_change_notification() {
     let state = AppState::new();
-    let rx = state.subscribe();
+    let mut rx = state.subscribe_changes();
 
     // Make a change
     state
@@ -75,7 +75,7 @@ async fn state_change_notification() {
         .await;
 
     // Should receive notification
-    assert!(rx.has_changed().unwrap());
+    assert_eq!(rx.try_recv().unwrap(), ChangeType::Authentication);
 }
 
 #[tokio::test]
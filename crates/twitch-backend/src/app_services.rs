@@ -1,6 +1,7 @@
 use async_trait::async_trait;
+use std::path::PathBuf;
 
-use crate::config::{Config, FollowedCategory};
+use crate::config::{Config, FollowedCategory, MenuToggle};
 use crate::twitch::{ApiError, Category, FollowedChannel};
 
 #[derive(serde::Serialize, Clone, Debug, PartialEq)]
@@ -44,6 +45,31 @@ pub trait AppServices: Send + Sync {
     async fn refresh_schedules_from_db(&self);
     async fn get_debug_schedule_data(&self, start: i64, end: i64) -> Vec<DebugStreamEntry>;
     async fn get_debug_hotness_data(&self) -> Vec<DebugHotnessEntry>;
+    /// Writes a debug snapshot of the current state to disk, returning its path.
+    async fn save_state_snapshot(&self) -> anyhow::Result<PathBuf>;
+    /// Sends a test notification, ignoring mutes and rate limits.
+    fn send_test_notification(&self) -> anyhow::Result<()>;
+    /// Mutes a streamer's notifications until the end of the day, as the
+    /// notification's "Mute today" button does.
+    fn mute_today(&self, user_login: &str);
+    /// Lifts a streamer's "Mute today" mute before it expires.
+    fn clear_temporary_mute(&self, user_login: &str);
+    /// Flips a setting switched from the tray menu, saves it and refreshes
+    /// the menu.
+    fn toggle_setting(&self, toggle: MenuToggle);
+    /// Adds a streamer to or removes them from favourites, saves it and
+    /// refreshes the menu.
+    fn set_favourite(&self, user_login: &str, favourite: bool);
+    /// Aborts in-flight Twitch requests and stops the backend's state change
+    /// listeners. Called when the app quits.
+    fn shutdown(&self);
+    /// Exports a scheduled stream, by segment id, as an `.ics` file and opens
+    /// it so the default calendar app can import it.
+    fn add_to_calendar(&self, scheduled_id: &str);
+    /// Opens a stream in the configured external player, or the browser if
+    /// none is set. Failing to start the player is reported as an error
+    /// notification.
+    fn open_in_player(&self, user_login: &str);
 }
 
 #[cfg(test)]
@@ -67,6 +93,7 @@ pub mod mock {
         refresh_schedules_count: AtomicUsize,
         debug_call_count: AtomicUsize,
         hotness_call_count: AtomicUsize,
+        snapshot_call_count: AtomicUsize,
     }
 
     impl MockAppServices {
@@ -82,6 +109,7 @@ pub mod mock {
                 refresh_schedules_count: AtomicUsize::new(0),
                 debug_call_count: AtomicUsize::new(0),
                 hotness_call_count: AtomicUsize::new(0),
+                snapshot_call_count: AtomicUsize::new(0),
             }
         }
 
@@ -124,6 +152,10 @@ pub mod mock {
         pub fn hotness_call_count(&self) -> usize {
             self.hotness_call_count.load(Ordering::SeqCst)
         }
+
+        pub fn snapshot_call_count(&self) -> usize {
+            self.snapshot_call_count.load(Ordering::SeqCst)
+        }
     }
 
     #[async_trait]
@@ -173,5 +205,57 @@ pub mod mock {
             self.hotness_call_count.fetch_add(1, Ordering::SeqCst);
             self.hotness_entries.lock().unwrap().clone()
         }
+
+        async fn save_state_snapshot(&self) -> anyhow::Result<PathBuf> {
+            self.snapshot_call_count.fetch_add(1, Ordering::SeqCst);
+            Ok(PathBuf::from("state-snapshot.json"))
+        }
+
+        fn send_test_notification(&self) -> anyhow::Result<()> {
+            Ok(())
+        }
+
+        fn mute_today(&self, user_login: &str) {
+            if let Some(s) = self
+                .config
+                .lock()
+                .unwrap()
+                .streamer_settings
+                .get_mut(user_login)
+            {
+                s.muted_until = Some(crate::notify::end_of_local_day(chrono::Utc::now()));
+            }
+        }
+
+        fn clear_temporary_mute(&self, user_login: &str) {
+            if let Some(s) = self
+                .config
+                .lock()
+                .unwrap()
+                .streamer_settings
+                .get_mut(user_login)
+            {
+                s.muted_until = None;
+            }
+        }
+
+        fn toggle_setting(&self, toggle: MenuToggle) {
+            toggle.toggle(&mut self.config.lock().unwrap());
+        }
+
+        fn set_favourite(&self, user_login: &str, favourite: bool) {
+            crate::config::set_favourite(
+                &mut self.config.lock().unwrap().streamer_settings,
+                user_login,
+                user_login,
+                favourite,
+            );
+        }
+
+        fn shutdown(&self) {}
+
+        fn add_to_calendar(&self, _scheduled_id: &str) {}
+
+        fn open_in_player(&self, _user_login: &str) {}
     }
 }
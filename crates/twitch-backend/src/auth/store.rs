@@ -125,6 +125,14 @@ impl TokenStore {
         })
     }
 
+    /// Creates a token store with a custom path (for testing)
+    #[cfg(test)]
+    pub fn with_path(path: PathBuf) -> Self {
+        Self {
+            inner: FileTokenStore::with_path(path),
+        }
+    }
+
     /// Saves the OAuth token
     pub fn save_token(&self, token: &Token) -> Result<()> {
         let data = serde_json::to_string(token).context("Failed to serialize token")?;
@@ -6,7 +6,8 @@ use tokio::time::{Duration, Instant};
 
 use crate::app_services::AppServices;
 use crate::auth::{TokenStore, CLIENT_ID};
-use crate::config::ConfigManager;
+use crate::category_alerts::CategoryAlerts;
+use crate::config::{is_streamer_muted, ConfigManager, MenuToggle};
 use crate::db::Database;
 use crate::events::BackendEvent;
 use crate::handle::{AuthCommand, BackendHandle, LoginProgress, RawDisplayData};
@@ -14,12 +15,22 @@ use crate::hotness_detection::{
     compute_hotness, compute_hotness_profile, find_nearest_bucket, BucketStats, HotnessConfig,
     HotnessInfo, ViewerObservation,
 };
+use crate::images::ImageCache;
 use crate::notification_dispatcher::NotificationDispatcher;
-use crate::notify::{DesktopNotifier, Notifier, SnoozeRequest, StreamerSettingsRequest};
+use crate::notification_history::{HistoryNotifier, NotificationHistory};
+use crate::notify::error_gate::ErrorGateNotifier;
+use crate::notify::forward::ForwardingNotifier;
+use crate::notify::rate_limit::RateLimitingNotifier;
+use crate::notify::repeat_live::RepeatLiveNotifier;
+use crate::notify::{
+    end_of_local_day, open_stream, DesktopNotifier, ErrorCategory, MuteRequest, MutingNotifier,
+    Notifier, SnoozeRequest, StreamerSettingsRequest,
+};
 use crate::schedule_walker::ScheduleWalker;
 use crate::session::SessionManager;
-use crate::state::AppState;
-use crate::twitch::TwitchClient;
+use crate::state::{AppState, SortOrder};
+use crate::twitch::http::{HttpClient, ReqwestClient};
+use crate::twitch::{CircuitState, TwitchClient};
 use tokio::task::JoinHandle;
 
 /// Age points (in minutes) at which to precompute hotness bucket stats.
@@ -28,6 +39,15 @@ const HOTNESS_AGE_POINTS: &[i64] = &[0, 5, 10, 15, 30, 45, 60, 90, 120, 180, 240
 /// Retention period for viewer observations (30 days in seconds).
 const OBSERVATION_RETENTION_SECS: i64 = 30 * 24 * 3600;
 
+/// Streams fetched per followed category.
+const CATEGORY_STREAMS_LIMIT: usize = 10;
+
+/// Maximum results shown when searching categories in settings.
+const CATEGORY_SEARCH_LIMIT: usize = 10;
+
+/// Recent notifications included in each display snapshot.
+const RECENT_NOTIFICATIONS_LIMIT: usize = 15;
+
 /// Cached hotness profile for a single broadcaster.
 struct CachedHotnessProfile {
     profile: Vec<(i64, BucketStats)>,
@@ -35,15 +55,15 @@ struct CachedHotnessProfile {
 }
 
 /// Internal backend orchestrator.
-pub(crate) struct Backend {
+pub(crate) struct Backend<H: HttpClient = ReqwestClient> {
     pub(crate) state: Arc<AppState>,
     pub(crate) config: Arc<ConfigManager>,
-    pub(crate) client: TwitchClient,
+    pub(crate) client: TwitchClient<H>,
     pub(crate) notifier: Arc<dyn Notifier>,
     pub(crate) db: Database,
 
-    session: SessionManager,
-    walker: Arc<ScheduleWalker>,
+    session: SessionManager<H>,
+    walker: Arc<ScheduleWalker<H>>,
     dispatcher: Arc<NotificationDispatcher>,
 
     auth_cancel_tx: watch::Sender<bool>,
@@ -57,6 +77,34 @@ pub(crate) struct Backend {
     settings_tx: mpsc::UnboundedSender<StreamerSettingsRequest>,
     settings_rx: Arc<Mutex<Option<mpsc::UnboundedReceiver<StreamerSettingsRequest>>>>,
 
+    mute_tx: mpsc::UnboundedSender<MuteRequest>,
+    mute_rx: Arc<Mutex<Option<mpsc::UnboundedReceiver<MuteRequest>>>>,
+
+    toggle_tx: mpsc::UnboundedSender<MenuToggle>,
+    toggle_rx: Arc<Mutex<Option<mpsc::UnboundedReceiver<MenuToggle>>>>,
+
+    /// `(user_login, favourite)` requests from the tray menu
+    favourite_tx: mpsc::UnboundedSender<(String, bool)>,
+    favourite_rx: Arc<Mutex<Option<mpsc::UnboundedReceiver<(String, bool)>>>>,
+
+    /// Ids of scheduled segments to add to the calendar, from the tray menu
+    calendar_tx: mpsc::UnboundedSender<String>,
+    calendar_rx: Arc<Mutex<Option<mpsc::UnboundedReceiver<String>>>>,
+
+    /// On-disk image cache, shared with the notifier for streamer avatars.
+    images: Arc<ImageCache<H>>,
+
+    /// Notifications that reached the desktop, for the tray's recent list.
+    history: Arc<NotificationHistory>,
+
+    /// Rate limit stage of `notifier`, flushed periodically so overflow
+    /// summaries go out when the window ends.
+    rate_limiter: Arc<RateLimitingNotifier>,
+
+    /// Error throttling stage of `notifier`, told when each kind of failure
+    /// clears so the next one is shown straight away.
+    error_gate: Arc<ErrorGateNotifier>,
+
     /// In-memory cache for profile image URLs (user_id -> (url, fetched_at)).
     profile_image_cache: Arc<std::sync::Mutex<HashMap<String, (String, Instant)>>>,
 
@@ -66,25 +114,128 @@ pub(crate) struct Backend {
     /// In-memory cache for hotness profiles (broadcaster user_id -> profile).
     /// Populated when a stream goes live, evicted when it goes offline.
     hotness_cache: Arc<std::sync::Mutex<HashMap<String, CachedHotnessProfile>>>,
+
+    /// Followed-category streams above their viewer alert threshold, so each
+    /// is alerted once per crossing.
+    category_alerts: Arc<std::sync::Mutex<CategoryAlerts>>,
+
+    /// True while Twitch is unreachable, so recovery is reported once per
+    /// outage.
+    api_outage: Arc<std::sync::atomic::AtomicBool>,
+    /// True while a circuit breaker pause is being reported, which stands in
+    /// for the unreachable warning until it's over.
+    connection_outage: Arc<std::sync::atomic::AtomicBool>,
 }
 
 impl Backend {
     fn new() -> anyhow::Result<Self> {
+        let config = Arc::new(ConfigManager::new()?);
+        let client = TwitchClient::new(CLIENT_ID.to_string());
+        let db = Database::new(&ConfigManager::config_dir()?.join("data.db"))?;
+        let images = Arc::new(ImageCache::new()?);
+        let notifier_images = images.clone();
+        let history = Arc::new(NotificationHistory::load(&ConfigManager::state_dir()?));
+        let forced_backend = config.get().notification_backend;
+        Ok(Self::with_parts(
+            config,
+            client,
+            db,
+            TokenStore::new()?,
+            images,
+            history,
+            |snooze_tx, settings_tx, mute_tx| {
+                Arc::new(DesktopNotifier::new(
+                    snooze_tx,
+                    settings_tx,
+                    mute_tx,
+                    notifier_images,
+                    forced_backend.as_deref(),
+                ))
+            },
+        ))
+    }
+}
+
+impl<H: HttpClient + Clone + 'static> Backend<H> {
+    /// Wires the session, schedule walker, and notification dispatcher around
+    /// the given client. `make_notifier` receives the senders for snooze and
+    /// streamer-settings requests raised from notification actions.
+    fn with_parts(
+        config: Arc<ConfigManager>,
+        client: TwitchClient<H>,
+        db: Database,
+        store: TokenStore,
+        images: Arc<ImageCache<H>>,
+        history: Arc<NotificationHistory>,
+        make_notifier: impl FnOnce(
+            mpsc::UnboundedSender<SnoozeRequest>,
+            mpsc::UnboundedSender<StreamerSettingsRequest>,
+            mpsc::UnboundedSender<MuteRequest>,
+        ) -> Arc<dyn Notifier>,
+    ) -> Self {
         use std::sync::atomic::AtomicBool;
         use tokio::sync::RwLock;
 
-        let config = Arc::new(ConfigManager::new()?);
         let state = AppState::new();
         let (snooze_tx, snooze_rx) = mpsc::unbounded_channel();
         let (settings_tx, settings_rx) = mpsc::unbounded_channel();
-        let notifier: Arc<dyn Notifier> =
-            Arc::new(DesktopNotifier::new(snooze_tx.clone(), settings_tx.clone()));
-        let client = TwitchClient::new(CLIENT_ID.to_string());
-        let db = Database::new(&ConfigManager::config_dir()?.join("data.db"))?;
+        let (mute_tx, mute_rx) = mpsc::unbounded_channel();
+        let (toggle_tx, toggle_rx) = mpsc::unbounded_channel();
+        let (favourite_tx, favourite_rx) = mpsc::unbounded_channel();
+        let (calendar_tx, calendar_rx) = mpsc::unbounded_channel();
+        // Mutes, the repeat window, the rate limit, whether to announce error
+        // recovery and the forwarding URL are read from the live config so
+        // changes apply immediately. Muted notifications never count as
+        // announced or against the limit, and only what is actually shown is
+        // forwarded and goes into the history.
+        let forward_config = config.clone();
+        let forwarding = Arc::new(ForwardingNotifier::new(
+            Arc::new(HistoryNotifier::new(
+                make_notifier(snooze_tx.clone(), settings_tx.clone(), mute_tx.clone()),
+                history.clone(),
+            )),
+            client.http().clone(),
+            Box::new(move || forward_config.get().notification_forward.url.clone()),
+        ));
+        let limit_config = config.clone();
+        let rate_limiter = Arc::new(RateLimitingNotifier::new(
+            forwarding,
+            Box::new(move || limit_config.get().notify_rate_limit_per_min),
+        ));
+        // Connection pauses are opt-in, so once one was shown its end is
+        // always announced
+        let recovery_config = config.clone();
+        let error_gate = Arc::new(ErrorGateNotifier::new(
+            rate_limiter.clone(),
+            // A player starting again is evident, so that recovery isn't announced
+            Box::new(move |category| match category {
+                ErrorCategory::Connection => true,
+                ErrorCategory::Player => false,
+                ErrorCategory::Api | ErrorCategory::Auth => {
+                    recovery_config.get().notify_on_error_recovery
+                }
+            }),
+        ));
+        let repeat_config = config.clone();
+        let repeat_live = Arc::new(RepeatLiveNotifier::new(
+            error_gate.clone(),
+            Box::new(move || {
+                chrono::Duration::minutes(repeat_config.get().live_repeat_window_min as i64)
+            }),
+        ));
+        let mute_config = config.clone();
+        let notifier: Arc<dyn Notifier> = Arc::new(MutingNotifier::new(
+            repeat_live,
+            Box::new(move |login| {
+                let cfg = mute_config.get();
+                cfg.notifications_paused
+                    || is_streamer_muted(&cfg.streamer_settings, login, Utc::now())
+            }),
+        ));
         let (auth_cancel_tx, auth_cancel_rx) = watch::channel(false);
 
         let (session, login_progress_rx) = SessionManager::new(
-            TokenStore::new()?,
+            store,
             client.clone(),
             state.clone(),
             db.clone(),
@@ -99,6 +250,7 @@ impl Backend {
             state.clone(),
             config.clone(),
             session.clone(),
+            notifier.clone(),
         ));
 
         let dispatcher = Arc::new(NotificationDispatcher::new(
@@ -107,7 +259,7 @@ impl Backend {
             session.initial_load_done.clone(),
         ));
 
-        Ok(Self {
+        Self {
             state,
             config,
             client,
@@ -123,10 +275,25 @@ impl Backend {
             snooze_rx: Arc::new(Mutex::new(Some(snooze_rx))),
             settings_tx,
             settings_rx: Arc::new(Mutex::new(Some(settings_rx))),
+            mute_tx,
+            mute_rx: Arc::new(Mutex::new(Some(mute_rx))),
+            toggle_tx,
+            toggle_rx: Arc::new(Mutex::new(Some(toggle_rx))),
+            favourite_tx,
+            favourite_rx: Arc::new(Mutex::new(Some(favourite_rx))),
+            calendar_tx,
+            calendar_rx: Arc::new(Mutex::new(Some(calendar_rx))),
+            images,
+            history,
+            rate_limiter,
+            error_gate,
             profile_image_cache: Arc::new(std::sync::Mutex::new(HashMap::new())),
             box_art_cache: Arc::new(std::sync::Mutex::new(HashMap::new())),
             hotness_cache: Arc::new(std::sync::Mutex::new(HashMap::new())),
-        })
+            category_alerts: Arc::new(std::sync::Mutex::new(CategoryAlerts::default())),
+            api_outage: Arc::new(AtomicBool::new(false)),
+            connection_outage: Arc::new(AtomicBool::new(false)),
+        }
     }
 
     async fn with_retry<F, Fut, T>(&self, f: F) -> Result<T, crate::twitch::ApiError>
@@ -197,6 +364,18 @@ impl Backend {
             }
         }));
 
+        // Rate limit flush task — reports suppressed notifications once the
+        // window ends, even if nothing else is sent
+        let backend = self.clone();
+        handles.push(tokio::spawn(async move {
+            loop {
+                tokio::time::sleep(Duration::from_secs(5)).await;
+                if let Err(e) = backend.rate_limiter.flush() {
+                    tracing::warn!("Failed to send overflow summary: {}", e);
+                }
+            }
+        }));
+
         // Schedule queue walker
         handles.push(self.walker.clone().start());
 
@@ -300,6 +479,8 @@ impl Backend {
                             display_name: request.display_name.clone(),
                             importance: crate::config::StreamerImportance::Normal,
                             hotness_z_threshold_override: None,
+                            notify_on_offline: false,
+                            muted_until: None,
                         },
                     );
                     if let Err(e) = backend.config.save(cfg) {
@@ -314,25 +495,123 @@ impl Backend {
             }
         }));
 
-        // State change listener task — pushes RawDisplayData on any state change
+        // Mute request task — saves "Mute today" mutes to the config and
+        // refreshes the menu, which lists them
         let backend = self.clone();
-        let display_tx_state = display_tx.clone();
+        let display_tx_mute = display_tx.clone();
         handles.push(tokio::spawn(async move {
-            let mut rx = backend.state.subscribe();
+            let Some(mut rx) = backend.mute_rx.lock().await.take() else {
+                tracing::warn!("Mute receiver already taken");
+                return;
+            };
 
-            while rx.changed().await.is_ok() {
-                if rx.borrow().is_none() {
+            while let Some(mut request) = rx.recv().await {
+                if request.display_name.is_empty() {
+                    request.display_name = backend.display_name_of(&request.user_login).await;
+                }
+                if let Err(e) = backend.set_temporary_mute(&request) {
+                    tracing::error!("Failed to save mute for {}: {}", request.user_login, e);
+                    continue;
+                }
+                backend.push_display_state(&display_tx_mute).await;
+            }
+        }));
+
+        // Menu toggle task — flips settings switched from the tray and
+        // refreshes the menu, which shows their checkboxes
+        let backend = self.clone();
+        let display_tx_toggle = display_tx.clone();
+        handles.push(tokio::spawn(async move {
+            let Some(mut rx) = backend.toggle_rx.lock().await.take() else {
+                tracing::warn!("Toggle receiver already taken");
+                return;
+            };
+
+            while let Some(toggle) = rx.recv().await {
+                let mut cfg = backend.config.get();
+                toggle.toggle(&mut cfg);
+                tracing::info!("Set {} to {}", toggle.key(), toggle.is_on(&cfg));
+                if let Err(e) = backend.config.save(cfg) {
+                    tracing::error!("Failed to save {}: {}", toggle.key(), e);
                     continue;
                 }
+                backend.push_display_state(&display_tx_toggle).await;
+            }
+        }));
+
+        // Favourite task — adds and removes favourites from the tray and
+        // refreshes the menu, which pins them to the top
+        let backend = self.clone();
+        let display_tx_favourite = display_tx.clone();
+        handles.push(tokio::spawn(async move {
+            let Some(mut rx) = backend.favourite_rx.lock().await.take() else {
+                tracing::warn!("Favourite receiver already taken");
+                return;
+            };
+
+            while let Some((user_login, favourite)) = rx.recv().await {
+                if let Err(e) = backend.set_favourite(&user_login, favourite).await {
+                    tracing::error!("Failed to save favourite {}: {}", user_login, e);
+                    continue;
+                }
+                backend.push_display_state(&display_tx_favourite).await;
+            }
+        }));
+
+        // Calendar task — exports scheduled streams picked in the tray. The
+        // segment is looked up again as the menu may be a minute old.
+        let backend = self.clone();
+        handles.push(tokio::spawn(async move {
+            let Some(mut rx) = backend.calendar_rx.lock().await.take() else {
+                tracing::warn!("Calendar receiver already taken");
+                return;
+            };
+
+            while let Some(scheduled_id) = rx.recv().await {
+                let scheduled = backend.state.get_scheduled_streams().await;
+                let Some(scheduled) = scheduled.iter().find(|s| s.id == scheduled_id) else {
+                    tracing::warn!("Scheduled stream {} is no longer listed", scheduled_id);
+                    continue;
+                };
+                match crate::calendar::open_in_calendar(scheduled) {
+                    Ok(path) => tracing::info!("Exported {} to calendar", path.display()),
+                    Err(e) => tracing::error!("Failed to add schedule to calendar: {:#}", e),
+                }
+            }
+        }));
+
+        // State change listener task — pushes RawDisplayData on any state
+        // change, until the state is closed on shutdown
+        let backend = self.clone();
+        let display_tx_state = display_tx.clone();
+        handles.push(tokio::spawn(async move {
+            let mut rx = backend.state.subscribe_changes();
+
+            loop {
+                match rx.recv().await {
+                    // A lagged receiver missed changes, which a push covers too
+                    Ok(_) | Err(broadcast::error::RecvError::Lagged(_)) => {}
+                    Err(broadcast::error::RecvError::Closed) => break,
+                }
 
                 // Debounce: coalesce rapid-fire state changes
                 tokio::time::sleep(Duration::from_millis(500)).await;
-                let _ = *rx.borrow_and_update();
+                while let Ok(_) | Err(broadcast::error::TryRecvError::Lagged(_)) = rx.try_recv() {}
 
                 backend.push_display_state(&display_tx_state).await;
             }
         }));
 
+        // Notification history listener task — the tray lists recent notifications
+        let backend = self.clone();
+        let display_tx_history = display_tx.clone();
+        handles.push(tokio::spawn(async move {
+            let mut rx = backend.history.subscribe();
+            while rx.changed().await.is_ok() {
+                backend.push_display_state(&display_tx_history).await;
+            }
+        }));
+
         // Notification listener task
         handles.push(
             self.dispatcher
@@ -371,6 +650,7 @@ impl Backend {
     /// Collects current state and sends a RawDisplayData snapshot.
     async fn push_display_state(&self, display_tx: &watch::Sender<RawDisplayData>) {
         let cfg = self.config.get();
+        self.sync_state_options(&cfg).await;
         let scheduled_streams = self.state.get_scheduled_streams().await;
 
         // Ensure profile images are cached for scheduled broadcasters
@@ -403,7 +683,10 @@ impl Backend {
                 .map(|(id, (url, _))| (id.clone(), url.clone()))
                 .collect()
         };
-        let live_streams = self.state.get_followed_streams().await;
+        let live_streams = self
+            .state
+            .get_followed_streams_sorted(SortOrder::Viewers)
+            .await;
 
         // Evaluate hotness for all live streams
         let hotness_results = self.evaluate_hotness(&live_streams);
@@ -415,20 +698,41 @@ impl Backend {
 
         let raw = RawDisplayData {
             is_authenticated: self.state.is_authenticated().await,
+            user_login: self.state.user_login().await,
             live_streams,
             scheduled_streams,
             schedules_loaded: self.state.schedules_loaded().await,
             followed_channels: self.state.get_followed_channels().await,
             followed_categories: cfg.followed_categories.clone(),
+            played_categories: self.played_categories(&cfg).await,
             category_streams: self.state.get_category_streams().await,
             config: cfg,
             profile_image_urls,
             box_art_urls,
             hot_stream_ids,
+            viewer_trends: self.state.viewer_trends().await,
+            first_seen_at: self.state.first_seen_times().await,
+            api_health: self.client.health(),
+            recent_notifications: self.history.recent(RECENT_NOTIFICATIONS_LIMIT),
+            notification_backend: self.notifier.backend_name(),
         };
         let _ = display_tx.send(raw);
     }
 
+    /// Applies config-driven options (favourites, stored stream details) to the state.
+    async fn sync_state_options(&self, cfg: &crate::config::Config) {
+        self.state.set_keep_stream_details(cfg.keep_stream_details);
+        self.state
+            .set_favourites(
+                cfg.streamer_settings
+                    .iter()
+                    .filter(|(_, s)| s.importance == crate::config::StreamerImportance::Favourite)
+                    .map(|(login, _)| login.clone())
+                    .collect(),
+            )
+            .await;
+    }
+
     async fn tick_stream_poll(&self, now: DateTime<Utc>) -> bool {
         if !self.state.is_authenticated().await {
             return false;
@@ -442,15 +746,167 @@ impl Backend {
             Some(last) => (now - last).num_seconds() >= poll_interval_secs as i64,
         };
 
+        // Polling inside a rate-limit penalty would only fail; try next tick
+        if let Some(resume_at) = self.client.next_allowed_at() {
+            if should_refresh {
+                tracing::debug!("Rate limited until {}, skipping poll", resume_at);
+            }
+            return false;
+        }
+
         if should_refresh {
+            self.sync_state_options(&self.config.get()).await;
             self.refresh_followed_streams().await;
             self.refresh_category_streams().await;
             self.refresh_schedules_from_db().await;
+            // A reported pause covers Twitch being unreachable, so it goes first
+            self.report_connection(self.client.circuit_state());
+            self.warn_if_unreachable(&self.client.health());
         }
 
         should_refresh
     }
 
+    /// Saves a temporary mute to the config, adding the streamer to the
+    /// settings if needed, or lifts it when the request has no end time
+    fn set_temporary_mute(&self, request: &MuteRequest) -> anyhow::Result<()> {
+        let mut cfg = self.config.get();
+        let existing = cfg
+            .streamer_settings
+            .keys()
+            .find(|login| login.eq_ignore_ascii_case(&request.user_login))
+            .cloned();
+        match (existing, request.until) {
+            (Some(login), until) => {
+                if let Some(settings) = cfg.streamer_settings.get_mut(&login) {
+                    settings.muted_until = until;
+                }
+            }
+            (None, Some(until)) => {
+                cfg.streamer_settings.insert(
+                    request.user_login.clone(),
+                    crate::config::StreamerSettings {
+                        display_name: request.display_name.clone(),
+                        importance: crate::config::StreamerImportance::Normal,
+                        hotness_z_threshold_override: None,
+                        notify_on_offline: false,
+                        muted_until: Some(until),
+                    },
+                );
+            }
+            (None, None) => return Ok(()),
+        }
+        match request.until {
+            Some(until) => tracing::info!("Muted {} until {}", request.display_name, until),
+            None => tracing::info!("Unmuted {}", request.user_login),
+        }
+        self.config.save(cfg)
+    }
+
+    /// Looks up a streamer's display name from the live streams or followed
+    /// channels, falling back to the login
+    async fn display_name_of(&self, user_login: &str) -> String {
+        match self
+            .state
+            .get_followed_streams()
+            .await
+            .into_iter()
+            .find(|s| s.user_login == user_login)
+        {
+            Some(stream) => stream.user_name,
+            None => self
+                .state
+                .get_followed_channels()
+                .await
+                .into_iter()
+                .find(|c| c.broadcaster_login == user_login)
+                .map_or_else(|| user_login.to_string(), |c| c.broadcaster_name),
+        }
+    }
+
+    /// Adds or removes a favourite, naming new entries after the streamer
+    async fn set_favourite(&self, user_login: &str, favourite: bool) -> anyhow::Result<()> {
+        let display_name = self.display_name_of(user_login).await;
+        let mut cfg = self.config.get();
+        crate::config::set_favourite(
+            &mut cfg.streamer_settings,
+            user_login,
+            &display_name,
+            favourite,
+        );
+        if favourite {
+            tracing::info!("Added {} to favourites", display_name);
+        } else {
+            tracing::info!("Removed {} from favourites", user_login);
+        }
+        self.config.save(cfg)
+    }
+
+    /// Reports Twitch being unreachable on every check, leaving the error gate
+    /// to throttle repeats, and resolves the outage once requests get through
+    /// again. Stays quiet while a connection pause is reported instead.
+    fn warn_if_unreachable(&self, health: &crate::twitch::ApiHealth) {
+        use std::sync::atomic::Ordering;
+
+        if health.is_unreachable() && self.connection_outage.load(Ordering::SeqCst) {
+            return;
+        }
+        if !health.is_unreachable() {
+            if self.api_outage.swap(false, Ordering::SeqCst) {
+                tracing::info!("Twitch API reachable again");
+                if let Err(e) = self.error_gate.resolve(ErrorCategory::Api) {
+                    tracing::error!("Notification error: {}", e);
+                }
+            }
+            return;
+        }
+        self.api_outage.store(true, Ordering::SeqCst);
+        let reason = health
+            .last_error
+            .as_ref()
+            .map_or_else(String::new, |e| e.message.clone());
+        if let Err(e) = self
+            .notifier
+            .error(ErrorCategory::Api, &format!("Twitch unreachable: {reason}"))
+        {
+            tracing::error!("Notification error: {}", e);
+        }
+    }
+
+    /// Reports requests being paused by the circuit breaker, if enabled, and
+    /// announces the connection coming back once a pause was reported.
+    ///
+    /// A pause during an outage already reported as Twitch being unreachable
+    /// isn't reported again, so one outage shows one notification.
+    fn report_connection(&self, circuit: CircuitState) {
+        use std::sync::atomic::Ordering;
+
+        match circuit {
+            CircuitState::Open { .. } => {
+                if !self.config.get().notify_on_connection_issues
+                    || self.api_outage.load(Ordering::SeqCst)
+                {
+                    return;
+                }
+                self.connection_outage.store(true, Ordering::SeqCst);
+                if let Err(e) = self.notifier.error(
+                    ErrorCategory::Connection,
+                    "Twitch requests paused after repeated failures, alerts may be delayed",
+                ) {
+                    tracing::error!("Notification error: {}", e);
+                }
+            }
+            // The probe request decides whether the pause is over
+            CircuitState::HalfOpen => {}
+            CircuitState::Closed => {
+                self.connection_outage.store(false, Ordering::SeqCst);
+                if let Err(e) = self.error_gate.resolve(ErrorCategory::Connection) {
+                    tracing::error!("Notification error: {}", e);
+                }
+            }
+        }
+    }
+
     async fn tick_followed_channels(
         &self,
         now: DateTime<Utc>,
@@ -470,11 +926,24 @@ impl Backend {
             return false;
         }
 
-        if let Err(e) = self.session.load_followed_channels().await {
-            tracing::warn!("Failed to refresh followed channels: {}", e);
-            false
-        } else {
-            true
+        // Return memory left over from earlier, larger data sets
+        self.state.compact().await;
+
+        match self.session.load_followed_channels().await {
+            Ok(diff) => {
+                if !diff.is_empty() && last_refresh.is_some() {
+                    tracing::info!(
+                        "Follow list changed: {} followed, {} unfollowed",
+                        diff.added.len(),
+                        diff.removed.len()
+                    );
+                }
+                true
+            }
+            Err(e) => {
+                tracing::warn!("Failed to refresh followed channels: {}", e);
+                false
+            }
         }
     }
 
@@ -507,8 +976,18 @@ impl Backend {
             tracing::error!("Failed to record viewer observations: {}", e);
         }
 
-        // Populate cache for newly live streams
-        for stream in &event.newly_live {
+        // Populate cache for live streams without a profile. Not just newly live
+        // ones: a stream that missed a poll is evicted below but isn't newly live
+        // when it reappears.
+        let missing: Vec<_> = {
+            let cache = self.hotness_cache.lock().unwrap();
+            event
+                .streams
+                .iter()
+                .filter(|s| !cache.contains_key(&s.user_id))
+                .collect()
+        };
+        for stream in missing {
             let broadcaster_id: i64 = match stream.user_id.parse() {
                 Ok(id) => id,
                 Err(_) => continue,
@@ -646,7 +1125,13 @@ impl Backend {
         results
     }
 
+    /// Refreshes everything after logging in or restoring the session.
+    ///
+    /// Channels remembered as having no schedule are forgotten, so a full
+    /// refresh asks about every channel again when it is next due.
     pub(crate) async fn refresh_all_data(&self) {
+        self.client.forget_missing_schedules();
+        self.sync_state_options(&self.config.get()).await;
         self.refresh_followed_streams().await;
         self.refresh_schedules_from_db().await;
         self.refresh_category_streams().await;
@@ -669,6 +1154,7 @@ impl Backend {
 
         // Enrich streams with profile image URLs from the Users API
         self.enrich_with_profile_images(&mut streams).await;
+        self.prefetch_avatars(&streams);
 
         self.session.record_live_refresh().await;
         self.state.set_followed_streams(streams).await;
@@ -702,32 +1188,23 @@ impl Backend {
             return;
         }
 
-        // Fetch in batches of 100 (Twitch API limit)
+        // The client batches requests and serves repeat lookups from its own cache
         let uncached_refs: Vec<&str> = uncached_ids
             .iter()
             .map(std::string::String::as_str)
             .collect();
-        let mut fetched = HashMap::new();
-        for chunk in uncached_refs.chunks(100) {
-            match self
-                .with_retry(|| self.client.get_users_by_ids(chunk))
-                .await
-            {
-                Ok(users) => {
-                    for user in users {
-                        fetched.insert(user.id, user.profile_image_url);
-                    }
-                }
-                Err(e) => {
-                    tracing::warn!("Failed to fetch user profiles: {}", e);
+        match self
+            .with_retry(|| self.client.get_user_profiles(&uncached_refs))
+            .await
+        {
+            Ok(fetched) => {
+                let mut cache = self.profile_image_cache.lock().unwrap();
+                for (id, url) in fetched {
+                    cache.insert(id, (url, now));
                 }
             }
-        }
-
-        if !fetched.is_empty() {
-            let mut cache = self.profile_image_cache.lock().unwrap();
-            for (id, url) in fetched {
-                cache.insert(id, (url, now));
+            Err(e) => {
+                tracing::warn!("Failed to fetch user profiles: {}", e);
             }
         }
     }
@@ -765,25 +1242,23 @@ impl Backend {
             .map(std::string::String::as_str)
             .collect();
         let mut fetched = HashMap::new();
-        for chunk in uncached_refs.chunks(100) {
-            match self
-                .with_retry(|| self.client.get_games_by_ids(chunk))
-                .await
-            {
-                Ok(games) => {
-                    for game in games {
-                        // Replace template placeholders with fixed dimensions (144x192)
-                        let url = game
-                            .box_art_url
-                            .replace("{width}", "144")
-                            .replace("{height}", "192");
-                        fetched.insert(game.id, url);
-                    }
-                }
-                Err(e) => {
-                    tracing::warn!("Failed to fetch game box art: {}", e);
+        match self
+            .with_retry(|| self.client.get_games_by_ids(&uncached_refs))
+            .await
+        {
+            Ok(games) => {
+                for game in games {
+                    // Replace template placeholders with fixed dimensions (144x192)
+                    let url = game
+                        .box_art_url
+                        .replace("{width}", "144")
+                        .replace("{height}", "192");
+                    fetched.insert(game.id, url);
                 }
             }
+            Err(e) => {
+                tracing::warn!("Failed to fetch game box art: {}", e);
+            }
         }
 
         if !fetched.is_empty() {
@@ -806,12 +1281,68 @@ impl Backend {
         }
     }
 
+    /// Downloads avatars that aren't on disk yet in the background, so they
+    /// are ready to use as notification icons without delaying notifications
+    fn prefetch_avatars(&self, streams: &[crate::twitch::Stream]) -> Option<JoinHandle<()>> {
+        let urls: Vec<String> = streams
+            .iter()
+            .map(|s| &s.profile_image_url)
+            .filter(|url| !url.is_empty() && self.images.cached_path(url).is_none())
+            .cloned()
+            .collect();
+        if urls.is_empty() {
+            return None;
+        }
+
+        let images = self.images.clone();
+        Some(tokio::spawn(async move {
+            for url in urls {
+                if let Err(e) = images.fetch(&url).await {
+                    tracing::debug!("Failed to prefetch avatar {}: {}", url, e);
+                }
+            }
+        }))
+    }
+
     pub(crate) async fn refresh_schedules_from_db(&self) {
         self.walker.refresh_schedules_from_db().await;
     }
 
+    /// Games followed streamers are playing that aren't followed categories,
+    /// by name, or none unless `show_played_categories` is on
+    async fn played_categories(
+        &self,
+        cfg: &crate::config::Config,
+    ) -> Vec<crate::config::FollowedCategory> {
+        if !cfg.show_played_categories {
+            return Vec::new();
+        }
+        let mut played: Vec<crate::config::FollowedCategory> = self
+            .state
+            .tracked_categories()
+            .await
+            .into_iter()
+            .filter(|(id, _)| !cfg.followed_categories.iter().any(|c| &c.id == id))
+            .map(|(id, name)| crate::config::FollowedCategory {
+                id,
+                name,
+                tags: Vec::new(),
+                viewer_alert: None,
+            })
+            .collect();
+        played.sort_by_cached_key(|c| c.name.to_lowercase());
+        played
+    }
+
     pub(crate) async fn refresh_category_streams(&self) {
-        let categories = self.config.get().followed_categories;
+        let cfg = self.config.get();
+        let mut categories = self.played_categories(&cfg).await;
+        categories.splice(0..0, cfg.followed_categories);
+
+        // Games nobody is playing any more would otherwise linger
+        let listed: std::collections::HashSet<String> =
+            categories.iter().map(|c| c.id.clone()).collect();
+        self.state.retain_category_streams(&listed).await;
         if categories.is_empty() {
             return;
         }
@@ -822,7 +1353,14 @@ impl Backend {
         for category in &categories {
             let cat_id = category.id.clone();
             let mut streams = match self
-                .with_retry(|| self.client.get_streams_by_category(&cat_id, lang_ref))
+                .with_retry(|| {
+                    self.client.get_streams_by_category_with_tags(
+                        &cat_id,
+                        lang_ref,
+                        &category.tags,
+                        CATEGORY_STREAMS_LIMIT,
+                    )
+                })
                 .await
             {
                 Ok(streams) => streams,
@@ -837,6 +1375,7 @@ impl Backend {
             };
 
             self.enrich_with_profile_images(&mut streams).await;
+            self.alert_big_category_streams(category, &streams);
 
             self.state
                 .set_category_streams(category.id.clone(), streams)
@@ -844,6 +1383,31 @@ impl Backend {
         }
     }
 
+    /// Notifies for streams in `category` that have just reached its viewer
+    /// alert threshold, if it has one
+    fn alert_big_category_streams(
+        &self,
+        category: &crate::config::FollowedCategory,
+        streams: &[crate::twitch::Stream],
+    ) {
+        let mut alerts = self
+            .category_alerts
+            .lock()
+            .unwrap_or_else(std::sync::PoisonError::into_inner);
+        let Some(threshold) = category.viewer_alert else {
+            alerts.forget(&category.id);
+            return;
+        };
+        let crossed = alerts.crossed(&category.id, threshold, streams);
+        drop(alerts);
+
+        for stream in &crossed {
+            if let Err(e) = self.notifier.category_stream_alert(stream, threshold) {
+                tracing::error!("Notification error: {}", e);
+            }
+        }
+    }
+
     async fn handle_login(
         &self,
         event_tx: &broadcast::Sender<BackendEvent>,
@@ -857,12 +1421,17 @@ impl Backend {
                 let _ = event_tx.send(BackendEvent::AuthStateChanged {
                     is_authenticated: true,
                 });
+                if let Err(e) = self.error_gate.resolve(ErrorCategory::Auth) {
+                    tracing::error!("Notification error: {}", e);
+                }
                 self.refresh_all_data().await;
                 self.push_display_state(display_tx).await;
             }
             Err(e) => {
                 tracing::error!("Authentication failed: {}", e);
-                let _ = self.notifier.error(&format!("Authentication failed: {e}"));
+                let _ = self
+                    .notifier
+                    .error(ErrorCategory::Auth, &format!("Authentication failed: {e}"));
             }
         }
     }
@@ -879,6 +1448,27 @@ impl Backend {
         self.push_display_state(display_tx).await;
     }
 
+    /// Writes a timestamped JSON snapshot of the state to the user's state
+    /// directory (falling back to the config directory) and returns its path.
+    pub(crate) async fn save_state_snapshot(&self) -> anyhow::Result<std::path::PathBuf> {
+        let dir = ConfigManager::state_dir()?;
+        std::fs::create_dir_all(&dir)?;
+
+        let path = dir.join(format!(
+            "state-snapshot-{}.json",
+            Utc::now().format("%Y%m%d-%H%M%S")
+        ));
+        let mut snapshot = self.state.snapshot().await;
+        snapshot.api_metrics = self.client.metrics();
+        let mut writer = std::io::BufWriter::new(std::fs::File::create(&path)?);
+        serde_json::to_writer_pretty(&mut writer, &snapshot)?;
+        // Dropping the writer would swallow a failed final write
+        std::io::Write::flush(&mut writer)?;
+
+        tracing::info!("Wrote state snapshot to {}", path.display());
+        Ok(path)
+    }
+
     pub(crate) async fn get_debug_hotness_data(
         &self,
     ) -> Vec<crate::app_services::DebugHotnessEntry> {
@@ -967,7 +1557,7 @@ impl Backend {
 }
 
 #[async_trait::async_trait]
-impl AppServices for Backend {
+impl<H: HttpClient + Clone + 'static> AppServices for Backend<H> {
     fn get_config(&self) -> crate::config::Config {
         self.config.get()
     }
@@ -983,7 +1573,9 @@ impl AppServices for Backend {
         &self,
         query: &str,
     ) -> Result<Vec<crate::twitch::Category>, crate::twitch::ApiError> {
-        self.client.search_categories(query).await
+        self.client
+            .search_categories(query, CATEGORY_SEARCH_LIMIT)
+            .await
     }
 
     fn get_followed_categories(&self) -> Vec<crate::config::FollowedCategory> {
@@ -1013,9 +1605,79 @@ impl AppServices for Backend {
     async fn get_debug_hotness_data(&self) -> Vec<crate::app_services::DebugHotnessEntry> {
         Backend::get_debug_hotness_data(self).await
     }
+
+    async fn save_state_snapshot(&self) -> anyhow::Result<std::path::PathBuf> {
+        Backend::save_state_snapshot(self).await
+    }
+
+    fn send_test_notification(&self) -> anyhow::Result<()> {
+        self.notifier.test()
+    }
+
+    fn mute_today(&self, user_login: &str) {
+        let _ = self.mute_tx.send(MuteRequest {
+            user_login: user_login.to_string(),
+            display_name: String::new(),
+            until: Some(end_of_local_day(Utc::now())),
+        });
+    }
+
+    fn clear_temporary_mute(&self, user_login: &str) {
+        let _ = self.mute_tx.send(MuteRequest {
+            user_login: user_login.to_string(),
+            display_name: user_login.to_string(),
+            until: None,
+        });
+    }
+
+    fn toggle_setting(&self, toggle: MenuToggle) {
+        let _ = self.toggle_tx.send(toggle);
+    }
+
+    fn set_favourite(&self, user_login: &str, favourite: bool) {
+        let _ = self.favourite_tx.send((user_login.to_string(), favourite));
+    }
+
+    fn shutdown(&self) {
+        self.client.cancel_requests();
+        self.state.close();
+    }
+
+    fn add_to_calendar(&self, scheduled_id: &str) {
+        let _ = self.calendar_tx.send(scheduled_id.to_string());
+    }
+
+    fn open_in_player(&self, user_login: &str) {
+        let Some(template) = self
+            .config
+            .get()
+            .external_player
+            .filter(|t| !t.trim().is_empty())
+        else {
+            open_stream(user_login);
+            return;
+        };
+
+        match crate::player::open_in_player(&template, user_login) {
+            Ok(()) => {
+                if let Err(e) = self.error_gate.resolve(ErrorCategory::Player) {
+                    tracing::error!("Notification error: {}", e);
+                }
+            }
+            Err(e) => {
+                tracing::error!("Failed to open {} in external player: {:#}", user_login, e);
+                if let Err(e) = self.notifier.error(
+                    ErrorCategory::Player,
+                    &format!("Couldn't open stream in player: {e:#}"),
+                ) {
+                    tracing::error!("Notification error: {}", e);
+                }
+            }
+        }
+    }
 }
 
-impl Clone for Backend {
+impl<H: HttpClient + Clone> Clone for Backend<H> {
     fn clone(&self) -> Self {
         Self {
             state: self.state.clone(),
@@ -1033,9 +1695,24 @@ impl Clone for Backend {
             snooze_rx: self.snooze_rx.clone(),
             settings_tx: self.settings_tx.clone(),
             settings_rx: self.settings_rx.clone(),
+            mute_tx: self.mute_tx.clone(),
+            mute_rx: self.mute_rx.clone(),
+            toggle_tx: self.toggle_tx.clone(),
+            toggle_rx: self.toggle_rx.clone(),
+            favourite_tx: self.favourite_tx.clone(),
+            favourite_rx: self.favourite_rx.clone(),
+            calendar_tx: self.calendar_tx.clone(),
+            calendar_rx: self.calendar_rx.clone(),
+            images: self.images.clone(),
+            history: self.history.clone(),
+            rate_limiter: self.rate_limiter.clone(),
+            error_gate: self.error_gate.clone(),
             profile_image_cache: self.profile_image_cache.clone(),
             box_art_cache: self.box_art_cache.clone(),
             hotness_cache: self.hotness_cache.clone(),
+            category_alerts: self.category_alerts.clone(),
+            api_outage: self.api_outage.clone(),
+            connection_outage: self.connection_outage.clone(),
         }
     }
 }
@@ -1062,3 +1739,608 @@ pub fn start() -> anyhow::Result<BackendHandle> {
         tasks,
     })
 }
+
+/// Sends a test notification without starting the backend, for the
+/// `--test-notification` command line flag. Uses the configured backend.
+pub fn send_test_notification() -> anyhow::Result<()> {
+    let config = ConfigManager::new()?;
+    let (snooze_tx, _) = mpsc::unbounded_channel();
+    let (settings_tx, _) = mpsc::unbounded_channel();
+    let (mute_tx, _) = mpsc::unbounded_channel();
+    let notifier = DesktopNotifier::new(
+        snooze_tx,
+        settings_tx,
+        mute_tx,
+        Arc::new(ImageCache::new()?),
+        config.get().notification_backend.as_deref(),
+    );
+    notifier.test()
+}
+
+/// Test-only constructor for dependency injection
+#[cfg(test)]
+impl Backend<crate::twitch::http::mock::MockHttpClient> {
+    fn with_http_client(
+        dir: &std::path::Path,
+        config: crate::config::Config,
+        http: crate::twitch::http::mock::MockHttpClient,
+        notifier: Arc<dyn Notifier>,
+    ) -> Self {
+        Self::with_parts(
+            Arc::new(ConfigManager::with_config(config)),
+            TwitchClient::with_http_client(CLIENT_ID.to_string(), http.clone()),
+            Database::new(&dir.join("data.db")).unwrap(),
+            TokenStore::with_path(dir.join("token.json")),
+            Arc::new(ImageCache::with_http_client(dir.join("images"), http)),
+            Arc::new(NotificationHistory::in_memory()),
+            |_, _, _| notifier,
+        )
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::config::{Config, StreamerImportance, StreamerSettings};
+    use crate::notify::mock::{NotificationType, RecordingNotifier};
+    use crate::test_helpers::{make_stream, make_stream_with_game};
+    use crate::twitch::http::mock::MockHttpClient;
+    use crate::twitch::{ApiFailure, ApiHealth, Stream, StreamsResponse, User, UsersResponse};
+
+    const FOLLOWED_STREAMS_URL: &str =
+        "https://api.twitch.tv/helix/streams/followed?user_id=me&first=100";
+
+    /// Scripts the followed-streams response, plus a profile lookup per streamer
+    fn script_live(mock: &MockHttpClient, streams: &[Stream]) {
+        let mut mock = mock.clone().on_get_json(
+            FOLLOWED_STREAMS_URL,
+            &StreamsResponse {
+                data: streams.to_vec(),
+                pagination: None,
+            },
+        );
+        for stream in streams {
+            mock = mock.on_get_json(
+                &format!("https://api.twitch.tv/helix/users?id={}", stream.user_id),
+                &UsersResponse {
+                    data: vec![User {
+                        id: stream.user_id.clone(),
+                        login: stream.user_login.clone(),
+                        display_name: stream.user_name.clone(),
+                        profile_image_url: format!("https://example.com/{}.png", stream.user_id),
+                    }],
+                },
+            );
+        }
+    }
+
+    /// Builds a logged-in backend whose notification dispatcher is running
+    async fn start_backend(
+        dir: &std::path::Path,
+        mock: &MockHttpClient,
+        notifier: &Arc<RecordingNotifier>,
+    ) -> (Backend<MockHttpClient>, JoinHandle<()>) {
+        let backend =
+            Backend::with_http_client(dir, Config::default(), mock.clone(), notifier.clone());
+        backend.client.set_access_token("token".to_string()).await;
+        backend.client.set_user_id("me".to_string()).await;
+        let dispatcher = backend
+            .dispatcher
+            .clone()
+            .start(backend.state.subscribe_streams());
+        (backend, dispatcher)
+    }
+
+    async fn settle() {
+        tokio::time::sleep(Duration::from_millis(50)).await;
+    }
+
+    #[tokio::test]
+    async fn full_refresh_asks_again_about_channels_without_schedule() {
+        let dir = tempfile::tempdir().unwrap();
+        let start: DateTime<Utc> = "2026-10-16T12:00:00Z".parse().unwrap();
+        let until = start + chrono::Duration::hours(24);
+        let schedule_url = "https://api.twitch.tv/helix/schedule?broadcaster_id=1\
+                            &start_time=2026-10-16T12%3A00%3A00Z&first=25";
+        let body = serde_json::json!({
+            "data": {
+                "segments": [],
+                "broadcaster_id": "1",
+                "broadcaster_name": "Streamer1",
+                "broadcaster_login": "streamer1",
+            }
+        });
+        let mock = MockHttpClient::new()
+            .on_get_once(schedule_url, 404, Default::default(), "Not Found")
+            .on_get(schedule_url, 200, body.to_string());
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) = start_backend(dir.path(), &mock, &notifier).await;
+
+        let first = backend
+            .client
+            .get_schedule("1", start, until)
+            .await
+            .unwrap();
+        assert!(first.is_none());
+        assert!(backend.client.has_no_schedule("1"));
+
+        backend.refresh_all_data().await;
+
+        assert!(!backend.client.has_no_schedule("1"));
+        let second = backend
+            .client
+            .get_schedule("1", start, until)
+            .await
+            .unwrap();
+        assert!(
+            second.is_some(),
+            "the channel is queried again after the refresh"
+        );
+
+        dispatcher.abort();
+    }
+
+    #[tokio::test]
+    async fn no_live_notifications_before_initial_load() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) = start_backend(dir.path(), &mock, &notifier).await;
+
+        script_live(&mock, &[make_stream("1", "Early")]);
+        backend.refresh_followed_streams().await;
+        settle().await;
+        assert_eq!(notifier.notification_count(), 0);
+
+        backend.session.mark_initial_load_done();
+        script_live(
+            &mock,
+            &[make_stream("1", "Early"), make_stream("2", "Later")],
+        );
+        backend.refresh_followed_streams().await;
+        settle().await;
+
+        let live = notifier.get_by_type(NotificationType::StreamLive);
+        assert_eq!(live.len(), 1);
+        assert!(live[0].title.starts_with("Later"));
+
+        dispatcher.abort();
+    }
+
+    #[tokio::test]
+    async fn category_change_notifies_after_initial_load() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) = start_backend(dir.path(), &mock, &notifier).await;
+
+        script_live(&mock, &[make_stream_with_game("1", "game1", "Game One")]);
+        backend.refresh_followed_streams().await;
+        settle().await;
+        backend.session.mark_initial_load_done();
+
+        script_live(&mock, &[make_stream_with_game("1", "game2", "Game Two")]);
+        backend.refresh_followed_streams().await;
+        settle().await;
+
+        assert_eq!(
+            notifier.get_by_type(NotificationType::CategoryChange).len(),
+            1
+        );
+        assert!(notifier
+            .get_by_type(NotificationType::StreamLive)
+            .is_empty());
+
+        dispatcher.abort();
+    }
+
+    #[tokio::test]
+    async fn muting_a_channel_applies_without_restart() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let backend = Backend::with_http_client(
+            dir.path(),
+            Config::default(),
+            MockHttpClient::new(),
+            notifier.clone(),
+        );
+        let stream = make_stream("1", "Streamer");
+
+        backend.notifier.stream_reminder(&stream).unwrap();
+        assert_eq!(notifier.notification_count(), 1);
+
+        let mut config = backend.config.get();
+        config.streamer_settings.insert(
+            "STREAMER".to_string(),
+            StreamerSettings {
+                display_name: "Streamer".to_string(),
+                importance: StreamerImportance::Silent,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
+            },
+        );
+        backend.config.set(config);
+
+        backend.notifier.stream_reminder(&stream).unwrap();
+        assert_eq!(notifier.notification_count(), 1);
+    }
+
+    #[tokio::test]
+    async fn display_data_lists_live_streams_by_viewers_favourites_first() {
+        let dir = tempfile::tempdir().unwrap();
+        let mut config = Config::default();
+        config.streamer_settings.insert(
+            "fav".to_string(),
+            StreamerSettings {
+                display_name: "Fav".to_string(),
+                importance: StreamerImportance::Favourite,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
+            },
+        );
+        let backend = Backend::with_http_client(
+            dir.path(),
+            config,
+            MockHttpClient::new(),
+            Arc::new(RecordingNotifier::new()),
+        );
+        let stream = |id, name, viewers| Stream {
+            viewer_count: viewers,
+            ..make_stream(id, name)
+        };
+        backend
+            .state
+            .set_followed_streams(vec![
+                stream("1", "Small", 10),
+                stream("2", "Fav", 5),
+                stream("3", "Big", 500),
+            ])
+            .await;
+        let (display_tx, display_rx) = watch::channel(RawDisplayData::default());
+
+        backend.push_display_state(&display_tx).await;
+
+        let logins: Vec<String> = display_rx
+            .borrow()
+            .live_streams
+            .iter()
+            .map(|s| s.user_login.clone())
+            .collect();
+        assert_eq!(logins, vec!["fav", "big", "small"]);
+    }
+
+    #[tokio::test]
+    async fn temporary_mute_applies_until_it_expires() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let backend = Backend::with_http_client(
+            dir.path(),
+            Config::default(),
+            MockHttpClient::new(),
+            notifier.clone(),
+        );
+        let stream = make_stream("1", "Streamer");
+        let mute_until = |until| {
+            let mut config = backend.config.get();
+            config.streamer_settings.insert(
+                "streamer".to_string(),
+                StreamerSettings {
+                    display_name: "Streamer".to_string(),
+                    importance: StreamerImportance::Normal,
+                    hotness_z_threshold_override: None,
+                    notify_on_offline: false,
+                    muted_until: Some(until),
+                },
+            );
+            backend.config.set(config);
+        };
+
+        mute_until(Utc::now() + chrono::Duration::hours(1));
+        backend.notifier.stream_live(&stream).unwrap();
+        assert_eq!(notifier.notification_count(), 0);
+
+        mute_until(Utc::now() - chrono::Duration::seconds(1));
+        backend.notifier.stream_live(&stream).unwrap();
+        assert_eq!(notifier.notification_count(), 1);
+    }
+
+    #[tokio::test]
+    async fn played_categories_skip_followed_ones_and_need_opting_in() {
+        let dir = tempfile::tempdir().unwrap();
+        let mut config = Config::default();
+        config.followed_categories = vec![crate::config::FollowedCategory {
+            id: "g1".to_string(),
+            name: "Chess".to_string(),
+            tags: Vec::new(),
+            viewer_alert: None,
+        }];
+        let backend = Backend::with_http_client(
+            dir.path(),
+            config.clone(),
+            MockHttpClient::new(),
+            Arc::new(RecordingNotifier::new()),
+        );
+        backend
+            .state
+            .set_followed_streams(vec![
+                make_stream_with_game("1", "g1", "Chess"),
+                make_stream_with_game("2", "g2", "Minecraft"),
+                make_stream_with_game("3", "g3", "Factorio"),
+            ])
+            .await;
+
+        assert!(backend.played_categories(&config).await.is_empty());
+
+        config.show_played_categories = true;
+        let names: Vec<String> = backend
+            .played_categories(&config)
+            .await
+            .into_iter()
+            .map(|c| c.name)
+            .collect();
+        assert_eq!(names, vec!["Factorio", "Minecraft"]);
+    }
+
+    #[tokio::test]
+    async fn paused_notifications_still_show_errors() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let mut config = Config::default();
+        config.notifications_paused = true;
+        let backend =
+            Backend::with_http_client(dir.path(), config, MockHttpClient::new(), notifier.clone());
+
+        backend
+            .notifier
+            .stream_live(&make_stream("1", "Streamer"))
+            .unwrap();
+        backend
+            .notifier
+            .error(ErrorCategory::Api, "Twitch unreachable")
+            .unwrap();
+
+        assert!(notifier
+            .get_by_type(NotificationType::StreamLive)
+            .is_empty());
+        assert_eq!(notifier.get_by_type(NotificationType::Error).len(), 1);
+    }
+
+    #[tokio::test]
+    async fn player_that_fails_to_start_is_reported() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let mut config = Config::default();
+        config.external_player = Some("/nonexistent/player {url}".to_string());
+        let backend =
+            Backend::with_http_client(dir.path(), config, MockHttpClient::new(), notifier.clone());
+
+        AppServices::open_in_player(&backend, "streamer");
+
+        let errors = notifier.get_by_type(NotificationType::Error);
+        assert_eq!(errors.len(), 1);
+        assert!(errors[0].message.contains("/nonexistent/player"));
+    }
+
+    #[tokio::test]
+    async fn test_notification_bypasses_rate_limit() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let mut config = Config::default();
+        config.notify_rate_limit_per_min = 1;
+        let backend =
+            Backend::with_http_client(dir.path(), config, MockHttpClient::new(), notifier.clone());
+        backend
+            .notifier
+            .stream_live(&make_stream("1", "Streamer"))
+            .unwrap();
+
+        backend.send_test_notification().unwrap();
+        backend.send_test_notification().unwrap();
+
+        assert_eq!(notifier.get_by_type(NotificationType::Test).len(), 2);
+    }
+
+    #[tokio::test]
+    async fn unreachable_warning_is_shown_once_per_outage() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) =
+            start_backend(dir.path(), &MockHttpClient::new(), &notifier).await;
+        let now = Utc::now();
+        let down = ApiHealth {
+            last_success_at: Some(now),
+            last_error: Some(ApiFailure {
+                endpoint: "/streams/followed".to_string(),
+                status: None,
+                message: "connection refused".to_string(),
+                at: now + chrono::Duration::seconds(1),
+            }),
+        };
+        let up = ApiHealth {
+            last_success_at: Some(now + chrono::Duration::seconds(2)),
+            ..down.clone()
+        };
+
+        backend.warn_if_unreachable(&down);
+        backend.warn_if_unreachable(&down);
+        backend.warn_if_unreachable(&down);
+        assert_eq!(notifier.get_by_type(NotificationType::Error).len(), 1);
+
+        // A different reason, as the same message is only shown once per ten minutes
+        let down_again = ApiHealth {
+            last_error: Some(ApiFailure {
+                message: "timed out".to_string(),
+                at: now + chrono::Duration::seconds(3),
+                ..down.last_error.clone().unwrap()
+            }),
+            ..up.clone()
+        };
+        backend.warn_if_unreachable(&up);
+        backend.warn_if_unreachable(&up);
+        let recovered = notifier.get_by_type(NotificationType::ErrorRecovered);
+        assert_eq!(recovered.len(), 1);
+        assert_eq!(
+            recovered[0].message,
+            "Twitch API back to normal (suppressed 2 errors)"
+        );
+
+        backend.warn_if_unreachable(&down_again);
+        assert_eq!(notifier.get_by_type(NotificationType::Error).len(), 2);
+
+        dispatcher.abort();
+    }
+
+    #[tokio::test]
+    async fn connection_pause_is_reported_and_recovery_always_announced() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let mut config = Config::default();
+        config.notify_on_connection_issues = true;
+        config.notify_on_error_recovery = false;
+        let backend =
+            Backend::with_http_client(dir.path(), config, MockHttpClient::new(), notifier.clone());
+        let open = CircuitState::Open {
+            until: Utc::now() + chrono::Duration::seconds(60),
+        };
+
+        backend.report_connection(CircuitState::Closed);
+        backend.report_connection(open);
+        backend.report_connection(CircuitState::HalfOpen);
+        backend.report_connection(open);
+        backend.report_connection(open);
+        assert_eq!(notifier.get_by_type(NotificationType::Error).len(), 1);
+
+        backend.report_connection(CircuitState::Closed);
+        backend.report_connection(CircuitState::Closed);
+        let recovered = notifier.get_by_type(NotificationType::ErrorRecovered);
+        assert_eq!(recovered.len(), 1);
+        assert_eq!(
+            recovered[0].message,
+            "Twitch connection back to normal (suppressed 2 errors)"
+        );
+    }
+
+    #[tokio::test]
+    async fn connection_pause_is_not_reported_by_default() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let backend = Backend::with_http_client(
+            dir.path(),
+            Config::default(),
+            MockHttpClient::new(),
+            notifier.clone(),
+        );
+
+        backend.report_connection(CircuitState::Open {
+            until: Utc::now() + chrono::Duration::seconds(60),
+        });
+        backend.report_connection(CircuitState::Closed);
+
+        assert_eq!(notifier.notification_count(), 0);
+    }
+
+    #[tokio::test]
+    async fn outage_with_paused_requests_shows_one_notification() {
+        let dir = tempfile::tempdir().unwrap();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let mut config = Config::default();
+        config.notify_on_connection_issues = true;
+        config.notify_on_error_recovery = true;
+        let backend =
+            Backend::with_http_client(dir.path(), config, MockHttpClient::new(), notifier.clone());
+        let now = Utc::now();
+        let down = ApiHealth {
+            last_success_at: Some(now),
+            last_error: Some(ApiFailure {
+                endpoint: "/streams/followed".to_string(),
+                status: None,
+                message: "connection refused".to_string(),
+                at: now + chrono::Duration::seconds(1),
+            }),
+        };
+        let up = ApiHealth {
+            last_success_at: Some(now + chrono::Duration::seconds(2)),
+            ..down.clone()
+        };
+        let open = CircuitState::Open {
+            until: now + chrono::Duration::seconds(60),
+        };
+        // Each poll reports the breaker before health
+        let poll = |circuit: CircuitState, health: &ApiHealth| {
+            backend.report_connection(circuit);
+            backend.warn_if_unreachable(health);
+        };
+
+        // The breaker opens on the first poll: only the pause is shown
+        poll(open, &down);
+        poll(CircuitState::HalfOpen, &down);
+        poll(open, &down);
+        poll(CircuitState::Closed, &up);
+
+        let errors = notifier.get_by_type(NotificationType::Error);
+        assert_eq!(errors.len(), 1);
+        assert!(errors[0].message.starts_with("Twitch requests paused"));
+        let recovered = notifier.get_by_type(NotificationType::ErrorRecovered);
+        assert_eq!(recovered.len(), 1);
+        assert!(recovered[0].message.starts_with("Twitch connection back"));
+
+        // Unreachable before the breaker opens: only the warning is shown
+        poll(CircuitState::Closed, &down);
+        poll(open, &down);
+        poll(CircuitState::HalfOpen, &down);
+        poll(CircuitState::Closed, &up);
+
+        let errors = notifier.get_by_type(NotificationType::Error);
+        assert_eq!(errors.len(), 2);
+        assert!(errors[1].message.starts_with("Twitch unreachable"));
+        let recovered = notifier.get_by_type(NotificationType::ErrorRecovered);
+        assert_eq!(recovered.len(), 2);
+        assert!(recovered[1].message.starts_with("Twitch API back"));
+    }
+
+    #[tokio::test]
+    async fn refresh_enriches_streams_with_profile_images() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new();
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) = start_backend(dir.path(), &mock, &notifier).await;
+
+        script_live(&mock, &[make_stream("1", "Streamer")]);
+        backend.refresh_followed_streams().await;
+
+        let streams = backend.state.get_followed_streams().await;
+        assert_eq!(streams.len(), 1);
+        assert_eq!(streams[0].profile_image_url, "https://example.com/1.png");
+
+        dispatcher.abort();
+    }
+
+    #[tokio::test]
+    async fn refresh_prefetches_avatars_for_notification_icons() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get("https://example.com/1.png", 200, "avatar");
+        let notifier = Arc::new(RecordingNotifier::new());
+        let (backend, dispatcher) = start_backend(dir.path(), &mock, &notifier).await;
+
+        // Streams without an avatar URL have nothing to fetch
+        assert!(backend
+            .prefetch_avatars(&[make_stream("1", "Streamer")])
+            .is_none());
+
+        script_live(&mock, &[make_stream("1", "Streamer")]);
+        backend.refresh_followed_streams().await;
+        settle().await;
+        assert!(backend
+            .images
+            .cached_path("https://example.com/1.png")
+            .is_some());
+
+        // Already on disk, so nothing is downloaded again
+        let streams = backend.state.get_followed_streams().await;
+        assert!(backend.prefetch_avatars(&streams).is_none());
+
+        dispatcher.abort();
+    }
+}
@@ -0,0 +1,191 @@
+//! Exporting scheduled streams as iCalendar (RFC 5545) events
+//!
+//! The tray's "Add to calendar" writes a single-event `.ics` file to the temp
+//! directory and opens it, leaving the import to the default calendar app.
+
+use std::path::PathBuf;
+
+use anyhow::Context;
+use chrono::{DateTime, Duration, Utc};
+
+use crate::twitch::{channel_url, ScheduledStream};
+
+/// Length of an event whose segment has no end time
+const DEFAULT_DURATION_MIN: i64 = 60;
+
+/// Longest content line in octets, excluding the CRLF
+const MAX_LINE_OCTETS: usize = 75;
+
+/// Escapes a TEXT property value: backslashes, semicolons and commas are
+/// escaped and line breaks become `\n`
+pub fn escape_text(value: &str) -> String {
+    let mut escaped = String::with_capacity(value.len());
+    let mut chars = value.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '\\' => escaped.push_str("\\\\"),
+            ';' => escaped.push_str("\\;"),
+            ',' => escaped.push_str("\\,"),
+            '\r' => {
+                chars.next_if_eq(&'\n');
+                escaped.push_str("\\n");
+            }
+            '\n' => escaped.push_str("\\n"),
+            c => escaped.push(c),
+        }
+    }
+    escaped
+}
+
+/// Folds a content line into CRLF-terminated lines of at most 75 octets,
+/// continuation lines starting with a space. Never splits a character.
+fn fold_line(line: &str) -> String {
+    let mut folded = String::with_capacity(line.len() + 2);
+    let mut octets = 0;
+    for c in line.chars() {
+        if octets + c.len_utf8() > MAX_LINE_OCTETS {
+            folded.push_str("\r\n ");
+            // The leading space counts towards the continuation line
+            octets = 1;
+        }
+        folded.push(c);
+        octets += c.len_utf8();
+    }
+    folded.push_str("\r\n");
+    folded
+}
+
+/// Formats a time as a UTC DATE-TIME value, e.g. `20240115T200000Z`
+fn format_time(time: DateTime<Utc>) -> String {
+    time.format("%Y%m%dT%H%M%SZ").to_string()
+}
+
+/// Builds a calendar holding one event for `scheduled`, stamped `now`
+pub fn scheduled_event(scheduled: &ScheduledStream, now: DateTime<Utc>) -> String {
+    let end_time = scheduled
+        .end_time
+        .filter(|end| *end > scheduled.start_time)
+        .unwrap_or(scheduled.start_time + Duration::minutes(DEFAULT_DURATION_MIN));
+    let summary = if scheduled.title.trim().is_empty() {
+        scheduled.broadcaster_name.clone()
+    } else {
+        format!("{}: {}", scheduled.broadcaster_name, scheduled.title)
+    };
+
+    let lines = [
+        "BEGIN:VCALENDAR".to_string(),
+        "VERSION:2.0".to_string(),
+        "PRODID:-//twitch-tray//EN".to_string(),
+        "BEGIN:VEVENT".to_string(),
+        format!("UID:{}@twitch-tray", escape_text(&scheduled.id)),
+        format!("DTSTAMP:{}", format_time(now)),
+        format!("DTSTART:{}", format_time(scheduled.start_time)),
+        format!("DTEND:{}", format_time(end_time)),
+        format!("SUMMARY:{}", escape_text(&summary)),
+        format!("URL:{}", channel_url(&scheduled.broadcaster_login)),
+        "END:VEVENT".to_string(),
+        "END:VCALENDAR".to_string(),
+    ];
+    lines.iter().map(|line| fold_line(line)).collect()
+}
+
+/// Writes `scheduled` to an `.ics` file in the temp directory and opens it
+/// with the default handler, returning the file's path
+pub fn open_in_calendar(scheduled: &ScheduledStream) -> anyhow::Result<PathBuf> {
+    let path = std::env::temp_dir().join(format!(
+        "twitch-tray-{}-{}.ics",
+        scheduled.broadcaster_login,
+        scheduled.start_time.format("%Y%m%d%H%M")
+    ));
+    std::fs::write(&path, scheduled_event(scheduled, Utc::now()))
+        .with_context(|| format!("Couldn't write {}", path.display()))?;
+    open::that(&path).with_context(|| format!("Couldn't open {}", path.display()))?;
+    Ok(path)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use chrono::TimeZone;
+
+    fn scheduled(title: &str, end_time: Option<DateTime<Utc>>) -> ScheduledStream {
+        ScheduledStream {
+            id: "seg1".to_string(),
+            broadcaster_id: "456".to_string(),
+            broadcaster_name: "Streamer".to_string(),
+            broadcaster_login: "streamer".to_string(),
+            title: title.to_string(),
+            start_time: Utc.with_ymd_and_hms(2024, 1, 15, 20, 0, 0).unwrap(),
+            end_time,
+            category: None,
+            category_id: None,
+            is_recurring: false,
+            is_inferred: false,
+        }
+    }
+
+    #[test]
+    fn escapes_text_values() {
+        assert_eq!(
+            escape_text(r"a\b; c, d"),
+            r"a\\b\; c\, d",
+            "backslash, semicolon and comma are escaped"
+        );
+        assert_eq!(escape_text("one\ntwo\r\nthree"), r"one\ntwo\nthree");
+        assert_eq!(
+            escape_text("Speedrun: any% \u{1f3ae}"),
+            "Speedrun: any% \u{1f3ae}"
+        );
+    }
+
+    #[test]
+    fn folds_long_lines_without_splitting_characters() {
+        let short = fold_line("SUMMARY:short");
+        assert_eq!(short, "SUMMARY:short\r\n");
+
+        let long = fold_line(&format!("SUMMARY:{}", "\u{e9}".repeat(60)));
+        let lines: Vec<&str> = long.trim_end_matches("\r\n").split("\r\n").collect();
+        assert_eq!(lines.len(), 2);
+        assert!(lines.iter().all(|l| l.len() <= MAX_LINE_OCTETS));
+        assert!(lines[1].starts_with(' '));
+        assert_eq!(
+            long.replace("\r\n ", ""),
+            format!("SUMMARY:{}\r\n", "\u{e9}".repeat(60)),
+            "unfolding restores the line"
+        );
+    }
+
+    #[test]
+    fn event_has_segment_times_summary_and_url() {
+        let end = Utc.with_ymd_and_hms(2024, 1, 15, 23, 30, 0).unwrap();
+        let now = Utc.with_ymd_and_hms(2024, 1, 10, 9, 0, 0).unwrap();
+        let ics = scheduled_event(&scheduled("Chill, chat; games", Some(end)), now);
+
+        assert!(ics.starts_with("BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"));
+        assert!(ics.ends_with("END:VEVENT\r\nEND:VCALENDAR\r\n"));
+        assert!(ics.contains("\r\nUID:seg1@twitch-tray\r\n"));
+        assert!(ics.contains("\r\nDTSTAMP:20240110T090000Z\r\n"));
+        assert!(ics.contains("\r\nDTSTART:20240115T200000Z\r\n"));
+        assert!(ics.contains("\r\nDTEND:20240115T233000Z\r\n"));
+        assert!(ics.contains("\r\nSUMMARY:Streamer: Chill\\, chat\\; games\r\n"));
+        assert!(ics.contains("\r\nURL:https://twitch.tv/streamer\r\n"));
+    }
+
+    #[test]
+    fn missing_or_invalid_end_defaults_to_one_hour() {
+        let now = Utc::now();
+        let start = scheduled("", None).start_time;
+
+        for end_time in [None, Some(start), Some(start - Duration::hours(1))] {
+            let ics = scheduled_event(&scheduled("Title", end_time), now);
+            assert!(ics.contains("\r\nDTEND:20240115T210000Z\r\n"), "{ics}");
+        }
+    }
+
+    #[test]
+    fn empty_title_uses_streamer_name() {
+        let ics = scheduled_event(&scheduled("  ", None), Utc::now());
+
+        assert!(ics.contains("\r\nSUMMARY:Streamer\r\n"));
+    }
+}
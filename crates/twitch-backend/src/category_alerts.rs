@@ -0,0 +1,122 @@
+//! Alerts for big streams in followed categories
+//!
+//! A followed category can set a viewer threshold. Each time the category's
+//! top streams are refreshed, [`CategoryAlerts`] picks out the streams that
+//! have just reached it, so a stream is alerted once when it crosses the
+//! threshold rather than on every poll while it stays above.
+
+use std::collections::{HashMap, HashSet};
+
+use crate::twitch::Stream;
+
+/// Streams currently at or above the viewer threshold, per category
+#[derive(Debug, Default)]
+pub struct CategoryAlerts {
+    /// category_id -> ids of the streams at or above the threshold last time
+    above: HashMap<String, HashSet<String>>,
+}
+
+impl CategoryAlerts {
+    /// Returns the streams in `streams` that have reached `threshold` since
+    /// the category was last checked.
+    ///
+    /// The first check of a category only records which streams are already
+    /// above the threshold, so turning the alert on, or starting the app,
+    /// doesn't alert for every big stream at once. Streams that drop below
+    /// the threshold are forgotten and alert again if they reach it again.
+    pub fn crossed(
+        &mut self,
+        category_id: &str,
+        threshold: u32,
+        streams: &[Stream],
+    ) -> Vec<Stream> {
+        let now_above: HashSet<String> = streams
+            .iter()
+            .filter(|s| s.viewer_count >= threshold)
+            .map(|s| s.id.clone())
+            .collect();
+
+        let Some(was_above) = self
+            .above
+            .insert(category_id.to_string(), now_above.clone())
+        else {
+            return Vec::new();
+        };
+
+        streams
+            .iter()
+            .filter(|s| now_above.contains(&s.id) && !was_above.contains(&s.id))
+            .cloned()
+            .collect()
+    }
+
+    /// Forgets a category whose alert was turned off, so turning it back on
+    /// starts from a fresh baseline
+    pub fn forget(&mut self, category_id: &str) {
+        self.above.remove(category_id);
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::test_helpers::make_stream_with_game;
+
+    fn stream(id: &str, viewers: u32) -> Stream {
+        let mut stream = make_stream_with_game(id, "g1", "Factorio");
+        stream.viewer_count = viewers;
+        stream
+    }
+
+    #[test]
+    fn first_check_only_records_baseline() {
+        let mut alerts = CategoryAlerts::default();
+
+        assert!(alerts
+            .crossed("g1", 1000, &[stream("1", 5000), stream("2", 10)])
+            .is_empty());
+    }
+
+    #[test]
+    fn stream_crossing_threshold_alerts_once() {
+        let mut alerts = CategoryAlerts::default();
+        alerts.crossed("g1", 1000, &[stream("1", 900)]);
+
+        let crossed = alerts.crossed("g1", 1000, &[stream("1", 1000)]);
+        assert_eq!(crossed.len(), 1);
+        assert_eq!(crossed[0].user_id, "1");
+
+        assert!(
+            alerts.crossed("g1", 1000, &[stream("1", 1500)]).is_empty(),
+            "still above, no repeat"
+        );
+    }
+
+    #[test]
+    fn stream_alerts_again_after_dropping_below() {
+        let mut alerts = CategoryAlerts::default();
+        alerts.crossed("g1", 1000, &[stream("1", 1200)]);
+
+        assert!(alerts.crossed("g1", 1000, &[stream("1", 800)]).is_empty());
+        assert_eq!(alerts.crossed("g1", 1000, &[stream("1", 1100)]).len(), 1);
+    }
+
+    #[test]
+    fn new_stream_appearing_above_threshold_alerts() {
+        let mut alerts = CategoryAlerts::default();
+        alerts.crossed("g1", 1000, &[]);
+
+        let crossed = alerts.crossed("g1", 1000, &[stream("1", 10), stream("2", 4000)]);
+        assert_eq!(crossed.len(), 1);
+        assert_eq!(crossed[0].user_id, "2");
+    }
+
+    #[test]
+    fn forget_resets_baseline() {
+        let mut alerts = CategoryAlerts::default();
+        alerts.crossed("g1", 1000, &[]);
+        alerts.forget("g1");
+
+        assert!(alerts.crossed("g1", 1000, &[stream("1", 4000)]).is_empty());
+    }
+}
@@ -1,4 +1,5 @@
 use anyhow::{Context, Result};
+use chrono::{DateTime, Utc};
 use serde::{Deserialize, Serialize};
 use std::collections::HashMap;
 use std::path::PathBuf;
@@ -11,18 +12,32 @@ const CONFIG_FILE: &str = "config.json";
 pub const DEFAULT_POLL_INTERVAL_SEC: u64 = 60;
 pub const DEFAULT_NOTIFY_ON_LIVE: bool = true;
 pub const DEFAULT_NOTIFY_ON_CATEGORY: bool = true;
+pub const DEFAULT_NOTIFY_ON_TITLE: bool = false;
+pub const DEFAULT_NOTIFY_ON_SCHEDULE_CHANGE: bool = false;
 pub const DEFAULT_NOTIFY_MAX_GAP_MIN: u64 = 10;
+pub const DEFAULT_LIVE_SUMMARY_THRESHOLD: usize = 3;
+pub const DEFAULT_LIVE_SUMMARY_WINDOW_SEC: u64 = 60;
+pub const DEFAULT_NOTIFY_RATE_LIMIT_PER_MIN: usize = 10;
+pub const DEFAULT_LIVE_REPEAT_WINDOW_MIN: u64 = 15;
+pub const DEFAULT_NOTIFY_ON_ERROR_RECOVERY: bool = true;
+pub const DEFAULT_NOTIFY_ON_CONNECTION_ISSUES: bool = false;
 pub const DEFAULT_SCHEDULE_STALE_HOURS: u64 = 24;
 pub const DEFAULT_SCHEDULE_CHECK_INTERVAL_SEC: u64 = 10;
+pub const DEFAULT_NO_SCHEDULE_SKIP_HOURS: u64 = 72;
 pub const DEFAULT_FOLLOWED_REFRESH_MIN: u64 = 15;
 pub const DEFAULT_SCHEDULE_LOOKAHEAD_HOURS: u64 = 6;
 pub const DEFAULT_SCHEDULE_BEFORE_NOW_MIN: u64 = 30;
 pub const DEFAULT_LIVE_MENU_LIMIT: usize = 10;
 pub const DEFAULT_SCHEDULE_MENU_LIMIT: usize = 5;
+pub const DEFAULT_GROUP_LIVE_BY_GAME: bool = false;
+pub const DEFAULT_HIDE_RERUNS: bool = false;
+pub const DEFAULT_NOTIFICATIONS_PAUSED: bool = false;
+pub const DEFAULT_SHOW_PLAYED_CATEGORIES: bool = false;
 pub const DEFAULT_HOTNESS_Z_THRESHOLD: f64 = 2.0;
 pub const DEFAULT_HOTNESS_MIN_OBSERVATIONS: usize = 5;
 pub const DEFAULT_HOTNESS_MIN_STREAMS: usize = 7;
 pub const DEFAULT_NOTIFY_ON_HOT: bool = true;
+pub const DEFAULT_KEEP_STREAM_DETAILS: bool = false;
 
 /// Importance level for a streamer, affecting display and notifications
 #[derive(Debug, Clone, Copy, Default, Serialize, Deserialize, PartialEq, Eq)]
@@ -35,6 +50,82 @@ pub enum StreamerImportance {
     Ignore,
 }
 
+impl StreamerImportance {
+    /// Silent and Ignore streamers never get notifications
+    pub fn is_muted(self) -> bool {
+        matches!(self, Self::Silent | Self::Ignore)
+    }
+}
+
+/// Looks up a streamer's settings by login, ignoring case since settings
+/// may have been keyed by hand
+pub fn streamer_settings<'a>(
+    settings: &'a HashMap<String, StreamerSettings>,
+    user_login: &str,
+) -> Option<&'a StreamerSettings> {
+    settings.get(user_login).or_else(|| {
+        settings
+            .iter()
+            .find(|(login, _)| login.eq_ignore_ascii_case(user_login))
+            .map(|(_, s)| s)
+    })
+}
+
+/// Looks up a streamer's importance by login. Unknown streamers are Normal.
+pub fn streamer_importance(
+    settings: &HashMap<String, StreamerSettings>,
+    user_login: &str,
+) -> StreamerImportance {
+    streamer_settings(settings, user_login)
+        .map(|s| s.importance)
+        .unwrap_or_default()
+}
+
+/// Returns true if notifications for the streamer are off, either by
+/// importance or by a temporary mute that hasn't expired by `now`
+pub fn is_streamer_muted(
+    settings: &HashMap<String, StreamerSettings>,
+    user_login: &str,
+    now: DateTime<Utc>,
+) -> bool {
+    streamer_settings(settings, user_login)
+        .is_some_and(|s| s.importance.is_muted() || s.muted_until.is_some_and(|until| now < until))
+}
+
+/// Makes a streamer a favourite, or turns a favourite back into a normal
+/// streamer. Unfavouriting leaves Silent and Ignore streamers as they are.
+pub fn set_favourite(
+    settings: &mut HashMap<String, StreamerSettings>,
+    user_login: &str,
+    display_name: &str,
+    favourite: bool,
+) {
+    let existing = settings
+        .iter_mut()
+        .find(|(login, _)| login.eq_ignore_ascii_case(user_login))
+        .map(|(_, s)| s);
+    match existing {
+        Some(s) if favourite => s.importance = StreamerImportance::Favourite,
+        Some(s) if s.importance == StreamerImportance::Favourite => {
+            s.importance = StreamerImportance::Normal
+        }
+        Some(_) => {}
+        None if favourite => {
+            settings.insert(
+                user_login.to_string(),
+                StreamerSettings {
+                    display_name: display_name.to_string(),
+                    importance: StreamerImportance::Favourite,
+                    hotness_z_threshold_override: None,
+                    notify_on_offline: false,
+                    muted_until: None,
+                },
+            );
+        }
+        None => {}
+    }
+}
+
 /// Per-streamer settings
 #[derive(Debug, Clone, Serialize, Deserialize, PartialEq)]
 pub struct StreamerSettings {
@@ -43,6 +134,13 @@ pub struct StreamerSettings {
     pub importance: StreamerImportance,
     #[serde(default)]
     pub hotness_z_threshold_override: Option<f64>,
+    /// Send a notification when this streamer goes offline (default: false)
+    #[serde(default)]
+    pub notify_on_offline: bool,
+    /// Notifications are muted until this time, set by "Mute today" on a
+    /// notification. Kept in the config so a restart doesn't unmute.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub muted_until: Option<DateTime<Utc>>,
 }
 
 /// A followed category for category stream tracking
@@ -50,6 +148,35 @@ pub struct StreamerSettings {
 pub struct FollowedCategory {
     pub id: String,
     pub name: String,
+    /// Only show streams matching all of these tags (case-insensitive substring)
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub tags: Vec<String>,
+    /// Notify when a stream in this category reaches this many viewers.
+    /// `None` (the default) turns the alert off.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub viewer_alert: Option<u32>,
+}
+
+/// Forwarding of notifications to another device
+#[derive(Debug, Clone, Default, Serialize, Deserialize, PartialEq)]
+pub struct ForwardConfig {
+    /// Webhook or ntfy topic URL that receives a JSON payload for each
+    /// notification shown. Forwarding is off while this is unset.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub url: Option<String>,
+}
+
+/// How scheduled streams show their start time in the menu
+#[derive(Debug, Clone, Copy, Default, Serialize, Deserialize, PartialEq, Eq)]
+#[serde(rename_all = "snake_case")]
+pub enum ScheduleTimeFormat {
+    /// e.g. "Today 7:00 PM"
+    #[default]
+    Absolute,
+    /// e.g. "in 2h 15m"
+    Relative,
+    /// e.g. "Today 7:00 PM (in 2h 15m)"
+    Both,
 }
 
 /// Application configuration
@@ -61,17 +188,56 @@ pub struct Config {
     pub notify_on_live: bool,
     #[serde(default = "default_notify_on_category")]
     pub notify_on_category: bool,
+    /// Send notifications when a live streamer changes their stream title.
+    /// Off by default since some streamers edit titles often.
+    #[serde(default = "default_notify_on_title")]
+    pub notify_on_title: bool,
+    /// Send notifications when an upcoming scheduled stream is cancelled or
+    /// moved. Off by default.
+    #[serde(default = "default_notify_on_schedule_change")]
+    pub notify_on_schedule_change: bool,
     /// Maximum gap (in minutes) between refreshes to still send notifications.
     /// If the app was asleep/suspended longer than this, notifications are suppressed
     /// to avoid a flood of alerts on wake.
     #[serde(default = "default_notify_max_gap")]
     pub notify_max_gap_min: u64,
+    /// More live notifications than this within `live_summary_window_sec` are
+    /// combined into a single summary notification
+    #[serde(default = "default_live_summary_threshold")]
+    pub live_summary_threshold: usize,
+    /// Window (in seconds) over which live notifications are counted for
+    /// `live_summary_threshold`
+    #[serde(default = "default_live_summary_window")]
+    pub live_summary_window_sec: u64,
+    /// Most notifications sent per minute; the rest are counted and reported
+    /// in one notification when the minute is up. Errors are not limited.
+    /// 0 turns the limit off.
+    #[serde(default = "default_notify_rate_limit")]
+    pub notify_rate_limit_per_min: usize,
+    /// A channel announced live within this many minutes is not announced
+    /// again, unless it is playing a different game. Stops streams whose
+    /// connection drops and recovers from notifying twice.
+    #[serde(default = "default_live_repeat_window")]
+    pub live_repeat_window_min: u64,
+    /// Whether to notify when a repeating error stops, with how many repeats
+    /// were not shown
+    #[serde(default = "default_notify_on_error_recovery")]
+    pub notify_on_error_recovery: bool,
+    /// Whether to notify when requests to Twitch are paused after repeated
+    /// failures, which explains delayed alerts. The connection coming back is
+    /// always announced once a pause was.
+    #[serde(default = "default_notify_on_connection_issues")]
+    pub notify_on_connection_issues: bool,
     /// How many hours before a schedule entry is considered stale and re-fetched
     #[serde(default = "default_schedule_stale_hours")]
     pub schedule_stale_hours: u64,
     /// How often (in seconds) the schedule queue walker checks the next broadcaster
     #[serde(default = "default_schedule_check_interval")]
     pub schedule_check_interval_sec: u64,
+    /// How many hours to skip a broadcaster whose schedule request returned 404.
+    /// Most channels never set up a schedule, so checking them daily wastes requests.
+    #[serde(default = "default_no_schedule_skip_hours")]
+    pub no_schedule_skip_hours: u64,
     /// How often (in minutes) to refresh the followed channels list from the API
     #[serde(default = "default_followed_refresh")]
     pub followed_refresh_min: u64,
@@ -88,6 +254,23 @@ pub struct Config {
     /// Maximum scheduled streams shown directly in the main menu before the overflow submenu.
     #[serde(default = "default_schedule_menu_limit")]
     pub schedule_menu_limit: usize,
+    /// How scheduled streams show their start time in the menu.
+    #[serde(default)]
+    pub schedule_time_format: ScheduleTimeFormat,
+    /// Group live streams under a submenu per game instead of one flat list.
+    #[serde(default = "default_group_live_by_game")]
+    pub group_live_by_game: bool,
+    /// Leave reruns out of the live list in the menu.
+    #[serde(default = "default_hide_reruns")]
+    pub hide_reruns: bool,
+    /// Drop all stream notifications until turned off again. Errors and the
+    /// test notification still show.
+    #[serde(default = "default_notifications_paused")]
+    pub notifications_paused: bool,
+    /// Also list the top streams in the games followed streamers are
+    /// playing. Costs an extra API request per game on every refresh.
+    #[serde(default = "default_show_played_categories")]
+    pub show_played_categories: bool,
     /// Z-score threshold for detecting "hot" streams (default: 2.0).
     /// A stream is hot when its current viewers exceed the historical mean by this many
     /// standard deviations.
@@ -103,6 +286,22 @@ pub struct Config {
     /// Send desktop notifications when a stream is detected as hot (default: true)
     #[serde(default = "default_notify_on_hot")]
     pub notify_on_hot: bool,
+    /// Keep stream thumbnail URLs and tags in memory (default: false).
+    /// Nothing displays them, so they are dropped to keep the idle footprint small.
+    #[serde(default = "default_keep_stream_details")]
+    pub keep_stream_details: bool,
+    /// Notification backend to use ("dbus", "notify-send", "osascript",
+    /// "powershell" or "log"), or `None` to use the first one that works.
+    /// Read at startup.
+    #[serde(default)]
+    pub notification_backend: Option<String>,
+    /// Forwards notifications to a webhook, e.g. to reach a phone
+    #[serde(default)]
+    pub notification_forward: ForwardConfig,
+    /// Command to open a stream in an external player, e.g.
+    /// `streamlink {url} best`, or `None` to only open streams in the browser
+    #[serde(default)]
+    pub external_player: Option<String>,
     /// Categories to follow for category-based stream listings
     #[serde(default)]
     pub followed_categories: Vec<FollowedCategory>,
@@ -123,10 +322,42 @@ fn default_notify_on_category() -> bool {
     DEFAULT_NOTIFY_ON_CATEGORY
 }
 
+fn default_notify_on_title() -> bool {
+    DEFAULT_NOTIFY_ON_TITLE
+}
+
+fn default_notify_on_schedule_change() -> bool {
+    DEFAULT_NOTIFY_ON_SCHEDULE_CHANGE
+}
+
 fn default_notify_max_gap() -> u64 {
     DEFAULT_NOTIFY_MAX_GAP_MIN
 }
 
+fn default_live_summary_threshold() -> usize {
+    DEFAULT_LIVE_SUMMARY_THRESHOLD
+}
+
+fn default_live_summary_window() -> u64 {
+    DEFAULT_LIVE_SUMMARY_WINDOW_SEC
+}
+
+fn default_notify_rate_limit() -> usize {
+    DEFAULT_NOTIFY_RATE_LIMIT_PER_MIN
+}
+
+fn default_live_repeat_window() -> u64 {
+    DEFAULT_LIVE_REPEAT_WINDOW_MIN
+}
+
+fn default_notify_on_error_recovery() -> bool {
+    DEFAULT_NOTIFY_ON_ERROR_RECOVERY
+}
+
+fn default_notify_on_connection_issues() -> bool {
+    DEFAULT_NOTIFY_ON_CONNECTION_ISSUES
+}
+
 fn default_schedule_stale_hours() -> u64 {
     DEFAULT_SCHEDULE_STALE_HOURS
 }
@@ -135,6 +366,10 @@ fn default_schedule_check_interval() -> u64 {
     DEFAULT_SCHEDULE_CHECK_INTERVAL_SEC
 }
 
+fn default_no_schedule_skip_hours() -> u64 {
+    DEFAULT_NO_SCHEDULE_SKIP_HOURS
+}
+
 fn default_followed_refresh() -> u64 {
     DEFAULT_FOLLOWED_REFRESH_MIN
 }
@@ -155,6 +390,22 @@ fn default_schedule_menu_limit() -> usize {
     DEFAULT_SCHEDULE_MENU_LIMIT
 }
 
+fn default_group_live_by_game() -> bool {
+    DEFAULT_GROUP_LIVE_BY_GAME
+}
+
+fn default_hide_reruns() -> bool {
+    DEFAULT_HIDE_RERUNS
+}
+
+fn default_notifications_paused() -> bool {
+    DEFAULT_NOTIFICATIONS_PAUSED
+}
+
+fn default_show_played_categories() -> bool {
+    DEFAULT_SHOW_PLAYED_CATEGORIES
+}
+
 fn default_hotness_z_threshold() -> f64 {
     DEFAULT_HOTNESS_Z_THRESHOLD
 }
@@ -171,30 +422,112 @@ fn default_notify_on_hot() -> bool {
     DEFAULT_NOTIFY_ON_HOT
 }
 
+fn default_keep_stream_details() -> bool {
+    DEFAULT_KEEP_STREAM_DETAILS
+}
+
 impl Default for Config {
     fn default() -> Self {
         Self {
             poll_interval_sec: DEFAULT_POLL_INTERVAL_SEC,
             notify_on_live: DEFAULT_NOTIFY_ON_LIVE,
             notify_on_category: DEFAULT_NOTIFY_ON_CATEGORY,
+            notify_on_title: DEFAULT_NOTIFY_ON_TITLE,
+            notify_on_schedule_change: DEFAULT_NOTIFY_ON_SCHEDULE_CHANGE,
             notify_max_gap_min: DEFAULT_NOTIFY_MAX_GAP_MIN,
+            live_summary_threshold: DEFAULT_LIVE_SUMMARY_THRESHOLD,
+            live_summary_window_sec: DEFAULT_LIVE_SUMMARY_WINDOW_SEC,
+            notify_rate_limit_per_min: DEFAULT_NOTIFY_RATE_LIMIT_PER_MIN,
+            live_repeat_window_min: DEFAULT_LIVE_REPEAT_WINDOW_MIN,
+            notify_on_error_recovery: DEFAULT_NOTIFY_ON_ERROR_RECOVERY,
+            notify_on_connection_issues: DEFAULT_NOTIFY_ON_CONNECTION_ISSUES,
             schedule_stale_hours: DEFAULT_SCHEDULE_STALE_HOURS,
             schedule_check_interval_sec: DEFAULT_SCHEDULE_CHECK_INTERVAL_SEC,
+            no_schedule_skip_hours: DEFAULT_NO_SCHEDULE_SKIP_HOURS,
             followed_refresh_min: DEFAULT_FOLLOWED_REFRESH_MIN,
             schedule_lookahead_hours: DEFAULT_SCHEDULE_LOOKAHEAD_HOURS,
             schedule_before_now_min: DEFAULT_SCHEDULE_BEFORE_NOW_MIN,
             live_menu_limit: DEFAULT_LIVE_MENU_LIMIT,
             schedule_menu_limit: DEFAULT_SCHEDULE_MENU_LIMIT,
+            schedule_time_format: ScheduleTimeFormat::default(),
+            group_live_by_game: DEFAULT_GROUP_LIVE_BY_GAME,
+            hide_reruns: DEFAULT_HIDE_RERUNS,
+            notifications_paused: DEFAULT_NOTIFICATIONS_PAUSED,
+            show_played_categories: DEFAULT_SHOW_PLAYED_CATEGORIES,
             hotness_z_threshold: DEFAULT_HOTNESS_Z_THRESHOLD,
             hotness_min_observations: DEFAULT_HOTNESS_MIN_OBSERVATIONS,
             hotness_min_streams: DEFAULT_HOTNESS_MIN_STREAMS,
             notify_on_hot: DEFAULT_NOTIFY_ON_HOT,
+            keep_stream_details: DEFAULT_KEEP_STREAM_DETAILS,
+            notification_backend: None,
+            notification_forward: ForwardConfig::default(),
+            external_player: None,
             followed_categories: Vec::new(),
             streamer_settings: HashMap::new(),
         }
     }
 }
 
+/// A setting that can be switched on and off from the tray menu
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum MenuToggle {
+    NotifyOnLive,
+    NotifyOnCategory,
+    HideReruns,
+    GroupLiveByGame,
+    NotificationsPaused,
+}
+
+impl MenuToggle {
+    /// Every toggle, in menu order
+    pub const ALL: [MenuToggle; 5] = [
+        Self::NotifyOnLive,
+        Self::NotifyOnCategory,
+        Self::HideReruns,
+        Self::GroupLiveByGame,
+        Self::NotificationsPaused,
+    ];
+
+    /// The config field name, used to identify the toggle in menu ids and
+    /// events
+    pub fn key(self) -> &'static str {
+        match self {
+            Self::NotifyOnLive => "notify_on_live",
+            Self::NotifyOnCategory => "notify_on_category",
+            Self::HideReruns => "hide_reruns",
+            Self::GroupLiveByGame => "group_live_by_game",
+            Self::NotificationsPaused => "notifications_paused",
+        }
+    }
+
+    pub fn from_key(key: &str) -> Option<Self> {
+        Self::ALL.into_iter().find(|t| t.key() == key)
+    }
+
+    /// Whether the setting is on in `config`
+    pub fn is_on(self, config: &Config) -> bool {
+        match self {
+            Self::NotifyOnLive => config.notify_on_live,
+            Self::NotifyOnCategory => config.notify_on_category,
+            Self::HideReruns => config.hide_reruns,
+            Self::GroupLiveByGame => config.group_live_by_game,
+            Self::NotificationsPaused => config.notifications_paused,
+        }
+    }
+
+    /// Flips the setting in `config`
+    pub fn toggle(self, config: &mut Config) {
+        let value = match self {
+            Self::NotifyOnLive => &mut config.notify_on_live,
+            Self::NotifyOnCategory => &mut config.notify_on_category,
+            Self::HideReruns => &mut config.hide_reruns,
+            Self::GroupLiveByGame => &mut config.group_live_by_game,
+            Self::NotificationsPaused => &mut config.notifications_paused,
+        };
+        *value = !*value;
+    }
+}
+
 /// Configuration manager
 pub struct ConfigManager {
     config: RwLock<Config>,
@@ -256,6 +589,15 @@ impl ConfigManager {
             .join(APP_NAME))
     }
 
+    /// Returns the directory for app state that isn't configuration, such as
+    /// notification history (the config directory where there is no state directory)
+    pub fn state_dir() -> Result<PathBuf> {
+        match dirs::state_dir() {
+            Some(dir) => Ok(dir.join(APP_NAME)),
+            None => Self::config_dir(),
+        }
+    }
+
     /// Creates a `ConfigManager` pre-loaded with the given config (no disk I/O).
     /// Only available in tests.
     #[cfg(test)]
@@ -381,12 +723,45 @@ mod tests {
         assert_eq!(config.poll_interval_sec, DEFAULT_POLL_INTERVAL_SEC);
         assert_eq!(config.notify_on_live, DEFAULT_NOTIFY_ON_LIVE);
         assert_eq!(config.notify_on_category, DEFAULT_NOTIFY_ON_CATEGORY);
+        assert_eq!(config.notify_on_title, DEFAULT_NOTIFY_ON_TITLE);
+        assert_eq!(
+            config.notify_on_schedule_change,
+            DEFAULT_NOTIFY_ON_SCHEDULE_CHANGE
+        );
         assert_eq!(config.notify_max_gap_min, DEFAULT_NOTIFY_MAX_GAP_MIN);
+        assert_eq!(
+            config.live_summary_threshold,
+            DEFAULT_LIVE_SUMMARY_THRESHOLD
+        );
+        assert_eq!(
+            config.live_summary_window_sec,
+            DEFAULT_LIVE_SUMMARY_WINDOW_SEC
+        );
+        assert_eq!(
+            config.notify_rate_limit_per_min,
+            DEFAULT_NOTIFY_RATE_LIMIT_PER_MIN
+        );
+        assert_eq!(
+            config.live_repeat_window_min,
+            DEFAULT_LIVE_REPEAT_WINDOW_MIN
+        );
+        assert_eq!(
+            config.notify_on_error_recovery,
+            DEFAULT_NOTIFY_ON_ERROR_RECOVERY
+        );
+        assert_eq!(
+            config.notify_on_connection_issues,
+            DEFAULT_NOTIFY_ON_CONNECTION_ISSUES
+        );
         assert_eq!(config.schedule_stale_hours, DEFAULT_SCHEDULE_STALE_HOURS);
         assert_eq!(
             config.schedule_check_interval_sec,
             DEFAULT_SCHEDULE_CHECK_INTERVAL_SEC
         );
+        assert_eq!(
+            config.no_schedule_skip_hours,
+            DEFAULT_NO_SCHEDULE_SKIP_HOURS
+        );
         assert_eq!(config.followed_refresh_min, DEFAULT_FOLLOWED_REFRESH_MIN);
         assert_eq!(
             config.schedule_lookahead_hours,
@@ -398,6 +773,17 @@ mod tests {
         );
         assert_eq!(config.live_menu_limit, DEFAULT_LIVE_MENU_LIMIT);
         assert_eq!(config.schedule_menu_limit, DEFAULT_SCHEDULE_MENU_LIMIT);
+        assert_eq!(config.schedule_time_format, ScheduleTimeFormat::Absolute);
+        assert_eq!(config.group_live_by_game, DEFAULT_GROUP_LIVE_BY_GAME);
+        assert_eq!(config.hide_reruns, DEFAULT_HIDE_RERUNS);
+        assert_eq!(config.notifications_paused, DEFAULT_NOTIFICATIONS_PAUSED);
+        assert_eq!(
+            config.show_played_categories,
+            DEFAULT_SHOW_PLAYED_CATEGORIES
+        );
+        assert_eq!(config.notification_backend, None);
+        assert_eq!(config.notification_forward.url, None);
+        assert_eq!(config.external_player, None);
         assert!(config.followed_categories.is_empty());
         assert!(config.streamer_settings.is_empty());
     }
@@ -441,6 +827,8 @@ mod tests {
                 display_name: "TestStreamer".to_string(),
                 importance: StreamerImportance::Favourite,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: DateTime::from_timestamp(1_700_000_000, 0),
             },
         );
 
@@ -448,21 +836,43 @@ mod tests {
             poll_interval_sec: 90,
             notify_on_live: true,
             notify_on_category: false,
+            notify_on_title: true,
+            notify_on_schedule_change: true,
             notify_max_gap_min: 15,
+            live_summary_threshold: 5,
+            live_summary_window_sec: 120,
+            notify_rate_limit_per_min: 4,
+            live_repeat_window_min: 30,
+            notify_on_error_recovery: false,
+            notify_on_connection_issues: true,
             schedule_stale_hours: 48,
             schedule_check_interval_sec: 20,
+            no_schedule_skip_hours: 24,
             followed_refresh_min: 30,
             schedule_lookahead_hours: 12,
             schedule_before_now_min: 20,
             live_menu_limit: 7,
             schedule_menu_limit: 3,
+            schedule_time_format: ScheduleTimeFormat::Both,
+            group_live_by_game: true,
+            hide_reruns: true,
+            notifications_paused: true,
+            show_played_categories: true,
             hotness_z_threshold: 3.0,
             hotness_min_observations: 10,
             hotness_min_streams: 5,
             notify_on_hot: false,
+            keep_stream_details: true,
+            notification_backend: Some("notify-send".to_string()),
+            notification_forward: ForwardConfig {
+                url: Some("https://ntfy.sh/my-topic".to_string()),
+            },
+            external_player: Some("streamlink {url} best".to_string()),
             followed_categories: vec![FollowedCategory {
                 id: "12345".to_string(),
                 name: "Just Chatting".to_string(),
+                tags: vec![],
+                viewer_alert: Some(5000),
             }],
             streamer_settings,
         };
@@ -473,7 +883,28 @@ mod tests {
         assert_eq!(deserialized.poll_interval_sec, original.poll_interval_sec);
         assert_eq!(deserialized.notify_on_live, original.notify_on_live);
         assert_eq!(deserialized.notify_on_category, original.notify_on_category);
+        assert_eq!(deserialized.notify_on_title, original.notify_on_title);
+        assert_eq!(
+            deserialized.notify_on_schedule_change,
+            original.notify_on_schedule_change
+        );
         assert_eq!(deserialized.notify_max_gap_min, original.notify_max_gap_min);
+        assert_eq!(
+            deserialized.notify_rate_limit_per_min,
+            original.notify_rate_limit_per_min
+        );
+        assert_eq!(
+            deserialized.live_repeat_window_min,
+            original.live_repeat_window_min
+        );
+        assert_eq!(
+            deserialized.notify_on_error_recovery,
+            original.notify_on_error_recovery
+        );
+        assert_eq!(
+            deserialized.notify_on_connection_issues,
+            original.notify_on_connection_issues
+        );
         assert_eq!(
             deserialized.schedule_stale_hours,
             original.schedule_stale_hours
@@ -482,6 +913,10 @@ mod tests {
             deserialized.schedule_check_interval_sec,
             original.schedule_check_interval_sec
         );
+        assert_eq!(
+            deserialized.no_schedule_skip_hours,
+            original.no_schedule_skip_hours
+        );
         assert_eq!(
             deserialized.followed_refresh_min,
             original.followed_refresh_min
@@ -504,6 +939,20 @@ mod tests {
             deserialized.schedule_menu_limit,
             original.schedule_menu_limit
         );
+        assert_eq!(
+            deserialized.schedule_time_format,
+            original.schedule_time_format
+        );
+        assert_eq!(deserialized.group_live_by_game, original.group_live_by_game);
+        assert_eq!(deserialized.hide_reruns, original.hide_reruns);
+        assert_eq!(
+            deserialized.notifications_paused,
+            original.notifications_paused
+        );
+        assert_eq!(
+            deserialized.show_played_categories,
+            original.show_played_categories
+        );
         assert!(
             (deserialized.hotness_z_threshold - original.hotness_z_threshold).abs() < f64::EPSILON
         );
@@ -516,6 +965,80 @@ mod tests {
             original.hotness_min_streams
         );
         assert_eq!(deserialized.notify_on_hot, original.notify_on_hot);
+        assert_eq!(
+            deserialized.keep_stream_details,
+            original.keep_stream_details
+        );
+        assert_eq!(
+            deserialized.notification_backend,
+            original.notification_backend
+        );
+        assert_eq!(
+            deserialized.notification_forward,
+            original.notification_forward
+        );
+        assert_eq!(deserialized.external_player, original.external_player);
+    }
+
+    #[test]
+    fn menu_toggle_flips_its_setting() {
+        let mut config = Config::default();
+
+        for toggle in MenuToggle::ALL {
+            let before = toggle.is_on(&config);
+            toggle.toggle(&mut config);
+            assert_eq!(toggle.is_on(&config), !before, "{toggle:?}");
+            assert_eq!(MenuToggle::from_key(toggle.key()), Some(toggle));
+        }
+        assert!(!config.notify_on_live);
+        assert!(config.notifications_paused);
+        assert_eq!(MenuToggle::from_key("poll_interval_sec"), None);
+    }
+
+    #[test]
+    fn set_favourite_adds_and_removes_favourites() {
+        let mut settings = HashMap::new();
+
+        set_favourite(&mut settings, "alice", "Alice", true);
+        assert_eq!(settings["alice"].display_name, "Alice");
+        assert_eq!(settings["alice"].importance, StreamerImportance::Favourite);
+
+        set_favourite(&mut settings, "ALICE", "Alice", false);
+        assert_eq!(settings.len(), 1);
+        assert_eq!(settings["alice"].importance, StreamerImportance::Normal);
+
+        set_favourite(&mut settings, "bob", "Bob", false);
+        assert!(!settings.contains_key("bob"));
+    }
+
+    #[test]
+    fn unfavouriting_keeps_silent_streamers_silent() {
+        let mut settings = HashMap::new();
+        settings.insert(
+            "alice".to_string(),
+            StreamerSettings {
+                display_name: "Alice".to_string(),
+                importance: StreamerImportance::Silent,
+                hotness_z_threshold_override: None,
+                notify_on_offline: true,
+                muted_until: None,
+            },
+        );
+
+        set_favourite(&mut settings, "alice", "Alice", false);
+        assert_eq!(settings["alice"].importance, StreamerImportance::Silent);
+
+        set_favourite(&mut settings, "alice", "Alice", true);
+        assert_eq!(settings["alice"].importance, StreamerImportance::Favourite);
+        assert!(settings["alice"].notify_on_offline);
+    }
+
+    #[test]
+    fn schedule_time_format_uses_snake_case() {
+        let config: Config =
+            serde_json::from_str(r#"{"schedule_time_format": "relative"}"#).unwrap();
+
+        assert_eq!(config.schedule_time_format, ScheduleTimeFormat::Relative);
     }
 
     #[test]
@@ -535,19 +1058,53 @@ mod tests {
         assert_eq!(config.followed_categories[1].name, "Minecraft");
     }
 
+    #[test]
+    fn followed_category_tags_default_to_empty() {
+        let json = r#"{
+            "followed_categories": [
+                {"id": "509658", "name": "Just Chatting"},
+                {"id": "27471", "name": "Minecraft", "tags": ["DropsEnabled"]}
+            ]
+        }"#;
+        let config: Config = serde_json::from_str(json).unwrap();
+
+        assert!(config.followed_categories[0].tags.is_empty());
+        assert_eq!(config.followed_categories[1].tags, vec!["DropsEnabled"]);
+    }
+
+    #[test]
+    fn followed_category_viewer_alert_is_off_by_default() {
+        let json = r#"{
+            "followed_categories": [
+                {"id": "509658", "name": "Just Chatting"},
+                {"id": "1469308723", "name": "Factorio", "viewer_alert": 5000}
+            ]
+        }"#;
+        let config: Config = serde_json::from_str(json).unwrap();
+
+        assert_eq!(config.followed_categories[0].viewer_alert, None);
+        assert_eq!(config.followed_categories[1].viewer_alert, Some(5000));
+    }
+
     #[test]
     fn followed_category_equality() {
         let cat1 = FollowedCategory {
             id: "123".to_string(),
             name: "Test".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         };
         let cat2 = FollowedCategory {
             id: "123".to_string(),
             name: "Test".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         };
         let cat3 = FollowedCategory {
             id: "456".to_string(),
             name: "Test".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         };
 
         assert_eq!(cat1, cat2);
@@ -671,6 +1228,57 @@ mod tests {
         assert_eq!(settings.hotness_z_threshold_override, None);
     }
 
+    #[test]
+    fn temporary_mute_expires() {
+        let until = DateTime::from_timestamp(1_700_000_000, 0).unwrap();
+        let mut settings = HashMap::new();
+        settings.insert(
+            "ninja".to_string(),
+            StreamerSettings {
+                display_name: "Ninja".to_string(),
+                importance: StreamerImportance::Normal,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: Some(until),
+            },
+        );
+
+        assert!(is_streamer_muted(
+            &settings,
+            "Ninja",
+            until - chrono::Duration::minutes(1)
+        ));
+        assert!(!is_streamer_muted(&settings, "ninja", until));
+        assert!(!is_streamer_muted(&settings, "shroud", until));
+    }
+
+    #[test]
+    fn silent_streamer_is_muted_without_temporary_mute() {
+        let json = r#"{
+            "streamer_settings": {
+                "shroud": {"display_name": "Shroud", "importance": "silent"}
+            }
+        }"#;
+        let config: Config = serde_json::from_str(json).unwrap();
+
+        assert_eq!(
+            config.streamer_settings.get("shroud").unwrap().muted_until,
+            None
+        );
+        assert!(is_streamer_muted(
+            &config.streamer_settings,
+            "shroud",
+            Utc::now()
+        ));
+    }
+
+    #[test]
+    fn default_keep_stream_details_is_false() {
+        let config: Config = serde_json::from_str("{}").unwrap();
+        assert_eq!(config.keep_stream_details, DEFAULT_KEEP_STREAM_DETAILS);
+        assert!(!Config::default().keep_stream_details);
+    }
+
     #[test]
     fn deserialize_ignores_unknown_fields() {
         let json = r#"{
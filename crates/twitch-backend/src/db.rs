@@ -1,9 +1,9 @@
-use std::collections::HashMap;
+use std::collections::{HashMap, HashSet};
 use std::path::Path;
 use std::sync::{Arc, Mutex};
 
 use chrono::{DateTime, Duration, Utc};
-use rusqlite::{Connection, OptionalExtension};
+use rusqlite::Connection;
 
 use crate::hotness_detection::ViewerObservation;
 use crate::twitch::{FollowedChannel, ScheduledStream, Stream};
@@ -260,6 +260,22 @@ impl Database {
         &self,
         stale_threshold_secs: i64,
     ) -> anyhow::Result<Option<(i64, String, String)>> {
+        Ok(self
+            .get_next_stale_broadcasters(stale_threshold_secs, 1, &HashSet::new())?
+            .into_iter()
+            .next())
+    }
+
+    /// Returns up to `limit` currently-followed broadcasters whose schedule
+    /// hasn't been checked within `stale_threshold_secs` seconds, most stale first.
+    ///
+    /// Broadcasters in `skip` are left in the queue for a later call.
+    pub fn get_next_stale_broadcasters(
+        &self,
+        stale_threshold_secs: i64,
+        limit: usize,
+        skip: &HashSet<i64>,
+    ) -> anyhow::Result<Vec<(i64, String, String)>> {
         let conn = self.conn.lock().unwrap();
         let threshold = Utc::now().timestamp() - stale_threshold_secs;
         let mut stmt = conn.prepare(
@@ -268,18 +284,29 @@ impl Database {
              JOIN schedule_last_checked s ON f.broadcaster_id = s.broadcaster_id
              WHERE s.last_checked_at < ?1
              ORDER BY s.last_checked_at ASC
-             LIMIT 1",
+             LIMIT ?2",
         )?;
-        let result = stmt
-            .query_row(rusqlite::params![threshold], |row| {
-                Ok((
-                    row.get::<_, i64>(0)?,
-                    row.get::<_, String>(1)?,
-                    row.get::<_, String>(2)?,
-                ))
-            })
-            .optional()?;
-        Ok(result)
+        // Every skipped row could be among the most stale, so fetch enough
+        // to still fill `limit` after dropping them
+        let fetch = limit + skip.len();
+        let rows = stmt.query_map(rusqlite::params![threshold, fetch as i64], |row| {
+            Ok((
+                row.get::<_, i64>(0)?,
+                row.get::<_, String>(1)?,
+                row.get::<_, String>(2)?,
+            ))
+        })?;
+        let mut broadcasters = Vec::with_capacity(limit);
+        for row in rows {
+            if broadcasters.len() >= limit {
+                break;
+            }
+            let row = row?;
+            if !skip.contains(&row.0) {
+                broadcasters.push(row);
+            }
+        }
+        Ok(broadcasters)
     }
 
     /// Marks a broadcaster's schedule as just-checked.
@@ -385,6 +412,18 @@ impl Database {
         Ok(schedules)
     }
 
+    /// Returns the start time of the scheduled stream with segment `id`, or
+    /// `None` if it isn't stored
+    pub fn get_schedule_start(&self, id: &str) -> anyhow::Result<Option<DateTime<Utc>>> {
+        let conn = self.conn.lock().unwrap();
+        let mut stmt = conn.prepare("SELECT start_time FROM scheduled_streams WHERE id = ?1")?;
+        let mut rows = stmt.query(rusqlite::params![id])?;
+        let Some(row) = rows.next()? else {
+            return Ok(None);
+        };
+        Ok(DateTime::from_timestamp(row.get(0)?, 0))
+    }
+
     /// Infers future schedules from historical stream data.
     ///
     /// Thin wrapper around `schedule_inference::infer_schedules`. Loads streams
@@ -575,6 +614,8 @@ mod tests {
             started_at,
             thumbnail_url: "https://example.com/thumb.jpg".to_string(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
@@ -841,6 +882,50 @@ mod tests {
         assert!(result.is_none());
     }
 
+    #[test]
+    fn stale_broadcasters_respects_limit_and_staleness_order() {
+        let db = in_memory_db();
+        db.sync_followed(&[
+            make_channel("100", "StreamerA"),
+            make_channel("200", "StreamerB"),
+            make_channel("300", "StreamerC"),
+        ])
+        .unwrap();
+        db.ensure_schedule_queue_entries(&[100, 200, 300]).unwrap();
+        db.update_last_checked(100).unwrap();
+
+        let result = db
+            .get_next_stale_broadcasters(60, 5, &HashSet::new())
+            .unwrap();
+        let ids: Vec<i64> = result.iter().map(|b| b.0).collect();
+        assert_eq!(ids.len(), 2);
+        assert!(!ids.contains(&100));
+
+        let limited = db
+            .get_next_stale_broadcasters(60, 1, &HashSet::new())
+            .unwrap();
+        assert_eq!(limited.len(), 1);
+    }
+
+    #[test]
+    fn stale_broadcasters_skips_given_ids_without_shrinking_batch() {
+        let db = in_memory_db();
+        db.sync_followed(&[
+            make_channel("100", "StreamerA"),
+            make_channel("200", "StreamerB"),
+            make_channel("300", "StreamerC"),
+        ])
+        .unwrap();
+        db.ensure_schedule_queue_entries(&[100, 200, 300]).unwrap();
+
+        let skip: HashSet<i64> = [100].into_iter().collect();
+        let result = db.get_next_stale_broadcasters(60, 2, &skip).unwrap();
+        let ids: Vec<i64> = result.iter().map(|b| b.0).collect();
+
+        assert_eq!(ids.len(), 2);
+        assert!(!ids.contains(&100));
+    }
+
     // === replace_future_schedules + get_upcoming_schedules tests ===
 
     fn make_scheduled_stream(
@@ -907,6 +992,22 @@ mod tests {
         assert_eq!(upcoming[0].id, "s2");
     }
 
+    #[test]
+    fn get_schedule_start_finds_stored_segment() {
+        let db = in_memory_db();
+        db.sync_followed(&[make_channel("100", "StreamerA")])
+            .unwrap();
+        let stream = make_scheduled_stream("s1", "100", 30);
+        db.replace_future_schedules(100, std::slice::from_ref(&stream))
+            .unwrap();
+
+        assert_eq!(
+            db.get_schedule_start("s1").unwrap(),
+            Some(DateTime::from_timestamp(stream.start_time.timestamp(), 0).unwrap())
+        );
+        assert_eq!(db.get_schedule_start("missing").unwrap(), None);
+    }
+
     #[test]
     fn get_upcoming_filters_by_horizon() {
         let db = in_memory_db();
@@ -1,4 +1,6 @@
 use std::collections::{HashMap, HashSet};
+
+use chrono::{DateTime, Utc};
 use std::sync::Arc;
 
 use tokio::sync::{broadcast, mpsc, watch};
@@ -7,7 +9,9 @@ use tokio::task::JoinHandle;
 use crate::app_services::AppServices;
 use crate::config::{Config, FollowedCategory};
 use crate::events::BackendEvent;
-use crate::twitch::{FollowedChannel, ScheduledStream, Stream};
+use crate::notification_history::HistoryEntry;
+use crate::state::ViewerTrend;
+use crate::twitch::{ApiHealth, FollowedChannel, ScheduledStream, Stream};
 
 /// Raw display data sent by the backend whenever state changes.
 ///
@@ -16,11 +20,16 @@ use crate::twitch::{FollowedChannel, ScheduledStream, Stream};
 #[derive(Clone, Debug, Default)]
 pub struct RawDisplayData {
     pub is_authenticated: bool,
+    /// Login of the signed-in user, empty when logged out.
+    pub user_login: String,
+    /// Followed live streams by viewers, favourites first.
     pub live_streams: Vec<Stream>,
     pub scheduled_streams: Vec<ScheduledStream>,
     pub schedules_loaded: bool,
     pub followed_channels: Vec<FollowedChannel>,
     pub followed_categories: Vec<FollowedCategory>,
+    /// Games followed streamers are playing, when listing them is on.
+    pub played_categories: Vec<FollowedCategory>,
     pub category_streams: HashMap<String, Vec<Stream>>,
     pub config: Config,
     /// Cached profile image URLs keyed by user/broadcaster ID.
@@ -29,6 +38,17 @@ pub struct RawDisplayData {
     pub box_art_urls: HashMap<String, String>,
     /// User IDs of streams currently detected as "hot" (significantly above normal viewers).
     pub hot_stream_ids: HashSet<String>,
+    /// Viewer-count trend per live stream, keyed by user ID.
+    pub viewer_trends: HashMap<String, ViewerTrend>,
+    /// When each live stream was first noticed live, keyed by user ID.
+    pub first_seen_at: HashMap<String, DateTime<Utc>>,
+    /// Latest Twitch API success and failure, for the connection status.
+    pub api_health: ApiHealth,
+    /// Most recent notifications, newest first.
+    pub recent_notifications: Vec<HistoryEntry>,
+    /// Backend desktop notifications are shown with (e.g. "dbus"), for the
+    /// status display.
+    pub notification_backend: Option<&'static str>,
 }
 
 /// Commands sent to the backend auth task.
@@ -0,0 +1,299 @@
+//! Image downloads (stream thumbnails, profile images, box art) with an
+//! on-disk cache.
+//!
+//! Files live in the platform cache directory, named by a hash of their URL.
+//! Stream thumbnails are refreshed by Twitch every few minutes, so they expire
+//! quickly; profile images and box art are kept for a week. The directory is
+//! pruned oldest-first whenever it grows past [`MAX_CACHE_BYTES`].
+
+use std::path::{Path, PathBuf};
+use std::time::{Duration, SystemTime};
+
+use anyhow::Context;
+
+use crate::twitch::http::{HttpClient, ReqwestClient};
+
+/// How long a cached stream thumbnail is served before being re-downloaded
+pub const STREAM_THUMBNAIL_TTL: Duration = Duration::from_secs(5 * 60);
+
+/// How long cached profile images and box art are served
+pub const STATIC_IMAGE_TTL: Duration = Duration::from_secs(7 * 24 * 60 * 60);
+
+/// Largest image that will be downloaded and cached
+const MAX_IMAGE_BYTES: usize = 2 * 1024 * 1024;
+
+/// Total size the cache directory is pruned back to
+const MAX_CACHE_BYTES: u64 = 50 * 1024 * 1024;
+
+/// Fills in the `{width}` and `{height}` placeholders of a Twitch image URL
+/// template (stream thumbnails and box art)
+pub fn thumbnail_url(template: &str, width: u32, height: u32) -> String {
+    template
+        .replace("{width}", &width.to_string())
+        .replace("{height}", &height.to_string())
+}
+
+/// Downloads images and caches them on disk
+pub struct ImageCache<H: HttpClient = ReqwestClient> {
+    http: H,
+    dir: PathBuf,
+    max_bytes: u64,
+}
+
+impl ImageCache<ReqwestClient> {
+    /// Creates a cache in the platform cache directory
+    /// (e.g. `~/.cache/twitch-tray/images` on Linux)
+    pub fn new() -> anyhow::Result<Self> {
+        let dir = dirs::cache_dir()
+            .context("Could not determine cache directory")?
+            .join("twitch-tray")
+            .join("images");
+        Ok(Self {
+            http: ReqwestClient::new(),
+            dir,
+            max_bytes: MAX_CACHE_BYTES,
+        })
+    }
+}
+
+impl<H: HttpClient> ImageCache<H> {
+    /// Returns the image at `url`, from disk if a fresh copy is cached
+    pub async fn fetch(&self, url: &str) -> anyhow::Result<Vec<u8>> {
+        let path = self.path_for(url);
+        if let Some(bytes) = read_fresh(&path, ttl_for(url)) {
+            return Ok(bytes);
+        }
+
+        let (status, bytes) = self.http.get_bytes(url).await?;
+        if !(200..300).contains(&status) {
+            anyhow::bail!("Image request failed with status {status}: {url}");
+        }
+        if bytes.len() > MAX_IMAGE_BYTES {
+            anyhow::bail!("Image too large ({} bytes): {url}", bytes.len());
+        }
+
+        if let Err(e) = self.store(&path, &bytes) {
+            tracing::warn!("Failed to cache image {}: {}", url, e);
+        }
+        Ok(bytes)
+    }
+
+    /// Returns where `url` is stored on disk if a fresh copy is cached,
+    /// without touching the network
+    pub fn cached_path(&self, url: &str) -> Option<PathBuf> {
+        let path = self.path_for(url);
+        is_fresh(&path, ttl_for(url)).then_some(path)
+    }
+
+    /// Deletes expired files, then the oldest files until the directory fits
+    /// within [`MAX_CACHE_BYTES`]
+    pub fn prune(&self) -> anyhow::Result<()> {
+        let now = SystemTime::now();
+        let mut files = Vec::new();
+        for entry in std::fs::read_dir(&self.dir)? {
+            let entry = entry?;
+            let meta = entry.metadata()?;
+            if !meta.is_file() {
+                continue;
+            }
+            let modified = meta.modified().unwrap_or(SystemTime::UNIX_EPOCH);
+            let age = now.duration_since(modified).unwrap_or_default();
+            if age >= STATIC_IMAGE_TTL {
+                std::fs::remove_file(entry.path())?;
+            } else {
+                files.push((modified, meta.len(), entry.path()));
+            }
+        }
+
+        let mut total: u64 = files.iter().map(|(_, len, _)| len).sum();
+        files.sort_by_key(|(modified, _, _)| *modified);
+        for (_, len, path) in files {
+            if total <= self.max_bytes {
+                break;
+            }
+            std::fs::remove_file(path)?;
+            total -= len;
+        }
+        Ok(())
+    }
+
+    fn path_for(&self, url: &str) -> PathBuf {
+        self.dir.join(format!("{:016x}", url_hash(url)))
+    }
+
+    /// Writes via a temporary file so readers never see a partial image
+    fn store(&self, path: &Path, bytes: &[u8]) -> anyhow::Result<()> {
+        std::fs::create_dir_all(&self.dir)?;
+        let tmp = path.with_extension("tmp");
+        std::fs::write(&tmp, bytes)?;
+        std::fs::rename(&tmp, path)?;
+        self.prune()
+    }
+}
+
+/// Test-only constructor for dependency injection
+#[cfg(test)]
+impl<H: HttpClient> ImageCache<H> {
+    pub fn with_http_client(dir: PathBuf, http: H) -> Self {
+        Self {
+            http,
+            dir,
+            max_bytes: MAX_CACHE_BYTES,
+        }
+    }
+}
+
+/// Stream thumbnails change while the stream runs; everything else rarely changes
+fn ttl_for(url: &str) -> Duration {
+    if url.contains("/previews-ttv/") {
+        STREAM_THUMBNAIL_TTL
+    } else {
+        STATIC_IMAGE_TTL
+    }
+}
+
+fn is_fresh(path: &Path, ttl: Duration) -> bool {
+    let Some(modified) = std::fs::metadata(path).and_then(|m| m.modified()).ok() else {
+        return false;
+    };
+    let age = SystemTime::now()
+        .duration_since(modified)
+        .unwrap_or_default();
+    age < ttl
+}
+
+fn read_fresh(path: &Path, ttl: Duration) -> Option<Vec<u8>> {
+    if !is_fresh(path, ttl) {
+        return None;
+    }
+    std::fs::read(path).ok()
+}
+
+/// FNV-1a, which unlike `DefaultHasher` is stable across Rust releases, so
+/// cache file names survive upgrades
+fn url_hash(url: &str) -> u64 {
+    url.bytes().fold(0xcbf2_9ce4_8422_2325, |hash, byte| {
+        (hash ^ u64::from(byte)).wrapping_mul(0x0100_0000_01b3)
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::twitch::http::mock::MockHttpClient;
+
+    const THUMBNAIL: &str =
+        "https://static-cdn.jtvnw.net/previews-ttv/live_user_streamer-320x180.jpg";
+    const BOX_ART: &str = "https://static-cdn.jtvnw.net/ttv-boxart/509658-144x192.jpg";
+
+    fn backdate(path: &Path, by: Duration) {
+        let file = std::fs::File::options().write(true).open(path).unwrap();
+        file.set_modified(SystemTime::now() - by).unwrap();
+    }
+
+    #[test]
+    fn thumbnail_url_fills_placeholders() {
+        assert_eq!(
+            thumbnail_url(
+                "https://static-cdn.jtvnw.net/previews-ttv/live_user_x-{width}x{height}.jpg",
+                320,
+                180
+            ),
+            "https://static-cdn.jtvnw.net/previews-ttv/live_user_x-320x180.jpg"
+        );
+    }
+
+    #[tokio::test]
+    async fn fetch_serves_cached_copy() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get(BOX_ART, 200, "image-bytes");
+        let cache = ImageCache::with_http_client(dir.path().to_path_buf(), mock.clone());
+
+        let first = cache.fetch(BOX_ART).await.unwrap();
+        let second = cache.fetch(BOX_ART).await.unwrap();
+
+        assert_eq!(first, b"image-bytes");
+        assert_eq!(second, b"image-bytes");
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn stale_thumbnail_is_downloaded_again() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get(THUMBNAIL, 200, "frame");
+        let cache = ImageCache::with_http_client(dir.path().to_path_buf(), mock.clone());
+
+        cache.fetch(THUMBNAIL).await.unwrap();
+        backdate(&cache.path_for(THUMBNAIL), STREAM_THUMBNAIL_TTL);
+        cache.fetch(THUMBNAIL).await.unwrap();
+
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn cached_path_only_returns_fresh_files() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get(THUMBNAIL, 200, "frame");
+        let cache = ImageCache::with_http_client(dir.path().to_path_buf(), mock.clone());
+
+        assert_eq!(cache.cached_path(THUMBNAIL), None);
+        cache.fetch(THUMBNAIL).await.unwrap();
+        let path = cache.cached_path(THUMBNAIL).unwrap();
+        assert_eq!(std::fs::read(&path).unwrap(), b"frame");
+
+        backdate(&path, STREAM_THUMBNAIL_TTL);
+        assert_eq!(cache.cached_path(THUMBNAIL), None);
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn box_art_outlives_thumbnail_ttl() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get(BOX_ART, 200, "art");
+        let cache = ImageCache::with_http_client(dir.path().to_path_buf(), mock.clone());
+
+        cache.fetch(BOX_ART).await.unwrap();
+        backdate(&cache.path_for(BOX_ART), STREAM_THUMBNAIL_TTL);
+        cache.fetch(BOX_ART).await.unwrap();
+
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn failed_download_is_not_cached() {
+        let dir = tempfile::tempdir().unwrap();
+        let mock = MockHttpClient::new().on_get_not_found(BOX_ART);
+        let cache = ImageCache::with_http_client(dir.path().to_path_buf(), mock);
+
+        assert!(cache.fetch(BOX_ART).await.is_err());
+        assert!(!cache.path_for(BOX_ART).exists());
+    }
+
+    #[test]
+    fn prune_drops_expired_and_oldest_files() {
+        let dir = tempfile::tempdir().unwrap();
+        let mut cache =
+            ImageCache::with_http_client(dir.path().to_path_buf(), MockHttpClient::new());
+        cache.max_bytes = 100;
+        let big = [0u8; 50];
+
+        let expired = dir.path().join("expired");
+        std::fs::write(&expired, b"x").unwrap();
+        backdate(&expired, STATIC_IMAGE_TTL);
+        let oldest = dir.path().join("oldest");
+        std::fs::write(&oldest, &big).unwrap();
+        backdate(&oldest, Duration::from_secs(60));
+        let middle = dir.path().join("middle");
+        std::fs::write(&middle, &big).unwrap();
+        backdate(&middle, Duration::from_secs(30));
+        let newest = dir.path().join("newest");
+        std::fs::write(&newest, b"x").unwrap();
+
+        cache.prune().unwrap();
+
+        assert!(!expired.exists());
+        assert!(!oldest.exists());
+        assert!(middle.exists());
+        assert!(newest.exists());
+    }
+}
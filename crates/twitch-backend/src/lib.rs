@@ -3,18 +3,24 @@
 
 pub mod app_services;
 pub mod auth;
+pub mod calendar;
+pub mod category_alerts;
 pub mod config;
 pub mod db;
 pub mod events;
 pub mod handle;
 pub mod hotness_detection;
+pub mod images;
 pub mod notification_dispatcher;
 pub mod notification_filter;
+pub mod notification_history;
 pub mod notify;
+pub mod player;
 pub mod schedule_inference;
 pub mod schedule_walker;
 pub mod session;
 pub mod state;
+pub mod text;
 pub mod twitch;
 
 pub(crate) mod backend;
@@ -23,6 +29,6 @@ pub(crate) mod backend;
 pub(crate) mod test_helpers;
 
 // Primary public API
-pub use backend::start;
+pub use backend::{send_test_notification, start};
 pub use events::BackendEvent;
 pub use handle::{AuthCommand, BackendHandle, LoginProgress, RawDisplayData};
@@ -5,6 +5,7 @@
 //! that is `Notifier`'s job. It owns the policy of *when* to notify, delegating
 //! the heavy lifting to `filter_notifications`.
 
+use std::collections::HashMap;
 use std::sync::atomic::{AtomicBool, Ordering};
 use std::sync::Arc;
 
@@ -12,10 +13,14 @@ use chrono::{DateTime, Utc};
 use tokio::sync::broadcast;
 use tokio::task::JoinHandle;
 
-use crate::config::ConfigManager;
+use crate::config::{ConfigManager, StreamerImportance, StreamerSettings};
 use crate::notification_filter::filter_notifications;
 use crate::notify::Notifier;
-use crate::state::StreamsUpdated;
+use crate::state::{StreamsUpdated, TitleChange};
+use crate::twitch::Stream;
+
+/// Title edits to one channel within this many seconds produce one notification
+const TITLE_DEBOUNCE_SECS: i64 = 60;
 
 /// Listens for `StreamsUpdated` broadcast events and dispatches desktop
 /// notifications according to the current config and notification filter.
@@ -47,6 +52,9 @@ impl NotificationDispatcher {
 
     pub(crate) async fn listen(&self, mut rx: broadcast::Receiver<StreamsUpdated>) {
         let mut last_event_time: Option<DateTime<Utc>> = None;
+        // When each recent live notification was sent, for coalescing bursts
+        let mut recent_live: Vec<DateTime<Utc>> = Vec::new();
+        let mut titles = TitleDebouncer::default();
 
         loop {
             match rx.recv().await {
@@ -63,12 +71,25 @@ impl NotificationDispatcher {
                     );
                     last_event_time = Some(now);
 
-                    if cfg.notify_on_live {
-                        for stream in decision.streams_to_notify {
-                            if let Err(e) = self.notifier.stream_live(&stream) {
+                    if cfg.notify_on_live && !decision.streams_to_notify.is_empty() {
+                        let window = chrono::Duration::seconds(cfg.live_summary_window_sec as i64);
+                        recent_live.retain(|sent_at| now - *sent_at < window);
+                        let streams = decision.streams_to_notify;
+                        let count = streams.len();
+
+                        if count > 1 && recent_live.len() + count > cfg.live_summary_threshold {
+                            let streams = favourites_first(streams, &cfg.streamer_settings);
+                            if let Err(e) = self.notifier.streams_live_summary(&streams) {
                                 tracing::error!("Notification error: {}", e);
                             }
+                        } else {
+                            for stream in streams {
+                                if let Err(e) = self.notifier.stream_live(&stream) {
+                                    tracing::error!("Notification error: {}", e);
+                                }
+                            }
                         }
+                        recent_live.extend(std::iter::repeat_n(now, count));
                     }
                     if cfg.notify_on_category {
                         for change in decision.categories_to_notify {
@@ -80,6 +101,30 @@ impl NotificationDispatcher {
                             }
                         }
                     }
+                    if cfg.notify_on_title {
+                        for change in decision.titles_to_notify {
+                            titles.push(change, now);
+                        }
+                        for change in titles.take_settled(&event.streams, now) {
+                            if let Err(e) = self
+                                .notifier
+                                .title_changed(&change.stream, &change.old_title)
+                            {
+                                tracing::error!("Notification error: {}", e);
+                            }
+                        }
+                    } else {
+                        titles = TitleDebouncer::default();
+                    }
+                    // Offline notifications are opt-in per streamer, so no global toggle
+                    for offline in decision.offline_to_notify {
+                        if let Err(e) = self
+                            .notifier
+                            .stream_offline(&offline.stream, offline.live_for())
+                        {
+                            tracing::error!("Notification error: {}", e);
+                        }
+                    }
                 }
                 Err(broadcast::error::RecvError::Lagged(n)) => {
                     tracing::warn!("Notification listener lagged by {} events", n);
@@ -92,11 +137,67 @@ impl NotificationDispatcher {
     }
 }
 
+/// Holds title changes until a channel's title has settled, so a burst of
+/// edits becomes one notification from the original title to the latest one
+#[derive(Default)]
+struct TitleDebouncer {
+    /// Pending changes by user_id, with when the first edit was seen
+    pending: HashMap<String, (TitleChange, DateTime<Utc>)>,
+}
+
+impl TitleDebouncer {
+    fn push(&mut self, change: TitleChange, now: DateTime<Utc>) {
+        match self.pending.get_mut(&change.stream.user_id) {
+            Some((pending, _)) => pending.stream = change.stream,
+            None => {
+                self.pending
+                    .insert(change.stream.user_id.clone(), (change, now));
+            }
+        }
+    }
+
+    /// Returns changes that have been pending for the debounce window.
+    /// Changes for streams no longer live, or edited back to the original
+    /// title, are dropped.
+    fn take_settled(&mut self, live: &[Stream], now: DateTime<Utc>) -> Vec<TitleChange> {
+        let window = chrono::Duration::seconds(TITLE_DEBOUNCE_SECS);
+        let mut settled = Vec::new();
+        self.pending.retain(|user_id, (change, first_seen)| {
+            if !live.iter().any(|s| s.user_id == *user_id) {
+                return false;
+            }
+            if now - *first_seen < window {
+                return true;
+            }
+            if change.stream.title != change.old_title {
+                settled.push(change.clone());
+            }
+            false
+        });
+        settled
+    }
+}
+
+/// Orders favourite streamers first, keeping the original order otherwise
+fn favourites_first(
+    mut streams: Vec<Stream>,
+    settings: &HashMap<String, StreamerSettings>,
+) -> Vec<Stream> {
+    streams.sort_by_key(|s| {
+        settings
+            .get(&s.user_login)
+            .is_none_or(|s| s.importance != StreamerImportance::Favourite)
+    });
+    streams
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
     use crate::config::Config;
-    use crate::notify::mock::RecordingNotifier;
+    use crate::config::{StreamerImportance, StreamerSettings};
+    use crate::notify::mock::{NotificationType, RecordedNotification, RecordingNotifier};
+    use crate::notify::MutingNotifier;
     use crate::state::StreamsUpdated;
     use crate::twitch::Stream;
     use chrono::Utc;
@@ -114,6 +215,8 @@ mod tests {
             started_at: Utc::now() - chrono::Duration::hours(1),
             thumbnail_url: String::new(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
@@ -124,9 +227,41 @@ mod tests {
             streams: vec![stream.clone()],
             newly_live: vec![stream],
             category_changes: vec![],
+            title_changes: vec![],
+            went_offline: vec![],
+        }
+    }
+
+    fn make_multi_event(user_logins: &[&str]) -> StreamsUpdated {
+        let streams: Vec<Stream> = user_logins.iter().map(|l| make_stream(l)).collect();
+        StreamsUpdated {
+            streams: streams.clone(),
+            newly_live: streams,
+            category_changes: vec![],
+            title_changes: vec![],
+            went_offline: vec![],
         }
     }
 
+    /// Sends each event to a fresh dispatcher and returns what was notified
+    async fn dispatch(config: Config, events: Vec<StreamsUpdated>) -> Vec<RecordedNotification> {
+        let notifier = Arc::new(RecordingNotifier::new());
+        let dispatcher = NotificationDispatcher::new(
+            notifier.clone(),
+            Arc::new(ConfigManager::with_config(config)),
+            Arc::new(AtomicBool::new(true)),
+        );
+
+        let (tx, rx) = broadcast::channel(16);
+        let handle = tokio::spawn(async move { dispatcher.listen(rx).await });
+        for event in events {
+            tx.send(event).unwrap();
+        }
+        tokio::time::sleep(tokio::time::Duration::from_millis(50)).await;
+        handle.abort();
+        notifier.get_notifications()
+    }
+
     fn make_category_event(user_login: &str) -> StreamsUpdated {
         use crate::state::CategoryChange;
         let stream = make_stream(user_login);
@@ -137,6 +272,8 @@ mod tests {
                 stream,
                 old_category: "Old Game".to_string(),
             }],
+            title_changes: vec![],
+            went_offline: vec![],
         }
     }
 
@@ -221,4 +358,163 @@ mod tests {
 
         handle.abort();
     }
+
+    #[tokio::test]
+    async fn burst_of_live_streams_is_summarised_with_favourites_first() {
+        let mut streamer_settings = HashMap::new();
+        streamer_settings.insert(
+            "e".to_string(),
+            StreamerSettings {
+                display_name: "e".to_string(),
+                importance: StreamerImportance::Favourite,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
+            },
+        );
+        let config = Config {
+            streamer_settings,
+            ..Config::default()
+        };
+
+        let notifications =
+            dispatch(config, vec![make_multi_event(&["a", "b", "c", "d", "e"])]).await;
+
+        assert_eq!(notifications.len(), 1);
+        assert_eq!(
+            notifications[0].notification_type,
+            NotificationType::StreamLiveSummary
+        );
+        assert_eq!(notifications[0].title, "5 channels went live");
+        assert_eq!(notifications[0].message, "e, a, b and 2 more");
+    }
+
+    #[tokio::test]
+    async fn live_notifications_are_counted_across_events_within_window() {
+        let config = Config {
+            live_summary_threshold: 3,
+            live_summary_window_sec: 60,
+            ..Config::default()
+        };
+
+        let notifications = dispatch(
+            config,
+            vec![make_multi_event(&["a", "b"]), make_multi_event(&["c", "d"])],
+        )
+        .await;
+
+        let types: Vec<_> = notifications
+            .iter()
+            .map(|n| n.notification_type.clone())
+            .collect();
+        assert_eq!(
+            types,
+            vec![
+                NotificationType::StreamLive,
+                NotificationType::StreamLive,
+                NotificationType::StreamLiveSummary,
+            ]
+        );
+    }
+
+    #[tokio::test]
+    async fn live_notifications_outside_window_stay_individual() {
+        let config = Config {
+            live_summary_threshold: 3,
+            live_summary_window_sec: 0,
+            ..Config::default()
+        };
+
+        let notifications = dispatch(
+            config,
+            vec![make_multi_event(&["a", "b"]), make_multi_event(&["c", "d"])],
+        )
+        .await;
+
+        assert_eq!(notifications.len(), 4);
+        assert!(notifications
+            .iter()
+            .all(|n| n.notification_type == NotificationType::StreamLive));
+    }
+
+    #[tokio::test]
+    async fn mutes_apply_on_top_of_notify_on_live() {
+        // (notify_on_live, channel muted, expected notifications)
+        for (notify_on_live, muted, expected) in
+            [(true, false, 1), (true, true, 0), (false, false, 0)]
+        {
+            let recorder = Arc::new(RecordingNotifier::new());
+            let notifier = Arc::new(MutingNotifier::new(
+                recorder.clone(),
+                Box::new(move |_| muted),
+            ));
+            let config = Arc::new(ConfigManager::with_config(Config {
+                notify_on_live,
+                ..Config::default()
+            }));
+            let dispatcher =
+                NotificationDispatcher::new(notifier, config, Arc::new(AtomicBool::new(true)));
+
+            let (tx, rx) = broadcast::channel(16);
+            let handle = tokio::spawn(async move { dispatcher.listen(rx).await });
+            tx.send(make_event("streamer")).unwrap();
+            tokio::time::sleep(tokio::time::Duration::from_millis(50)).await;
+            handle.abort();
+
+            assert_eq!(
+                recorder.notification_count(),
+                expected,
+                "notify_on_live={notify_on_live} muted={muted}"
+            );
+        }
+    }
+
+    fn title_change(user_login: &str, old_title: &str, new_title: &str) -> TitleChange {
+        let mut stream = make_stream(user_login);
+        stream.title = new_title.to_string();
+        TitleChange {
+            stream,
+            old_title: old_title.to_string(),
+        }
+    }
+
+    #[test]
+    fn title_edits_within_window_are_combined() {
+        let mut titles = TitleDebouncer::default();
+        let start = Utc::now();
+        let live = vec![make_stream("streamer")];
+
+        titles.push(title_change("streamer", "Chatting", "Drops soon"), start);
+        assert!(titles.take_settled(&live, start).is_empty());
+
+        let later = start + chrono::Duration::seconds(30);
+        titles.push(
+            title_change("streamer", "Drops soon", "!drops now live"),
+            later,
+        );
+        assert!(titles.take_settled(&live, later).is_empty());
+
+        let settled = titles.take_settled(&live, start + chrono::Duration::seconds(61));
+        assert_eq!(settled.len(), 1);
+        assert_eq!(settled[0].old_title, "Chatting");
+        assert_eq!(settled[0].stream.title, "!drops now live");
+        assert!(titles.pending.is_empty());
+    }
+
+    #[test]
+    fn title_reverted_or_stream_offline_is_dropped() {
+        let mut titles = TitleDebouncer::default();
+        let start = Utc::now();
+        let settled_at = start + chrono::Duration::seconds(61);
+
+        titles.push(title_change("streamer", "Chatting", "Typo"), start);
+        titles.push(title_change("streamer", "Typo", "Chatting"), start);
+        assert!(titles
+            .take_settled(&[make_stream("streamer")], settled_at)
+            .is_empty());
+
+        titles.push(title_change("streamer", "Chatting", "Bye"), start);
+        assert!(titles.take_settled(&[], settled_at).is_empty());
+        assert!(titles.pending.is_empty());
+    }
 }
@@ -2,14 +2,16 @@ use std::collections::HashMap;
 
 use chrono::{DateTime, Utc};
 
-use crate::config::{StreamerImportance, StreamerSettings};
-use crate::state::{CategoryChange, StreamsUpdated};
+use crate::config::{streamer_importance, streamer_settings, StreamerSettings};
+use crate::state::{CategoryChange, StreamOffline, StreamsUpdated, TitleChange};
 use crate::twitch::Stream;
 
 /// Streams and category changes that should be dispatched to the notifier.
 pub struct NotificationDecision {
     pub streams_to_notify: Vec<Stream>,
     pub categories_to_notify: Vec<CategoryChange>,
+    pub titles_to_notify: Vec<TitleChange>,
+    pub offline_to_notify: Vec<StreamOffline>,
 }
 
 /// Determines which notifications (if any) to send for a stream update event.
@@ -19,7 +21,10 @@ pub struct NotificationDecision {
 /// - The gap since the previous event exceeds `max_gap_secs` (avoids floods
 ///   after wake from sleep/suspension)
 ///
-/// Silent and Ignore streamers are always excluded regardless.
+/// Silent and Ignore streamers are always excluded regardless, and reruns
+/// never trigger a live notification (they still appear in the menu).
+/// Streams going offline are only notified for streamers who opted in with
+/// `notify_on_offline`.
 pub fn filter_notifications(
     event: &StreamsUpdated,
     last_event_time: Option<DateTime<Utc>>,
@@ -31,6 +36,8 @@ pub fn filter_notifications(
     let empty = NotificationDecision {
         streams_to_notify: Vec::new(),
         categories_to_notify: Vec::new(),
+        titles_to_notify: Vec::new(),
+        offline_to_notify: Vec::new(),
     };
 
     // Suppress everything during the initial baseline load.
@@ -53,18 +60,13 @@ pub fn filter_notifications(
     }
 
     // Filter by streamer importance — Silent and Ignore streamers are never notified.
-    let is_silent_or_ignored = |user_login: &str| -> bool {
-        let importance = settings
-            .get(user_login)
-            .map(|s| s.importance)
-            .unwrap_or_default();
-        importance == StreamerImportance::Silent || importance == StreamerImportance::Ignore
-    };
+    let is_silent_or_ignored =
+        |user_login: &str| streamer_importance(settings, user_login).is_muted();
 
     let streams_to_notify = event
         .newly_live
         .iter()
-        .filter(|s| !is_silent_or_ignored(&s.user_login))
+        .filter(|s| s.is_live_broadcast() && !is_silent_or_ignored(&s.user_login))
         .cloned()
         .collect();
 
@@ -75,16 +77,35 @@ pub fn filter_notifications(
         .cloned()
         .collect();
 
+    let titles_to_notify = event
+        .title_changes
+        .iter()
+        .filter(|c| !is_silent_or_ignored(&c.stream.user_login))
+        .cloned()
+        .collect();
+
+    let offline_to_notify = event
+        .went_offline
+        .iter()
+        .filter(|o| {
+            streamer_settings(settings, &o.stream.user_login)
+                .is_some_and(|s| s.notify_on_offline && !s.importance.is_muted())
+        })
+        .cloned()
+        .collect();
+
     NotificationDecision {
         streams_to_notify,
         categories_to_notify,
+        titles_to_notify,
+        offline_to_notify,
     }
 }
 
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::config::StreamerSettings;
+    use crate::config::{StreamerImportance, StreamerSettings};
     use chrono::Duration;
 
     fn make_stream(user_login: &str) -> Stream {
@@ -100,6 +121,8 @@ mod tests {
             started_at: Utc::now() - Duration::hours(1),
             thumbnail_url: String::new(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
@@ -112,6 +135,8 @@ mod tests {
             streams: newly_live.clone(),
             newly_live,
             category_changes,
+            title_changes: vec![],
+            went_offline: vec![],
         }
     }
 
@@ -126,6 +151,8 @@ mod tests {
                 display_name: user_login.to_string(),
                 importance,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
             },
         );
         map
@@ -150,6 +177,17 @@ mod tests {
         assert_eq!(decision.streams_to_notify.len(), 1);
     }
 
+    #[test]
+    fn reruns_do_not_notify() {
+        let mut rerun = make_stream("rerunner");
+        rerun.stream_type = "rerun".to_string();
+        let event = make_event(vec![rerun, make_stream("streamer")], vec![]);
+        let decision = filter_notifications(&event, None, Utc::now(), 600, true, &HashMap::new());
+
+        assert_eq!(decision.streams_to_notify.len(), 1);
+        assert_eq!(decision.streams_to_notify[0].user_login, "streamer");
+    }
+
     // === Sleep gap suppression ===
 
     #[test]
@@ -267,6 +305,33 @@ mod tests {
         assert!(decision.categories_to_notify.is_empty());
     }
 
+    #[test]
+    fn silent_streamer_excluded_from_title_changes() {
+        let mut event = make_event(vec![], vec![]);
+        for login in ["quietstreamer", "loudstreamer"] {
+            event.title_changes.push(TitleChange {
+                stream: make_stream(login),
+                old_title: "Old title".to_string(),
+            });
+        }
+        let settings = settings_with("quietstreamer", StreamerImportance::Silent);
+        let decision = filter_notifications(&event, None, Utc::now(), 600, true, &settings);
+        assert_eq!(decision.titles_to_notify.len(), 1);
+        assert_eq!(
+            decision.titles_to_notify[0].stream.user_login,
+            "loudstreamer"
+        );
+    }
+
+    #[test]
+    fn importance_lookup_ignores_case() {
+        let event = make_event(vec![make_stream("quietstreamer")], vec![]);
+        let now = Utc::now();
+        let settings = settings_with("QuietStreamer", StreamerImportance::Silent);
+        let decision = filter_notifications(&event, None, now, 600, true, &settings);
+        assert!(decision.streams_to_notify.is_empty());
+    }
+
     #[test]
     fn mixed_importance_only_normal_notified() {
         let silent = make_stream("silentone");
@@ -280,10 +345,57 @@ mod tests {
                 display_name: "silentone".to_string(),
                 importance: StreamerImportance::Silent,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
             },
         );
         let decision = filter_notifications(&event, None, now, 600, true, &settings);
         assert_eq!(decision.streams_to_notify.len(), 1);
         assert_eq!(decision.streams_to_notify[0].user_login, "normalone");
     }
+
+    // === Offline notifications ===
+
+    fn offline_event(user_login: &str) -> StreamsUpdated {
+        StreamsUpdated {
+            streams: vec![],
+            newly_live: vec![],
+            category_changes: vec![],
+            title_changes: vec![],
+            went_offline: vec![StreamOffline {
+                stream: make_stream(user_login),
+                last_seen_at: Utc::now(),
+            }],
+        }
+    }
+
+    fn offline_opt_in(
+        user_login: &str,
+        importance: StreamerImportance,
+    ) -> HashMap<String, StreamerSettings> {
+        let mut settings = settings_with(user_login, importance);
+        settings.get_mut(user_login).unwrap().notify_on_offline = true;
+        settings
+    }
+
+    #[test]
+    fn offline_notified_only_for_opted_in_streamers() {
+        let now = Utc::now();
+        let event = offline_event("streamer");
+
+        let decision = filter_notifications(&event, None, now, 600, true, &HashMap::new());
+        assert!(decision.offline_to_notify.is_empty());
+
+        let settings = offline_opt_in("streamer", StreamerImportance::Normal);
+        let decision = filter_notifications(&event, None, now, 600, true, &settings);
+        assert_eq!(decision.offline_to_notify.len(), 1);
+    }
+
+    #[test]
+    fn offline_not_notified_for_silent_streamers() {
+        let event = offline_event("streamer");
+        let settings = offline_opt_in("streamer", StreamerImportance::Silent);
+        let decision = filter_notifications(&event, None, Utc::now(), 600, true, &settings);
+        assert!(decision.offline_to_notify.is_empty());
+    }
 }
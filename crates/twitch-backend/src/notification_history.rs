@@ -0,0 +1,380 @@
+//! Recently sent notifications, so missed ones can be found again from the tray.
+//!
+//! [`HistoryNotifier`] records every notification that reaches the desktop into
+//! a bounded [`NotificationHistory`], which is written to the platform state
+//! directory so it survives restarts.
+
+use std::collections::VecDeque;
+use std::path::{Path, PathBuf};
+use std::sync::{Arc, Mutex};
+
+use anyhow::Context;
+use chrono::{DateTime, Duration, Utc};
+use serde::{Deserialize, Serialize};
+use tokio::sync::watch;
+
+use crate::hotness_detection::HotnessInfo;
+use crate::notify::error_gate::recovery_message;
+use crate::notify::rate_limit::overflow_message;
+use crate::notify::{
+    category_alert_title, live_summary_message, offline_title, schedule_change_title,
+    ErrorCategory, Notifier, ScheduleChange,
+};
+use crate::twitch::{ScheduledStream, Stream};
+
+/// Most entries kept in memory and on disk
+pub const HISTORY_CAPACITY: usize = 50;
+
+const HISTORY_FILE: &str = "notification-history.json";
+
+/// What a recorded notification was about
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+#[serde(rename_all = "snake_case")]
+pub enum HistoryKind {
+    Live,
+    LiveSummary,
+    Reminder,
+    CategoryChange,
+    TitleChange,
+    Offline,
+    Hot,
+    CategoryAlert,
+    ScheduleChange,
+    Overflow,
+    Error,
+    Recovered,
+}
+
+/// A notification as shown to the user
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct HistoryEntry {
+    pub kind: HistoryKind,
+    /// Login of the channel the notification was about, if it was about one
+    pub user_login: Option<String>,
+    pub message: String,
+    pub at: DateTime<Utc>,
+}
+
+impl HistoryEntry {
+    fn for_stream(kind: HistoryKind, stream: &Stream, message: String) -> Self {
+        Self {
+            kind,
+            user_login: Some(stream.user_login.clone()),
+            message,
+            at: Utc::now(),
+        }
+    }
+}
+
+/// Bounded, newest-last list of sent notifications
+pub struct NotificationHistory {
+    entries: Mutex<VecDeque<HistoryEntry>>,
+    /// Where the history is saved, or `None` to keep it in memory only
+    path: Option<PathBuf>,
+    changed_tx: watch::Sender<()>,
+}
+
+impl NotificationHistory {
+    /// Loads the history saved in `dir`, starting empty if there is none or it
+    /// can't be read
+    pub fn load(dir: &Path) -> Self {
+        let path = dir.join(HISTORY_FILE);
+        let entries = match std::fs::read_to_string(&path) {
+            Ok(data) => serde_json::from_str(&data).unwrap_or_else(|e| {
+                tracing::warn!("Ignoring unreadable notification history: {}", e);
+                VecDeque::new()
+            }),
+            Err(_) => VecDeque::new(),
+        };
+        Self::with_entries(entries, Some(path))
+    }
+
+    /// Creates an empty history that is never written to disk
+    pub fn in_memory() -> Self {
+        Self::with_entries(VecDeque::new(), None)
+    }
+
+    fn with_entries(mut entries: VecDeque<HistoryEntry>, path: Option<PathBuf>) -> Self {
+        while entries.len() > HISTORY_CAPACITY {
+            entries.pop_front();
+        }
+        Self {
+            entries: Mutex::new(entries),
+            path,
+            changed_tx: watch::channel(()).0,
+        }
+    }
+
+    /// Returns a receiver that is notified whenever an entry is added
+    pub fn subscribe(&self) -> watch::Receiver<()> {
+        self.changed_tx.subscribe()
+    }
+
+    /// Appends an entry, dropping the oldest once over capacity, and saves
+    pub fn push(&self, entry: HistoryEntry) {
+        let snapshot = {
+            let mut entries = self
+                .entries
+                .lock()
+                .unwrap_or_else(std::sync::PoisonError::into_inner);
+            entries.push_back(entry);
+            if entries.len() > HISTORY_CAPACITY {
+                entries.pop_front();
+            }
+            self.path.is_some().then(|| entries.clone())
+        };
+
+        if let (Some(path), Some(entries)) = (&self.path, snapshot) {
+            if let Err(e) = save(path, &entries) {
+                tracing::warn!("Failed to save notification history: {}", e);
+            }
+        }
+        self.changed_tx.send_replace(());
+    }
+
+    /// Returns up to `limit` entries, newest first
+    pub fn recent(&self, limit: usize) -> Vec<HistoryEntry> {
+        self.entries
+            .lock()
+            .unwrap_or_else(std::sync::PoisonError::into_inner)
+            .iter()
+            .rev()
+            .take(limit)
+            .cloned()
+            .collect()
+    }
+}
+
+fn save(path: &Path, entries: &VecDeque<HistoryEntry>) -> anyhow::Result<()> {
+    if let Some(dir) = path.parent() {
+        std::fs::create_dir_all(dir).context("Failed to create state directory")?;
+    }
+    let json = serde_json::to_string(entries)?;
+    std::fs::write(path, json).context("Failed to write notification history")
+}
+
+/// Records each notification that `inner` sends successfully, other than
+/// test notifications
+pub struct HistoryNotifier {
+    inner: Arc<dyn Notifier>,
+    history: Arc<NotificationHistory>,
+}
+
+impl HistoryNotifier {
+    pub fn new(inner: Arc<dyn Notifier>, history: Arc<NotificationHistory>) -> Self {
+        Self { inner, history }
+    }
+
+    fn record(
+        &self,
+        sent: anyhow::Result<()>,
+        entry: impl FnOnce() -> HistoryEntry,
+    ) -> anyhow::Result<()> {
+        if sent.is_ok() {
+            self.history.push(entry());
+        }
+        sent
+    }
+}
+
+impl Notifier for HistoryNotifier {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.record(self.inner.stream_live(stream), || {
+            HistoryEntry::for_stream(
+                HistoryKind::Live,
+                stream,
+                format!("{} went live", stream.user_name),
+            )
+        })
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        self.record(self.inner.streams_live_summary(streams), || HistoryEntry {
+            kind: HistoryKind::LiveSummary,
+            user_login: None,
+            message: live_summary_message(streams),
+            at: Utc::now(),
+        })
+    }
+
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.record(self.inner.stream_reminder(stream), || {
+            HistoryEntry::for_stream(
+                HistoryKind::Reminder,
+                stream,
+                format!("{} live for {}", stream.user_name, stream.format_duration()),
+            )
+        })
+    }
+
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        self.record(self.inner.category_changed(stream, old_category), || {
+            HistoryEntry::for_stream(
+                HistoryKind::CategoryChange,
+                stream,
+                format!("{} switched to {}", stream.user_name, stream.game_name),
+            )
+        })
+    }
+
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        self.record(self.inner.title_changed(stream, old_title), || {
+            HistoryEntry::for_stream(
+                HistoryKind::TitleChange,
+                stream,
+                format!("{} changed title", stream.user_name),
+            )
+        })
+    }
+
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        self.record(self.inner.stream_offline(stream, live_for), || {
+            HistoryEntry::for_stream(
+                HistoryKind::Offline,
+                stream,
+                offline_title(stream, live_for),
+            )
+        })
+    }
+
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        self.record(self.inner.stream_hot(stream, info), || {
+            HistoryEntry::for_stream(
+                HistoryKind::Hot,
+                stream,
+                format!("{} is hot on {}", stream.user_name, stream.game_name),
+            )
+        })
+    }
+
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        self.record(self.inner.category_stream_alert(stream, threshold), || {
+            HistoryEntry::for_stream(
+                HistoryKind::CategoryAlert,
+                stream,
+                category_alert_title(stream, threshold),
+            )
+        })
+    }
+
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        self.record(self.inner.schedule_changed(stream, change), || {
+            HistoryEntry {
+                kind: HistoryKind::ScheduleChange,
+                user_login: Some(stream.broadcaster_login.clone()),
+                message: schedule_change_title(stream, change),
+                at: Utc::now(),
+            }
+        })
+    }
+
+    fn overflow_summary(&self, suppressed: usize) -> anyhow::Result<()> {
+        self.record(self.inner.overflow_summary(suppressed), || HistoryEntry {
+            kind: HistoryKind::Overflow,
+            user_login: None,
+            message: overflow_message(suppressed),
+            at: Utc::now(),
+        })
+    }
+
+    fn error(&self, category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        self.record(self.inner.error(category, message), || HistoryEntry {
+            kind: HistoryKind::Error,
+            user_login: None,
+            message: message.to_string(),
+            at: Utc::now(),
+        })
+    }
+
+    fn error_recovered(&self, category: ErrorCategory, suppressed: usize) -> anyhow::Result<()> {
+        self.record(self.inner.error_recovered(category, suppressed), || {
+            HistoryEntry {
+                kind: HistoryKind::Recovered,
+                user_login: None,
+                message: recovery_message(category, suppressed),
+                at: Utc::now(),
+            }
+        })
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::notify::mock::RecordingNotifier;
+    use crate::test_helpers::make_stream;
+
+    fn recording_history() -> (Arc<NotificationHistory>, HistoryNotifier) {
+        let history = Arc::new(NotificationHistory::in_memory());
+        let notifier = HistoryNotifier::new(Arc::new(RecordingNotifier::new()), history.clone());
+        (history, notifier)
+    }
+
+    #[test]
+    fn records_sent_notifications_newest_first() {
+        let (history, notifier) = recording_history();
+
+        notifier.stream_live(&make_stream("1", "Alice")).unwrap();
+        notifier
+            .error(ErrorCategory::Api, "Twitch is unreachable")
+            .unwrap();
+
+        let recent = history.recent(15);
+        assert_eq!(recent.len(), 2);
+        assert_eq!(recent[0].kind, HistoryKind::Error);
+        assert_eq!(recent[0].user_login, None);
+        assert_eq!(recent[1].kind, HistoryKind::Live);
+        assert_eq!(recent[1].user_login.as_deref(), Some("alice"));
+        assert_eq!(recent[1].message, "Alice went live");
+    }
+
+    #[test]
+    fn drops_oldest_entries_past_capacity() {
+        let (history, notifier) = recording_history();
+
+        for i in 0..=HISTORY_CAPACITY {
+            notifier
+                .stream_live(&make_stream(&i.to_string(), &format!("User{i}")))
+                .unwrap();
+        }
+
+        let recent = history.recent(usize::MAX);
+        assert_eq!(recent.len(), HISTORY_CAPACITY);
+        assert_eq!(
+            recent[0].user_login,
+            Some(format!("user{HISTORY_CAPACITY}"))
+        );
+        assert_eq!(recent.last().unwrap().user_login.as_deref(), Some("user1"));
+    }
+
+    #[test]
+    fn history_survives_reload() {
+        let dir = tempfile::tempdir().unwrap();
+        let history = Arc::new(NotificationHistory::load(dir.path()));
+        let notifier = HistoryNotifier::new(Arc::new(RecordingNotifier::new()), history);
+        notifier.stream_live(&make_stream("1", "Alice")).unwrap();
+
+        let reloaded = NotificationHistory::load(dir.path());
+        let recent = reloaded.recent(15);
+        assert_eq!(recent.len(), 1);
+        assert_eq!(recent[0].message, "Alice went live");
+    }
+
+    #[test]
+    fn push_notifies_subscribers() {
+        let (history, notifier) = recording_history();
+        let mut rx = history.subscribe();
+
+        notifier.stream_live(&make_stream("1", "Alice")).unwrap();
+
+        assert!(rx.has_changed().unwrap());
+    }
+}
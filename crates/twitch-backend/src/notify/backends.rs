@@ -0,0 +1,530 @@
+//! Ways of putting a notification on screen
+//!
+//! Not every session has a notification daemon, and not every Windows install
+//! lets an unregistered app show toasts, so several backends are probed at
+//! startup and the first that works is used. [`LogBackend`] always works, so a
+//! notification is at least logged when nothing else can show it.
+
+use std::path::PathBuf;
+
+use anyhow::Context;
+#[cfg(any(target_os = "linux", target_os = "macos", target_os = "windows"))]
+use std::process::Command;
+use std::sync::atomic::{AtomicUsize, Ordering};
+use std::sync::Arc;
+
+#[cfg(target_os = "linux")]
+use super::APP_NAME;
+
+#[cfg(target_os = "linux")]
+const NOTIFICATION_TIMEOUT_MS: i32 = 10_000;
+
+/// Runs when the user picks a notification action
+pub type ActionHandler = Arc<dyn Fn() + Send + Sync>;
+
+/// A button on a notification. The action with id `"default"` runs when the
+/// notification body is clicked.
+pub struct NotificationAction {
+    pub id: &'static str,
+    pub label: &'static str,
+    pub run: ActionHandler,
+}
+
+/// A notification ready to be shown by a backend
+pub struct DesktopNotification {
+    pub title: String,
+    pub message: String,
+    pub icon: Option<PathBuf>,
+    /// freedesktop.org category, so users can configure behaviour per type
+    pub category: Option<&'static str>,
+    /// Ignored by backends that can't report clicks
+    pub actions: Vec<NotificationAction>,
+}
+
+/// Something that can show a desktop notification
+pub trait NotificationBackend: Send + Sync {
+    /// Name used to force this backend in config and shown in the status display
+    fn name(&self) -> &'static str;
+
+    /// Checks whether this backend can work in the current session
+    fn probe(&self) -> bool;
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()>;
+}
+
+/// The backends for this platform, most capable first
+pub fn platform_backends() -> Vec<Box<dyn NotificationBackend>> {
+    vec![
+        #[cfg(target_os = "linux")]
+        Box::new(DbusBackend),
+        #[cfg(target_os = "linux")]
+        Box::new(NotifySendBackend),
+        #[cfg(target_os = "macos")]
+        Box::new(OsascriptBackend),
+        #[cfg(target_os = "windows")]
+        Box::new(PowerShellToastBackend),
+        Box::new(LogBackend),
+    ]
+}
+
+/// Sends through the first working backend, falling back to later ones when
+/// it fails
+pub struct FallbackBackends {
+    backends: Vec<Box<dyn NotificationBackend>>,
+    /// Index of the backend that last worked
+    active: AtomicUsize,
+}
+
+impl FallbackBackends {
+    /// Probes `backends` in order and starts with the first that works.
+    ///
+    /// When `forced` names one of the backends, that one is used on its own
+    /// without probing, so its failures are reported rather than hidden.
+    pub fn probe(mut backends: Vec<Box<dyn NotificationBackend>>, forced: Option<&str>) -> Self {
+        if let Some(name) = forced {
+            if let Some(i) = backends.iter().position(|b| b.name() == name) {
+                tracing::info!("Using notification backend {} (set in config)", name);
+                return Self {
+                    backends: vec![backends.swap_remove(i)],
+                    active: AtomicUsize::new(0),
+                };
+            }
+            tracing::warn!(
+                "Unknown notification backend {:?}, choosing one automatically",
+                name
+            );
+        }
+
+        let active = backends
+            .iter()
+            .position(|b| b.probe())
+            .unwrap_or(backends.len().saturating_sub(1));
+        let chosen = Self {
+            backends,
+            active: AtomicUsize::new(active),
+        };
+        tracing::info!("Using notification backend {}", chosen.active_name());
+        chosen
+    }
+
+    /// Name of the backend notifications are currently sent with
+    pub fn active_name(&self) -> &'static str {
+        self.backends
+            .get(self.active.load(Ordering::Relaxed))
+            .map_or("none", |b| b.name())
+    }
+
+    /// Sends with the active backend, trying each later one in turn if it
+    /// fails. The first that succeeds becomes the active backend.
+    pub fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        let start = self.active.load(Ordering::Relaxed);
+        let mut last_error = None;
+
+        for (i, backend) in self.backends.iter().enumerate().skip(start) {
+            match backend.send(notification) {
+                Ok(()) => {
+                    if i != start {
+                        tracing::warn!(
+                            "Switched notification backend from {} to {}",
+                            self.backends[start].name(),
+                            backend.name()
+                        );
+                        self.active.store(i, Ordering::Relaxed);
+                    }
+                    return Ok(());
+                }
+                Err(e) => {
+                    tracing::warn!("Notification backend {} failed: {}", backend.name(), e);
+                    last_error = Some(e);
+                }
+            }
+        }
+
+        Err(last_error.unwrap_or_else(|| anyhow::anyhow!("No notification backend available")))
+    }
+
+    /// Sends with the active backend only, so a failure is reported rather
+    /// than hidden by falling back. Used for test notifications.
+    pub fn send_active(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        let backend = self
+            .backends
+            .get(self.active.load(Ordering::Relaxed))
+            .context("No notification backend available")?;
+        backend
+            .send(notification)
+            .with_context(|| format!("The {} notification backend failed", backend.name()))
+    }
+}
+
+/// Runs `command`, turning a non-zero exit into an error carrying its stderr
+#[cfg(any(target_os = "linux", target_os = "macos", target_os = "windows"))]
+fn run(mut command: Command) -> anyhow::Result<()> {
+    let output = command.output()?;
+    if !output.status.success() {
+        anyhow::bail!(
+            "{:?} exited with {}: {}",
+            command.get_program(),
+            output.status,
+            String::from_utf8_lossy(&output.stderr).trim()
+        );
+    }
+    Ok(())
+}
+
+/// Talks to the notification daemon over D-Bus. The only backend that
+/// supports actions.
+#[cfg(target_os = "linux")]
+pub struct DbusBackend;
+
+#[cfg(target_os = "linux")]
+impl NotificationBackend for DbusBackend {
+    fn name(&self) -> &'static str {
+        "dbus"
+    }
+
+    fn probe(&self) -> bool {
+        notify_rust::get_server_information().is_ok()
+    }
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        use notify_rust::{Hint, Notification};
+
+        let mut n = Notification::new();
+        n.summary(&notification.title)
+            .body(&notification.message)
+            .appname(APP_NAME)
+            .timeout(NOTIFICATION_TIMEOUT_MS);
+
+        if let Some(icon) = &notification.icon {
+            n.icon(&icon.to_string_lossy());
+        }
+        if let Some(category) = notification.category {
+            n.hint(Hint::Category(category.to_string()));
+        }
+
+        if notification.actions.is_empty() {
+            n.show()?;
+            return Ok(());
+        }
+
+        for action in &notification.actions {
+            n.action(action.id, action.label);
+        }
+        let handlers: Vec<(&'static str, ActionHandler)> = notification
+            .actions
+            .iter()
+            .map(|a| (a.id, a.run.clone()))
+            .collect();
+        let handle = n.show()?;
+        std::thread::spawn(move || {
+            handle.wait_for_action(|picked| {
+                if let Some((_, run)) = handlers.iter().find(|(id, _)| *id == picked) {
+                    run();
+                }
+            });
+        });
+
+        Ok(())
+    }
+}
+
+/// Runs `notify-send`, for sessions where the D-Bus connection can't be made
+/// directly (e.g. sandboxes that only allow spawning helpers)
+#[cfg(target_os = "linux")]
+pub struct NotifySendBackend;
+
+#[cfg(target_os = "linux")]
+impl NotificationBackend for NotifySendBackend {
+    fn name(&self) -> &'static str {
+        "notify-send"
+    }
+
+    fn probe(&self) -> bool {
+        Command::new("notify-send")
+            .arg("--version")
+            .output()
+            .is_ok_and(|o| o.status.success())
+    }
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        let mut command = Command::new("notify-send");
+        command
+            .arg("--app-name")
+            .arg(APP_NAME)
+            .arg("--expire-time")
+            .arg(NOTIFICATION_TIMEOUT_MS.to_string());
+        if let Some(icon) = &notification.icon {
+            command.arg("--icon").arg(icon);
+        }
+        if let Some(category) = notification.category {
+            command.arg("--category").arg(category);
+        }
+        command
+            .arg("--")
+            .arg(&notification.title)
+            .arg(&notification.message);
+        run(command)
+    }
+}
+
+/// Shows a Notification Center banner via AppleScript
+#[cfg(target_os = "macos")]
+pub struct OsascriptBackend;
+
+#[cfg(target_os = "macos")]
+impl NotificationBackend for OsascriptBackend {
+    fn name(&self) -> &'static str {
+        "osascript"
+    }
+
+    fn probe(&self) -> bool {
+        Command::new("osascript")
+            .args(["-e", "return"])
+            .output()
+            .is_ok_and(|o| o.status.success())
+    }
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        // Title and message are passed as arguments so they need no escaping
+        let mut command = Command::new("osascript");
+        command
+            .args([
+                "-e",
+                "on run argv",
+                "-e",
+                "display notification (item 2 of argv) with title (item 1 of argv)",
+                "-e",
+                "end run",
+            ])
+            .arg(&notification.title)
+            .arg(&notification.message);
+        run(command)
+    }
+}
+
+/// Shows a toast through PowerShell's registered app id, which works without
+/// the app itself being registered with the Start menu
+#[cfg(target_os = "windows")]
+pub struct PowerShellToastBackend;
+
+#[cfg(target_os = "windows")]
+const TOAST_SCRIPT: &str = r"
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text[0].AppendChild($xml.CreateTextNode($env:TWITCH_TRAY_TITLE)) > $null
+$text[1].AppendChild($xml.CreateTextNode($env:TWITCH_TRAY_MESSAGE)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)
+";
+
+#[cfg(target_os = "windows")]
+impl PowerShellToastBackend {
+    fn powershell() -> Command {
+        use std::os::windows::process::CommandExt;
+        const CREATE_NO_WINDOW: u32 = 0x0800_0000;
+
+        let mut command = Command::new("powershell");
+        command
+            .args(["-NoProfile", "-NonInteractive", "-Command"])
+            .creation_flags(CREATE_NO_WINDOW);
+        command
+    }
+}
+
+#[cfg(target_os = "windows")]
+impl NotificationBackend for PowerShellToastBackend {
+    fn name(&self) -> &'static str {
+        "powershell"
+    }
+
+    fn probe(&self) -> bool {
+        Self::powershell()
+            .arg("exit 0")
+            .output()
+            .is_ok_and(|o| o.status.success())
+    }
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        // Text goes through the environment so it needs no escaping
+        let mut command = Self::powershell();
+        command
+            .arg(TOAST_SCRIPT)
+            .env("TWITCH_TRAY_TITLE", &notification.title)
+            .env("TWITCH_TRAY_MESSAGE", &notification.message);
+        run(command)
+    }
+}
+
+/// Writes notifications to the log. Always available, as the last resort.
+pub struct LogBackend;
+
+impl NotificationBackend for LogBackend {
+    fn name(&self) -> &'static str {
+        "log"
+    }
+
+    fn probe(&self) -> bool {
+        true
+    }
+
+    fn send(&self, notification: &DesktopNotification) -> anyhow::Result<()> {
+        tracing::info!(
+            "Notification: {} - {}",
+            notification.title,
+            notification.message
+        );
+        Ok(())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::sync::atomic::AtomicBool;
+
+    /// Backend with a scripted probe result that fails sends while `broken`
+    struct FakeBackend {
+        name: &'static str,
+        available: bool,
+        broken: Arc<AtomicBool>,
+        sent: Arc<AtomicUsize>,
+    }
+
+    impl FakeBackend {
+        fn new(name: &'static str, available: bool) -> Self {
+            Self {
+                name,
+                available,
+                broken: Arc::new(AtomicBool::new(false)),
+                sent: Arc::new(AtomicUsize::new(0)),
+            }
+        }
+    }
+
+    impl NotificationBackend for FakeBackend {
+        fn name(&self) -> &'static str {
+            self.name
+        }
+
+        fn probe(&self) -> bool {
+            self.available
+        }
+
+        fn send(&self, _notification: &DesktopNotification) -> anyhow::Result<()> {
+            if self.broken.load(Ordering::Relaxed) {
+                anyhow::bail!("{} is broken", self.name);
+            }
+            self.sent.fetch_add(1, Ordering::Relaxed);
+            Ok(())
+        }
+    }
+
+    fn notification() -> DesktopNotification {
+        DesktopNotification {
+            title: "Alice is now live!".to_string(),
+            message: "Just Chatting".to_string(),
+            icon: None,
+            category: None,
+            actions: vec![],
+        }
+    }
+
+    #[test]
+    fn probe_picks_first_available_backend() {
+        let chosen = FallbackBackends::probe(
+            vec![
+                Box::new(FakeBackend::new("dbus", false)),
+                Box::new(FakeBackend::new("notify-send", true)),
+                Box::new(LogBackend),
+            ],
+            None,
+        );
+
+        assert_eq!(chosen.active_name(), "notify-send");
+    }
+
+    #[test]
+    fn probe_falls_back_to_last_backend() {
+        let chosen = FallbackBackends::probe(
+            vec![
+                Box::new(FakeBackend::new("dbus", false)),
+                Box::new(FakeBackend::new("notify-send", false)),
+            ],
+            None,
+        );
+
+        assert_eq!(chosen.active_name(), "notify-send");
+    }
+
+    #[test]
+    fn forced_backend_is_used_without_probing() {
+        let chosen = FallbackBackends::probe(
+            vec![
+                Box::new(FakeBackend::new("dbus", true)),
+                Box::new(FakeBackend::new("notify-send", false)),
+                Box::new(LogBackend),
+            ],
+            Some("notify-send"),
+        );
+
+        assert_eq!(chosen.active_name(), "notify-send");
+    }
+
+    #[test]
+    fn unknown_forced_backend_chooses_automatically() {
+        let chosen = FallbackBackends::probe(
+            vec![
+                Box::new(FakeBackend::new("dbus", true)),
+                Box::new(LogBackend),
+            ],
+            Some("carrier-pigeon"),
+        );
+
+        assert_eq!(chosen.active_name(), "dbus");
+    }
+
+    #[test]
+    fn failed_send_falls_back_and_remembers_backend() {
+        let dbus = FakeBackend::new("dbus", true);
+        let dbus_broken = dbus.broken.clone();
+        let dbus_sent = dbus.sent.clone();
+        let notify_send = FakeBackend::new("notify-send", true);
+        let notify_send_sent = notify_send.sent.clone();
+        let chosen = FallbackBackends::probe(vec![Box::new(dbus), Box::new(notify_send)], None);
+
+        dbus_broken.store(true, Ordering::Relaxed);
+        chosen.send(&notification()).unwrap();
+        assert_eq!(chosen.active_name(), "notify-send");
+
+        // Stays on the working backend even once the first recovers
+        dbus_broken.store(false, Ordering::Relaxed);
+        chosen.send(&notification()).unwrap();
+        assert_eq!(dbus_sent.load(Ordering::Relaxed), 0);
+        assert_eq!(notify_send_sent.load(Ordering::Relaxed), 2);
+    }
+
+    #[test]
+    fn send_active_reports_failure_without_falling_back() {
+        let dbus = FakeBackend::new("dbus", true);
+        dbus.broken.store(true, Ordering::Relaxed);
+        let log = FakeBackend::new("log", true);
+        let log_sent = log.sent.clone();
+        let chosen = FallbackBackends::probe(vec![Box::new(dbus), Box::new(log)], None);
+
+        let err = chosen.send_active(&notification()).unwrap_err();
+
+        assert!(err.to_string().contains("dbus"), "{err}");
+        assert_eq!(log_sent.load(Ordering::Relaxed), 0);
+        assert_eq!(chosen.active_name(), "dbus");
+    }
+
+    #[test]
+    fn send_fails_when_every_backend_fails() {
+        let dbus = FakeBackend::new("dbus", true);
+        dbus.broken.store(true, Ordering::Relaxed);
+        let chosen = FallbackBackends::probe(vec![Box::new(dbus)], None);
+
+        assert!(chosen.send(&notification()).is_err());
+    }
+}
@@ -0,0 +1,249 @@
+//! Throttles error notifications while a failure persists
+//!
+//! A condition like "network down" is hit on every poll. [`ErrorGateNotifier`]
+//! shows the first error in each [`ErrorCategory`], counts the repeats that
+//! follow within the cooldown, and once the caller reports the category as
+//! resolved, optionally says so along with how many errors were not shown.
+
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+
+use chrono::{DateTime, Duration, Utc};
+
+use super::{ErrorCategory, Notifier};
+
+/// Repeats in a category are counted silently for this many minutes after an
+/// error is shown
+const ERROR_COOLDOWN_MIN: i64 = 30;
+
+/// Returns whether to send a notification when a category recovers
+pub type AnnounceLookup = Box<dyn Fn(ErrorCategory) -> bool + Send + Sync>;
+
+/// Message for the notification sent when errors in `category` stop
+pub fn recovery_message(category: ErrorCategory, suppressed: usize) -> String {
+    let label = category.label();
+    match suppressed {
+        0 => format!("{label} back to normal"),
+        1 => format!("{label} back to normal (suppressed 1 error)"),
+        n => format!("{label} back to normal (suppressed {n} errors)"),
+    }
+}
+
+/// An ongoing failure in one category
+#[derive(Debug)]
+struct Outage {
+    /// When an error was last shown
+    shown_at: DateTime<Utc>,
+    /// Errors not shown since the outage began
+    suppressed: usize,
+}
+
+/// Per-category cooldowns behind [`ErrorGateNotifier`]
+///
+/// Takes `now` explicitly so the cooldown is deterministic in tests.
+#[derive(Debug, Default)]
+struct ErrorGate {
+    outages: HashMap<ErrorCategory, Outage>,
+}
+
+impl ErrorGate {
+    /// Returns whether an error in `category` may be shown: the first one
+    /// may, as may the first once the cooldown has passed. Others are counted.
+    fn admit(&mut self, category: ErrorCategory, now: DateTime<Utc>) -> bool {
+        match self.outages.get_mut(&category) {
+            Some(outage) if now - outage.shown_at < Duration::minutes(ERROR_COOLDOWN_MIN) => {
+                outage.suppressed += 1;
+                false
+            }
+            Some(outage) => {
+                outage.shown_at = now;
+                true
+            }
+            None => {
+                self.outages.insert(
+                    category,
+                    Outage {
+                        shown_at: now,
+                        suppressed: 0,
+                    },
+                );
+                true
+            }
+        }
+    }
+
+    /// Ends the outage in `category`, returning how many errors were
+    /// suppressed during it, or `None` if there wasn't one
+    fn resolve(&mut self, category: ErrorCategory) -> Option<usize> {
+        self.outages.remove(&category).map(|o| o.suppressed)
+    }
+}
+
+/// Shows the first error in each category and counts repeats until the
+/// category is [resolved](Self::resolve)
+pub struct ErrorGateNotifier {
+    inner: Arc<dyn Notifier>,
+    announce_recovery: AnnounceLookup,
+    gate: Mutex<ErrorGate>,
+}
+
+impl ErrorGateNotifier {
+    /// Whether to announce recovery is looked up each time so config changes
+    /// apply immediately.
+    pub fn new(inner: Arc<dyn Notifier>, announce_recovery: AnnounceLookup) -> Self {
+        Self {
+            inner,
+            announce_recovery,
+            gate: Mutex::new(ErrorGate::default()),
+        }
+    }
+
+    fn gate(&self) -> std::sync::MutexGuard<'_, ErrorGate> {
+        self.gate
+            .lock()
+            .unwrap_or_else(std::sync::PoisonError::into_inner)
+    }
+
+    /// Marks errors in `category` as over, so the next one is shown straight
+    /// away. Sends a recovery notification if enabled and an error was shown.
+    pub fn resolve(&self, category: ErrorCategory) -> anyhow::Result<()> {
+        let suppressed = self.gate().resolve(category);
+        match suppressed {
+            Some(suppressed) if (self.announce_recovery)(category) => {
+                self.inner.error_recovered(category, suppressed)
+            }
+            _ => Ok(()),
+        }
+    }
+}
+
+impl Notifier for ErrorGateNotifier {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn error(&self, category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        if !self.gate().admit(category, Utc::now()) {
+            tracing::debug!("Suppressed {:?} error notification: {}", category, message);
+            return Ok(());
+        }
+        self.inner.error(category, message)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::notify::mock::{NotificationType, RecordingNotifier};
+    use crate::test_helpers::make_stream;
+
+    fn at(mins: i64) -> DateTime<Utc> {
+        DateTime::from_timestamp(1_700_000_000, 0).unwrap() + Duration::minutes(mins)
+    }
+
+    #[test]
+    fn repeats_within_cooldown_are_counted() {
+        let mut gate = ErrorGate::default();
+
+        assert!(gate.admit(ErrorCategory::Api, at(0)));
+        assert!(!gate.admit(ErrorCategory::Api, at(1)));
+        assert!(!gate.admit(ErrorCategory::Api, at(ERROR_COOLDOWN_MIN - 1)));
+
+        assert_eq!(gate.resolve(ErrorCategory::Api), Some(2));
+        assert_eq!(gate.resolve(ErrorCategory::Api), None, "already resolved");
+    }
+
+    #[test]
+    fn error_is_shown_again_after_cooldown() {
+        let mut gate = ErrorGate::default();
+
+        assert!(gate.admit(ErrorCategory::Api, at(0)));
+        assert!(!gate.admit(ErrorCategory::Api, at(10)));
+        assert!(gate.admit(ErrorCategory::Api, at(ERROR_COOLDOWN_MIN)));
+        assert!(
+            !gate.admit(ErrorCategory::Api, at(ERROR_COOLDOWN_MIN + 1)),
+            "cooldown restarts from the repeat that was shown"
+        );
+        assert_eq!(gate.resolve(ErrorCategory::Api), Some(2));
+    }
+
+    #[test]
+    fn categories_are_throttled_separately() {
+        let mut gate = ErrorGate::default();
+
+        assert!(gate.admit(ErrorCategory::Api, at(0)));
+        assert!(gate.admit(ErrorCategory::Auth, at(1)));
+        assert_eq!(gate.resolve(ErrorCategory::Auth), Some(0));
+        assert!(!gate.admit(ErrorCategory::Api, at(2)));
+    }
+
+    #[test]
+    fn resolve_announces_recovery_with_suppressed_count() {
+        let recorder = RecordingNotifier::new();
+        let notifier = ErrorGateNotifier::new(Arc::new(recorder.clone()), Box::new(|_| true));
+
+        for _ in 0..15 {
+            notifier
+                .error(ErrorCategory::Api, "Twitch unreachable")
+                .unwrap();
+        }
+        notifier.resolve(ErrorCategory::Api).unwrap();
+        notifier.resolve(ErrorCategory::Api).unwrap();
+
+        assert_eq!(recorder.get_by_type(NotificationType::Error).len(), 1);
+        let recovered = recorder.get_by_type(NotificationType::ErrorRecovered);
+        assert_eq!(recovered.len(), 1, "only the first resolve ends an outage");
+        assert_eq!(
+            recovered[0].message,
+            "Twitch API back to normal (suppressed 14 errors)"
+        );
+    }
+
+    #[test]
+    fn resolve_is_silent_when_announcing_is_off() {
+        let recorder = RecordingNotifier::new();
+        let notifier = ErrorGateNotifier::new(Arc::new(recorder.clone()), Box::new(|_| false));
+
+        notifier
+            .error(ErrorCategory::Auth, "Login expired")
+            .unwrap();
+        notifier.resolve(ErrorCategory::Auth).unwrap();
+        notifier
+            .error(ErrorCategory::Auth, "Login expired")
+            .unwrap();
+
+        assert_eq!(recorder.get_by_type(NotificationType::Error).len(), 2);
+        assert!(recorder
+            .get_by_type(NotificationType::ErrorRecovered)
+            .is_empty());
+    }
+
+    #[test]
+    fn other_notifications_pass_through_ungated() {
+        let recorder = RecordingNotifier::new();
+        let notifier = ErrorGateNotifier::new(Arc::new(recorder.clone()), Box::new(|_| true));
+        let stream = make_stream("1", "Streamer");
+
+        for _ in 0..2 {
+            notifier.stream_live(&stream).unwrap();
+            notifier.overflow_summary(3).unwrap();
+            notifier.test().unwrap();
+        }
+
+        assert_eq!(recorder.get_by_type(NotificationType::StreamLive).len(), 2);
+        assert_eq!(recorder.get_by_type(NotificationType::Overflow).len(), 2);
+        assert_eq!(recorder.get_by_type(NotificationType::Test).len(), 2);
+    }
+
+    #[test]
+    fn recovery_message_counts_errors() {
+        assert_eq!(
+            recovery_message(ErrorCategory::Api, 0),
+            "Twitch API back to normal"
+        );
+        assert_eq!(
+            recovery_message(ErrorCategory::Auth, 1),
+            "Authentication back to normal (suppressed 1 error)"
+        );
+    }
+}
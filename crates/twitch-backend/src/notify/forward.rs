@@ -0,0 +1,344 @@
+//! Forwards notifications to a webhook or ntfy topic
+//!
+//! When `notification_forward.url` is set, [`ForwardingNotifier`] posts a small
+//! JSON payload for each notification as well as showing it on the desktop, so
+//! alerts can reach a phone. Posts run in the background with a timeout and a
+//! couple of retries; a failure is logged and never affects the desktop
+//! notification.
+
+use std::sync::Arc;
+use std::time::Duration as StdDuration;
+
+use chrono::Duration;
+use serde::Serialize;
+
+use super::error_gate::recovery_message;
+use super::rate_limit::overflow_message;
+use super::{live_summary_message, schedule_change_title, ErrorCategory, Notifier, ScheduleChange};
+use crate::hotness_detection::HotnessInfo;
+use crate::twitch::http::{HttpClient, ReqwestClient};
+use crate::twitch::{channel_url, ScheduledStream, Stream};
+
+/// How long a single post may take
+const FORWARD_TIMEOUT_SECS: u64 = 5;
+
+/// Posts attempted before giving up
+const FORWARD_ATTEMPTS: u32 = 3;
+
+/// Pause between attempts
+const FORWARD_RETRY_DELAY_MS: u64 = 250;
+
+/// Returns the URL to forward to, or `None` if forwarding is off
+pub type UrlLookup = Box<dyn Fn() -> Option<String> + Send + Sync>;
+
+/// JSON body posted for each notification
+#[derive(Debug, Clone, PartialEq, Serialize)]
+pub struct ForwardPayload {
+    /// What the notification was about, e.g. "live" or "error"
+    #[serde(rename = "type")]
+    pub kind: &'static str,
+    /// Display name of the channel, if it was about one
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub channel: Option<String>,
+    /// The stream title for stream notifications, otherwise the message shown
+    pub title: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub game: Option<String>,
+    /// Link to the channel
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub url: Option<String>,
+}
+
+impl ForwardPayload {
+    fn for_stream(kind: &'static str, stream: &Stream) -> Self {
+        Self {
+            kind,
+            channel: Some(stream.user_name.clone()),
+            title: stream.title.clone(),
+            game: Some(stream.game_name.clone()),
+            url: Some(stream.channel_url()),
+        }
+    }
+
+    fn message(kind: &'static str, message: String) -> Self {
+        Self {
+            kind,
+            channel: None,
+            title: message,
+            game: None,
+            url: None,
+        }
+    }
+}
+
+/// Shows notifications through `inner` and also posts them to the forwarding
+/// URL, if one is set
+pub struct ForwardingNotifier<H: HttpClient = ReqwestClient> {
+    inner: Arc<dyn Notifier>,
+    http: H,
+    url: UrlLookup,
+}
+
+impl<H: HttpClient + Clone + 'static> ForwardingNotifier<H> {
+    /// The URL is looked up on each notification so config changes apply
+    /// immediately.
+    pub fn new(inner: Arc<dyn Notifier>, http: H, url: UrlLookup) -> Self {
+        Self { inner, http, url }
+    }
+
+    /// Posts the payload in the background if forwarding is on. Needs a Tokio
+    /// runtime; without one (e.g. the command line test) nothing is sent.
+    fn forward(&self, payload: impl FnOnce() -> ForwardPayload) {
+        let Some(url) = (self.url)().filter(|url| !url.is_empty()) else {
+            return;
+        };
+        let Ok(runtime) = tokio::runtime::Handle::try_current() else {
+            tracing::debug!("No runtime to forward notification on");
+            return;
+        };
+        let body = match serde_json::to_string(&payload()) {
+            Ok(body) => body,
+            Err(e) => {
+                tracing::warn!("Failed to encode forwarded notification: {}", e);
+                return;
+            }
+        };
+
+        let http = self.http.clone();
+        runtime.spawn(async move {
+            if let Err(e) = post_with_retry(&http, &url, body).await {
+                tracing::warn!("Failed to forward notification: {}", e);
+            }
+        });
+    }
+}
+
+/// Posts `body` to `url`, retrying failures and timeouts a couple of times
+async fn post_with_retry<H: HttpClient>(http: &H, url: &str, body: String) -> anyhow::Result<()> {
+    let mut error = anyhow::anyhow!("not sent");
+    for attempt in 0..FORWARD_ATTEMPTS {
+        if attempt > 0 {
+            tokio::time::sleep(StdDuration::from_millis(FORWARD_RETRY_DELAY_MS)).await;
+        }
+        let sent = tokio::time::timeout(
+            StdDuration::from_secs(FORWARD_TIMEOUT_SECS),
+            http.post_json_response(url, body.clone()),
+        )
+        .await;
+        error = match sent {
+            Ok(Ok(response)) if response.is_success() => return Ok(()),
+            Ok(Ok(response)) => anyhow::anyhow!("HTTP {}", response.status),
+            Ok(Err(e)) => e,
+            Err(_) => anyhow::anyhow!("timed out after {FORWARD_TIMEOUT_SECS}s"),
+        };
+    }
+    Err(error)
+}
+
+impl<H: HttpClient + Clone + 'static> Notifier for ForwardingNotifier<H> {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        let sent = self.inner.stream_live(stream);
+        self.forward(|| ForwardPayload::for_stream("live", stream));
+        sent
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        let sent = self.inner.streams_live_summary(streams);
+        self.forward(|| ForwardPayload::message("live_summary", live_summary_message(streams)));
+        sent
+    }
+
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        let sent = self.inner.stream_reminder(stream);
+        self.forward(|| ForwardPayload::for_stream("reminder", stream));
+        sent
+    }
+
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        let sent = self.inner.category_changed(stream, old_category);
+        self.forward(|| ForwardPayload::for_stream("category_change", stream));
+        sent
+    }
+
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        let sent = self.inner.title_changed(stream, old_title);
+        self.forward(|| ForwardPayload::for_stream("title_change", stream));
+        sent
+    }
+
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        let sent = self.inner.stream_offline(stream, live_for);
+        self.forward(|| ForwardPayload::for_stream("offline", stream));
+        sent
+    }
+
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        let sent = self.inner.stream_hot(stream, info);
+        self.forward(|| ForwardPayload::for_stream("hot", stream));
+        sent
+    }
+
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        let sent = self.inner.category_stream_alert(stream, threshold);
+        self.forward(|| ForwardPayload::for_stream("category_alert", stream));
+        sent
+    }
+
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        let sent = self.inner.schedule_changed(stream, change);
+        self.forward(|| ForwardPayload {
+            kind: "schedule_change",
+            channel: Some(stream.broadcaster_name.clone()),
+            title: schedule_change_title(stream, change),
+            game: stream.category.clone(),
+            url: Some(channel_url(&stream.broadcaster_login)),
+        });
+        sent
+    }
+
+    fn overflow_summary(&self, suppressed: usize) -> anyhow::Result<()> {
+        let sent = self.inner.overflow_summary(suppressed);
+        self.forward(|| ForwardPayload::message("overflow", overflow_message(suppressed)));
+        sent
+    }
+
+    fn error(&self, category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        let sent = self.inner.error(category, message);
+        self.forward(|| ForwardPayload::message("error", message.to_string()));
+        sent
+    }
+
+    fn error_recovered(&self, category: ErrorCategory, suppressed: usize) -> anyhow::Result<()> {
+        let sent = self.inner.error_recovered(category, suppressed);
+        self.forward(|| {
+            ForwardPayload::message("recovered", recovery_message(category, suppressed))
+        });
+        sent
+    }
+
+    /// Also forwarded, so the forwarding URL can be checked the same way
+    fn test(&self) -> anyhow::Result<()> {
+        let sent = self.inner.test();
+        self.forward(|| ForwardPayload::message("test", "Notifications are working".to_string()));
+        sent
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::notify::mock::{NotificationType, RecordingNotifier};
+    use crate::test_helpers::make_stream_with_game;
+    use crate::twitch::http::mock::MockHttpClient;
+
+    const URL: &str = "https://ntfy.example.com/twitch";
+
+    fn forwarding(
+        mock: &MockHttpClient,
+        url: Option<&str>,
+    ) -> (RecordingNotifier, ForwardingNotifier<MockHttpClient>) {
+        let recorder = RecordingNotifier::new();
+        let url = url.map(str::to_string);
+        let notifier = ForwardingNotifier::new(
+            Arc::new(recorder.clone()),
+            mock.clone(),
+            Box::new(move || url.clone()),
+        );
+        (recorder, notifier)
+    }
+
+    async fn settle() {
+        tokio::time::sleep(StdDuration::from_millis(50)).await;
+    }
+
+    #[test]
+    fn stream_payload_has_channel_title_game_and_url() {
+        let stream = make_stream_with_game("1", "g1", "Factorio");
+
+        let json = serde_json::to_value(ForwardPayload::for_stream("live", &stream)).unwrap();
+
+        assert_eq!(
+            json,
+            serde_json::json!({
+                "type": "live",
+                "channel": "User 1",
+                "title": "Test Stream",
+                "game": "Factorio",
+                "url": "https://twitch.tv/user_1",
+            })
+        );
+    }
+
+    #[test]
+    fn message_payload_omits_stream_fields() {
+        let json =
+            serde_json::to_value(ForwardPayload::message("error", "Oops".to_string())).unwrap();
+
+        assert_eq!(json, serde_json::json!({"type": "error", "title": "Oops"}));
+    }
+
+    #[tokio::test]
+    async fn posts_payload_when_url_is_set() {
+        let mock = MockHttpClient::new().on_post(URL, 200, "");
+        let (recorder, notifier) = forwarding(&mock, Some(URL));
+
+        notifier
+            .stream_live(&make_stream_with_game("1", "g1", "Factorio"))
+            .unwrap();
+        settle().await;
+
+        assert_eq!(recorder.get_by_type(NotificationType::StreamLive).len(), 1);
+        let requests = mock.get_requests();
+        assert_eq!(requests.len(), 1);
+        assert_eq!(requests[0].url, URL);
+        let body: serde_json::Value =
+            serde_json::from_str(requests[0].body.as_deref().unwrap()).unwrap();
+        assert_eq!(body["type"], "live");
+        assert_eq!(body["game"], "Factorio");
+    }
+
+    #[tokio::test]
+    async fn nothing_is_posted_without_url() {
+        let mock = MockHttpClient::new().on_post(URL, 200, "");
+        let (recorder, notifier) = forwarding(&mock, None);
+
+        notifier
+            .stream_live(&make_stream_with_game("1", "g1", "Factorio"))
+            .unwrap();
+        settle().await;
+
+        assert_eq!(recorder.notification_count(), 1);
+        assert!(mock.get_requests().is_empty());
+    }
+
+    #[tokio::test]
+    async fn failed_forward_does_not_affect_desktop_notification() {
+        // No POST response configured, so every attempt fails
+        let mock = MockHttpClient::new();
+        let (recorder, notifier) = forwarding(&mock, Some(URL));
+
+        notifier
+            .error(ErrorCategory::Api, "Twitch unreachable")
+            .unwrap();
+
+        assert_eq!(recorder.get_by_type(NotificationType::Error).len(), 1);
+    }
+
+    #[tokio::test]
+    async fn post_is_retried_before_giving_up() {
+        let mock = MockHttpClient::new().on_post(URL, 503, "");
+
+        let result = post_with_retry(&mock, URL, "{}".to_string()).await;
+
+        assert!(result.unwrap_err().to_string().contains("503"));
+        assert_eq!(mock.get_requests().len(), FORWARD_ATTEMPTS as usize);
+    }
+}
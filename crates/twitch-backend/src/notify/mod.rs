@@ -0,0 +1,1438 @@
+//! Desktop notification handling
+//!
+//! This module provides notification functionality with a trait-based
+//! abstraction for testability.
+
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use chrono::{DateTime, Duration, Local, NaiveTime, Utc};
+use tokio::sync::mpsc;
+
+use crate::hotness_detection::HotnessInfo;
+use crate::images::ImageCache;
+use crate::text::truncate;
+use crate::twitch::{
+    channel_url, format_duration, format_schedule_time, format_viewer_count, ScheduledStream,
+    Stream,
+};
+
+pub mod backends;
+pub mod error_gate;
+pub mod forward;
+pub mod rate_limit;
+pub mod repeat_live;
+
+use backends::{
+    platform_backends, ActionHandler, DesktopNotification, FallbackBackends, NotificationAction,
+};
+use error_gate::recovery_message;
+use rate_limit::overflow_message;
+
+const APP_NAME: &str = "Twitch Tray";
+const SNOOZE_DURATION_MIN: i64 = 10;
+
+/// Channels named in a live summary before the rest are counted
+const SUMMARY_NAMES: usize = 3;
+
+/// What an error notification is about. Repeats are throttled per category,
+/// see [`error_gate`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash)]
+pub enum ErrorCategory {
+    /// Twitch API requests failing
+    Api,
+    /// Logging in or refreshing the token failing
+    Auth,
+    /// Requests paused by the circuit breaker after repeated failures
+    Connection,
+    /// The external player command failing to start
+    Player,
+}
+
+/// How an upcoming scheduled stream changed
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ScheduleChange {
+    /// The segment was removed before it started
+    Cancelled,
+    /// The segment now starts at its `start_time` instead of `from`
+    Moved { from: DateTime<Utc> },
+}
+
+impl ErrorCategory {
+    /// Human-readable name, used in recovery messages
+    pub fn label(self) -> &'static str {
+        match self {
+            Self::Api => "Twitch API",
+            Self::Auth => "Authentication",
+            Self::Connection => "Twitch connection",
+            Self::Player => "External player",
+        }
+    }
+}
+
+/// Shown on notifications that have no streamer avatar
+const APP_ICON_BYTES: &[u8] = include_bytes!(concat!(
+    env!("CARGO_MANIFEST_DIR"),
+    "/../twitch-app-tauri/icons/icon.png"
+));
+const APP_ICON_FILE: &str = "notification-icon.png";
+
+/// Writes the bundled app icon into `dir` so notification daemons can load
+/// it by path, and returns that path
+///
+/// The file is left alone when it already matches the bundled icon and
+/// rewritten when it doesn't, e.g. after an upgrade changed the icon.
+pub fn install_app_icon(dir: &Path) -> anyhow::Result<PathBuf> {
+    let path = dir.join(APP_ICON_FILE);
+    if std::fs::read(&path).is_ok_and(|existing| existing == APP_ICON_BYTES) {
+        return Ok(path);
+    }
+
+    std::fs::create_dir_all(dir)?;
+    let tmp = path.with_extension("tmp");
+    std::fs::write(&tmp, APP_ICON_BYTES)?;
+    std::fs::rename(&tmp, &path)?;
+    Ok(path)
+}
+
+/// A request to snooze a stream notification and re-notify after a delay
+#[derive(Debug, Clone)]
+pub struct SnoozeRequest {
+    pub user_id: String,
+    pub user_name: String,
+    pub remind_at: DateTime<Utc>,
+}
+
+/// A request to mute a streamer's notifications until a given time
+#[derive(Debug, Clone)]
+pub struct MuteRequest {
+    pub user_login: String,
+    /// Empty when muted from the tray menu; looked up from the followed
+    /// streams then
+    pub display_name: String,
+    /// `None` lifts the mute early
+    pub until: Option<DateTime<Utc>>,
+}
+
+/// Returns the start of the next local day, when "Mute today" wears off
+pub fn end_of_local_day(now: DateTime<Utc>) -> DateTime<Utc> {
+    let tomorrow = now.with_timezone(&Local).date_naive() + Duration::days(1);
+    tomorrow
+        .and_time(NaiveTime::MIN)
+        .and_local_timezone(Local)
+        .earliest()
+        .map_or_else(|| now + Duration::days(1), |t| t.with_timezone(&Utc))
+}
+
+/// A request to open per-streamer settings from a notification
+#[derive(Debug, Clone)]
+pub struct StreamerSettingsRequest {
+    pub user_login: String,
+    pub display_name: String,
+}
+
+/// Opens a Twitch stream in the default browser
+///
+/// Shared by the tray menu and notification clicks so both open streams the
+/// same way.
+pub fn open_stream(user_login: &str) {
+    open_in_browser(&channel_url(user_login));
+}
+
+/// Opens a URL in the default browser, logging failures
+pub fn open_in_browser(url: &str) {
+    if let Err(e) = open::that(url) {
+        tracing::error!("Failed to open browser: {}", e);
+    }
+}
+
+/// Info needed to attach a snooze button to a notification
+struct SnoozeInfo {
+    user_id: String,
+    user_name: String,
+    snooze_tx: mpsc::UnboundedSender<SnoozeRequest>,
+}
+
+/// Info needed to attach a "Mute today" button to a notification
+struct MuteInfo {
+    user_login: String,
+    display_name: String,
+    mute_tx: mpsc::UnboundedSender<MuteRequest>,
+}
+
+/// Info needed to attach a settings button to a notification
+struct SettingsInfo {
+    user_login: String,
+    display_name: String,
+    settings_tx: mpsc::UnboundedSender<StreamerSettingsRequest>,
+}
+
+/// Trait for sending notifications
+///
+/// This abstraction allows easy mocking of notifications in tests.
+pub trait Notifier: Send + Sync {
+    /// The notifier this one wraps, if any. Methods a wrapper doesn't
+    /// override are passed on to it unchanged; a notifier that wraps nothing
+    /// overrides them all.
+    fn inner(&self) -> Option<&dyn Notifier> {
+        None
+    }
+
+    /// Sends a notification when a streamer goes live
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.stream_live(stream))
+    }
+
+    /// Sends one notification for several streams that went live together.
+    /// Streams are listed in the order given.
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.streams_live_summary(streams))
+    }
+
+    /// Sends a reminder notification for a snoozed stream
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.stream_reminder(stream))
+    }
+
+    /// Sends a notification when a streamer changes category
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.category_changed(stream, old_category))
+    }
+
+    /// Sends a notification when a streamer changes their stream title
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.title_changed(stream, old_title))
+    }
+
+    /// Sends a notification when a stream has gone offline, `live_for` after
+    /// it started
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.stream_offline(stream, live_for))
+    }
+
+    /// Sends a notification when a stream is detected as "hot"
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.stream_hot(stream, info))
+    }
+
+    /// Sends a notification when a stream in a followed category reaches the
+    /// category's viewer threshold
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        self.inner().map_or(Ok(()), |inner| {
+            inner.category_stream_alert(stream, threshold)
+        })
+    }
+
+    /// Sends a notification when an upcoming scheduled stream is cancelled
+    /// or moved
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.schedule_changed(stream, change))
+    }
+
+    /// Reports how many notifications were dropped by the rate limit
+    fn overflow_summary(&self, suppressed: usize) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.overflow_summary(suppressed))
+    }
+
+    /// Sends an error notification
+    fn error(&self, category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.error(category, message))
+    }
+
+    /// Sends a notification that errors in `category` have stopped, after
+    /// `suppressed` repeats were not shown
+    fn error_recovered(&self, category: ErrorCategory, suppressed: usize) -> anyhow::Result<()> {
+        self.inner()
+            .map_or(Ok(()), |inner| inner.error_recovered(category, suppressed))
+    }
+
+    /// Sends a sample live-style notification, labelled as a test, so users
+    /// can check notifications work. Ignores mutes and rate limits; the error
+    /// names the backend when delivery fails.
+    fn test(&self) -> anyhow::Result<()> {
+        self.inner().map_or(Ok(()), |inner| inner.test())
+    }
+
+    /// Name of the backend notifications are shown with, for the status display
+    fn backend_name(&self) -> Option<&'static str> {
+        self.inner().and_then(|inner| inner.backend_name())
+    }
+}
+
+/// Desktop notification implementation
+pub struct DesktopNotifier {
+    snooze_tx: mpsc::UnboundedSender<SnoozeRequest>,
+    settings_tx: mpsc::UnboundedSender<StreamerSettingsRequest>,
+    mute_tx: mpsc::UnboundedSender<MuteRequest>,
+    /// Streamer avatars, used as notification icons once downloaded
+    images: Arc<ImageCache>,
+    /// Fallback icon, or `None` if it couldn't be written to disk
+    app_icon: Option<PathBuf>,
+    backends: FallbackBackends,
+}
+
+impl DesktopNotifier {
+    /// Creates a new notifier.
+    ///
+    /// `notify_on_live` and `notify_on_category` are no longer stored here —
+    /// gating is done by `NotificationDispatcher` which reads config live on
+    /// each event so that changes take effect without a restart.
+    ///
+    /// The platform's notification backends are probed here; `forced_backend`
+    /// names one to use instead (see `Config::notification_backend`).
+    pub fn new(
+        snooze_tx: mpsc::UnboundedSender<SnoozeRequest>,
+        settings_tx: mpsc::UnboundedSender<StreamerSettingsRequest>,
+        mute_tx: mpsc::UnboundedSender<MuteRequest>,
+        images: Arc<ImageCache>,
+        forced_backend: Option<&str>,
+    ) -> Self {
+        let app_icon = dirs::cache_dir()
+            .map(|dir| dir.join("twitch-tray"))
+            .ok_or_else(|| anyhow::anyhow!("Could not determine cache directory"))
+            .and_then(|dir| install_app_icon(&dir));
+        let app_icon = match app_icon {
+            Ok(path) => Some(path),
+            Err(e) => {
+                tracing::warn!("Notifications will have no icon: {}", e);
+                None
+            }
+        };
+
+        Self {
+            snooze_tx,
+            settings_tx,
+            mute_tx,
+            images,
+            app_icon,
+            backends: FallbackBackends::probe(platform_backends(), forced_backend),
+        }
+    }
+
+    /// Sends a notification through the first working backend
+    ///
+    /// `on_click` runs when the notification itself is clicked, where the
+    /// backend supports notification actions. Snooze, mute and settings
+    /// buttons are only offered alongside it.
+    #[allow(clippy::too_many_arguments)] // one per notification feature; most are optional
+    fn send_notification(
+        &self,
+        title: &str,
+        message: &str,
+        icon: Option<&Path>,
+        on_click: Option<ActionHandler>,
+        category: Option<&'static str>,
+        snooze_info: Option<SnoozeInfo>,
+        settings_info: Option<SettingsInfo>,
+        mute_info: Option<MuteInfo>,
+    ) -> anyhow::Result<()> {
+        let mut actions = Vec::new();
+        if let Some(on_click) = on_click {
+            actions.push(NotificationAction {
+                id: "default",
+                label: "Open Stream",
+                run: on_click,
+            });
+            if let Some(info) = snooze_info {
+                actions.push(NotificationAction {
+                    id: "snooze_10",
+                    label: "Snooze 10m",
+                    run: Arc::new(move || {
+                        let request = SnoozeRequest {
+                            user_id: info.user_id.clone(),
+                            user_name: info.user_name.clone(),
+                            remind_at: Utc::now() + Duration::minutes(SNOOZE_DURATION_MIN),
+                        };
+                        let _ = info.snooze_tx.send(request);
+                    }),
+                });
+            }
+            if let Some(info) = mute_info {
+                actions.push(NotificationAction {
+                    id: "mute_today",
+                    label: "Mute today",
+                    run: Arc::new(move || {
+                        let request = MuteRequest {
+                            user_login: info.user_login.clone(),
+                            display_name: info.display_name.clone(),
+                            until: Some(end_of_local_day(Utc::now())),
+                        };
+                        let _ = info.mute_tx.send(request);
+                    }),
+                });
+            }
+            if let Some(info) = settings_info {
+                actions.push(NotificationAction {
+                    id: "streamer-settings",
+                    label: "\u{2699}\u{fe0f}",
+                    run: Arc::new(move || {
+                        let request = StreamerSettingsRequest {
+                            user_login: info.user_login.clone(),
+                            display_name: info.display_name.clone(),
+                        };
+                        let _ = info.settings_tx.send(request);
+                    }),
+                });
+            }
+        }
+
+        self.backends.send(&DesktopNotification {
+            title: title.to_string(),
+            message: message.to_string(),
+            icon: icon.map(Path::to_path_buf),
+            category,
+            actions,
+        })
+    }
+}
+
+/// Notification categories (freedesktop.org spec)
+/// These allow users to configure different behaviors per notification type at the OS level
+mod categories {
+    /// Category for "stream went live" notifications
+    pub const STREAM_LIVE: &str = "presence.online";
+    /// Category for "category changed" notifications
+    pub const CATEGORY_CHANGE: &str = "category.changed";
+    /// Category for "stream is hot" notifications
+    pub const STREAM_HOT: &str = "presence.hot";
+    /// Category for "big stream in a followed category" notifications
+    pub const CATEGORY_ALERT: &str = "presence.category";
+    /// Category for "scheduled stream cancelled or moved" notifications
+    pub const SCHEDULE_CHANGE: &str = "schedule.changed";
+    /// Category for "title changed" notifications
+    pub const TITLE_CHANGE: &str = "title.changed";
+    /// Category for "stream went offline" notifications
+    pub const STREAM_OFFLINE: &str = "presence.offline";
+}
+
+impl DesktopNotifier {
+    /// Click handler that opens the stream, as the tray menu does
+    fn open_stream_on_click(stream: &Stream) -> Option<ActionHandler> {
+        let user_login = stream.user_login.clone();
+        Some(Arc::new(move || open_stream(&user_login)))
+    }
+
+    /// The streamer's avatar if it has already been downloaded, otherwise the
+    /// app icon. Avatars are fetched ahead of time by the backend so
+    /// notifications never wait on the network.
+    fn icon_for(&self, stream: &Stream) -> Option<PathBuf> {
+        if stream.profile_image_url.is_empty() {
+            return self.app_icon.clone();
+        }
+        self.images
+            .cached_path(&stream.profile_image_url)
+            .or_else(|| self.app_icon.clone())
+    }
+
+    fn make_snooze_info(&self, stream: &Stream) -> Option<SnoozeInfo> {
+        Some(SnoozeInfo {
+            user_id: stream.user_id.clone(),
+            user_name: stream.user_name.clone(),
+            snooze_tx: self.snooze_tx.clone(),
+        })
+    }
+
+    fn make_mute_info(&self, stream: &Stream) -> Option<MuteInfo> {
+        Some(MuteInfo {
+            user_login: stream.user_login.clone(),
+            display_name: stream.user_name.clone(),
+            mute_tx: self.mute_tx.clone(),
+        })
+    }
+
+    fn make_settings_info(&self, stream: &Stream) -> Option<SettingsInfo> {
+        Some(SettingsInfo {
+            user_login: stream.user_login.clone(),
+            display_name: stream.user_name.clone(),
+            settings_tx: self.settings_tx.clone(),
+        })
+    }
+}
+
+impl Notifier for DesktopNotifier {
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        let title = format!("{} is now live!", stream.user_name);
+        let message = if stream.title.is_empty() {
+            stream.game_name.clone()
+        } else {
+            format!("{} - {}", stream.game_name, truncate(&stream.title, 50))
+        };
+
+        let on_click = Self::open_stream_on_click(stream);
+        let snooze = self.make_snooze_info(stream);
+        let settings = self.make_settings_info(stream);
+        let mute = self.make_mute_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::STREAM_LIVE),
+            snooze,
+            settings,
+            mute,
+        )
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        let title = format!("{} channels went live", streams.len());
+        let message = live_summary_message(streams);
+
+        self.send_notification(
+            &title,
+            &message,
+            self.app_icon.as_deref(),
+            None,
+            Some(categories::STREAM_LIVE),
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        let title = format!("{} live for {}", stream.user_name, stream.format_duration());
+        let message = if stream.title.is_empty() {
+            stream.game_name.clone()
+        } else {
+            format!("{} - {}", stream.game_name, truncate(&stream.title, 50))
+        };
+
+        let on_click = Self::open_stream_on_click(stream);
+        let snooze = self.make_snooze_info(stream);
+        let settings = self.make_settings_info(stream);
+        let mute = self.make_mute_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::STREAM_LIVE),
+            snooze,
+            settings,
+            mute,
+        )
+    }
+
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        let title = format!("{} changed category", stream.user_name);
+        let message = format!("{} → {}", old_category, stream.game_name);
+
+        let on_click = Self::open_stream_on_click(stream);
+        let settings = self.make_settings_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::CATEGORY_CHANGE),
+            None,
+            settings,
+            None,
+        )
+    }
+
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        let title = format!("{} changed title", stream.user_name);
+        let message = format!(
+            "{} → {}",
+            truncate(old_title, 40),
+            truncate(&stream.title, 80)
+        );
+
+        let on_click = Self::open_stream_on_click(stream);
+        let settings = self.make_settings_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::TITLE_CHANGE),
+            None,
+            settings,
+            None,
+        )
+    }
+
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        let title = offline_title(stream, live_for);
+        let message = stream.game_name.clone();
+
+        let on_click = Self::open_stream_on_click(stream);
+        let settings = self.make_settings_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::STREAM_OFFLINE),
+            None,
+            settings,
+            None,
+        )
+    }
+
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        let title = format!(
+            "\u{1f525}\u{1f525}\u{1f525} ({:.1}\u{03c3}) {} on {} IS HOT",
+            info.z_score, stream.user_name, stream.game_name,
+        );
+        let message = truncate(&stream.title, 80);
+
+        let on_click = Self::open_stream_on_click(stream);
+        let settings = self.make_settings_info(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::STREAM_HOT),
+            None,
+            settings,
+            None,
+        )
+    }
+
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        let title = category_alert_title(stream, threshold);
+        let message = truncate(&stream.title, 80);
+
+        let on_click = Self::open_stream_on_click(stream);
+        self.send_notification(
+            &title,
+            &message,
+            self.icon_for(stream).as_deref(),
+            on_click,
+            Some(categories::CATEGORY_ALERT),
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        self.send_notification(
+            &schedule_change_title(stream, change),
+            &schedule_change_message(stream, change),
+            self.app_icon.as_deref(),
+            None,
+            Some(categories::SCHEDULE_CHANGE),
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn overflow_summary(&self, suppressed: usize) -> anyhow::Result<()> {
+        self.send_notification(
+            APP_NAME,
+            &overflow_message(suppressed),
+            self.app_icon.as_deref(),
+            None,
+            None,
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn error(&self, _category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        self.send_notification(
+            APP_NAME,
+            message,
+            self.app_icon.as_deref(),
+            None,
+            None,
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn error_recovered(&self, category: ErrorCategory, suppressed: usize) -> anyhow::Result<()> {
+        self.send_notification(
+            APP_NAME,
+            &recovery_message(category, suppressed),
+            self.app_icon.as_deref(),
+            None,
+            None,
+            None,
+            None,
+            None,
+        )
+    }
+
+    fn test(&self) -> anyhow::Result<()> {
+        self.backends.send_active(&DesktopNotification {
+            title: format!("Test: {APP_NAME} is now live!"),
+            message: format!(
+                "Notifications are working (sent with {})",
+                self.backends.active_name()
+            ),
+            icon: self.app_icon.clone(),
+            category: Some(categories::STREAM_LIVE),
+            actions: Vec::new(),
+        })
+    }
+
+    fn backend_name(&self) -> Option<&'static str> {
+        Some(self.backends.active_name())
+    }
+}
+
+/// Returns true if notifications for the channel with this login are muted
+pub type MuteLookup = Box<dyn Fn(&str) -> bool + Send + Sync>;
+
+/// Drops stream notifications for muted channels before they reach `inner`
+///
+/// The lookup is injected so this module doesn't depend on config; the
+/// backend wires it to the live config so mute changes apply immediately.
+pub struct MutingNotifier {
+    inner: Arc<dyn Notifier>,
+    is_muted: MuteLookup,
+}
+
+impl MutingNotifier {
+    pub fn new(inner: Arc<dyn Notifier>, is_muted: MuteLookup) -> Self {
+        Self { inner, is_muted }
+    }
+
+    fn muted(&self, login: &str, kind: &str) -> bool {
+        let muted = (self.is_muted)(login);
+        if muted {
+            tracing::debug!(
+                "Suppressed {} notification for muted channel {}",
+                kind,
+                login
+            );
+        }
+        muted
+    }
+}
+
+impl Notifier for MutingNotifier {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "live") {
+            return Ok(());
+        }
+        self.inner.stream_live(stream)
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        let unmuted: Vec<Stream> = streams
+            .iter()
+            .filter(|s| !self.muted(&s.user_login, "live"))
+            .cloned()
+            .collect();
+        match unmuted.as_slice() {
+            [] => Ok(()),
+            [stream] => self.inner.stream_live(stream),
+            _ => self.inner.streams_live_summary(&unmuted),
+        }
+    }
+
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "reminder") {
+            return Ok(());
+        }
+        self.inner.stream_reminder(stream)
+    }
+
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "category") {
+            return Ok(());
+        }
+        self.inner.category_changed(stream, old_category)
+    }
+
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "title") {
+            return Ok(());
+        }
+        self.inner.title_changed(stream, old_title)
+    }
+
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "offline") {
+            return Ok(());
+        }
+        self.inner.stream_offline(stream, live_for)
+    }
+
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "hot") {
+            return Ok(());
+        }
+        self.inner.stream_hot(stream, info)
+    }
+
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        if self.muted(&stream.user_login, "category alert") {
+            return Ok(());
+        }
+        self.inner.category_stream_alert(stream, threshold)
+    }
+
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        if self.muted(&stream.broadcaster_login, "schedule change") {
+            return Ok(());
+        }
+        self.inner.schedule_changed(stream, change)
+    }
+}
+
+/// e.g. "Streamer went offline after 4h 12m"
+pub fn offline_title(stream: &Stream, live_for: Duration) -> String {
+    format!(
+        "{} went offline after {}",
+        stream.user_name,
+        format_duration(live_for)
+    )
+}
+
+/// e.g. "GiantWaffle just hit 12k viewers in Factorio"
+pub fn category_alert_title(stream: &Stream, threshold: u32) -> String {
+    format!(
+        "{} just hit {} viewers in {}",
+        stream.user_name,
+        format_viewer_count(threshold),
+        stream.game_name
+    )
+}
+
+/// e.g. "Streamer cancelled a scheduled stream"
+pub fn schedule_change_title(stream: &ScheduledStream, change: ScheduleChange) -> String {
+    match change {
+        ScheduleChange::Cancelled => {
+            format!("{} cancelled a scheduled stream", stream.broadcaster_name)
+        }
+        ScheduleChange::Moved { .. } => {
+            format!("{} moved a scheduled stream", stream.broadcaster_name)
+        }
+    }
+}
+
+/// e.g. "Now Tomorrow 8:00 PM, was Today 8:00 PM"
+fn schedule_change_message(stream: &ScheduledStream, change: ScheduleChange) -> String {
+    match change {
+        ScheduleChange::Cancelled => format!(
+            "Was {}: {}",
+            format_schedule_time(stream.start_time),
+            truncate(&stream.title, 50)
+        ),
+        ScheduleChange::Moved { from } => format!(
+            "Now {}, was {}",
+            format_schedule_time(stream.start_time),
+            format_schedule_time(from)
+        ),
+    }
+}
+
+/// Names the first few streamers and counts the rest, e.g. "A, B, C and 2 more"
+pub fn live_summary_message(streams: &[Stream]) -> String {
+    let names: Vec<&str> = streams
+        .iter()
+        .take(SUMMARY_NAMES)
+        .map(|s| s.user_name.as_str())
+        .collect();
+    let rest = streams.len().saturating_sub(SUMMARY_NAMES);
+    if rest == 0 {
+        names.join(", ")
+    } else {
+        format!("{} and {rest} more", names.join(", "))
+    }
+}
+
+/// Recording notifier for testing
+///
+/// Records all notifications for later verification.
+#[cfg(test)]
+pub mod mock {
+    use super::*;
+    use std::sync::{Arc, RwLock};
+
+    /// A recorded notification
+    #[derive(Debug, Clone, PartialEq)]
+    pub struct RecordedNotification {
+        pub notification_type: NotificationType,
+        pub title: String,
+        pub message: String,
+    }
+
+    /// Type of notification
+    #[derive(Debug, Clone, PartialEq)]
+    pub enum NotificationType {
+        StreamLive,
+        StreamLiveSummary,
+        StreamReminder,
+        CategoryChange,
+        TitleChange,
+        StreamOffline,
+        StreamHot,
+        CategoryStreamAlert,
+        ScheduleChange,
+        Overflow,
+        Error,
+        ErrorRecovered,
+        Test,
+    }
+
+    /// Recording notifier that captures all notifications
+    #[derive(Debug, Default, Clone)]
+    pub struct RecordingNotifier {
+        notifications: Arc<RwLock<Vec<RecordedNotification>>>,
+    }
+
+    impl RecordingNotifier {
+        /// Creates a new recording notifier
+        pub fn new() -> Self {
+            Self::default()
+        }
+
+        /// Returns all recorded notifications
+        pub fn get_notifications(&self) -> Vec<RecordedNotification> {
+            self.notifications.read().unwrap().clone()
+        }
+
+        /// Returns the number of notifications recorded
+        pub fn notification_count(&self) -> usize {
+            self.notifications.read().unwrap().len()
+        }
+
+        /// Returns notifications of a specific type
+        pub fn get_by_type(
+            &self,
+            notification_type: NotificationType,
+        ) -> Vec<RecordedNotification> {
+            self.notifications
+                .read()
+                .unwrap()
+                .iter()
+                .filter(|n| n.notification_type == notification_type)
+                .cloned()
+                .collect()
+        }
+
+        /// Clears all recorded notifications
+        pub fn clear(&self) {
+            self.notifications.write().unwrap().clear();
+        }
+    }
+
+    impl Notifier for RecordingNotifier {
+        fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+            let title = format!("{} is now live!", stream.user_name);
+            let message = if !stream.title.is_empty() {
+                format!("{} - {}", stream.game_name, stream.title)
+            } else {
+                stream.game_name.clone()
+            };
+
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::StreamLive,
+                    title,
+                    message,
+                });
+
+            Ok(())
+        }
+
+        fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::StreamLiveSummary,
+                    title: format!("{} channels went live", streams.len()),
+                    message: live_summary_message(streams),
+                });
+
+            Ok(())
+        }
+
+        fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+            let title = format!("{} live for {}", stream.user_name, stream.format_duration());
+            let message = if !stream.title.is_empty() {
+                format!("{} - {}", stream.game_name, stream.title)
+            } else {
+                stream.game_name.clone()
+            };
+
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::StreamReminder,
+                    title,
+                    message,
+                });
+
+            Ok(())
+        }
+
+        fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+            let title = format!("{} changed category", stream.user_name);
+            let message = format!("{} → {}", old_category, stream.game_name);
+
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::CategoryChange,
+                    title,
+                    message,
+                });
+
+            Ok(())
+        }
+
+        fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::TitleChange,
+                    title: format!("{} changed title", stream.user_name),
+                    message: format!("{} → {}", old_title, stream.title),
+                });
+
+            Ok(())
+        }
+
+        fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::StreamOffline,
+                    title: offline_title(stream, live_for),
+                    message: stream.game_name.clone(),
+                });
+
+            Ok(())
+        }
+
+        fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+            let title = format!(
+                "\u{1f525}\u{1f525}\u{1f525} ({:.1}\u{03c3}) {} on {} IS HOT",
+                info.z_score, stream.user_name, stream.game_name,
+            );
+            let message = stream.title.clone();
+
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::StreamHot,
+                    title,
+                    message,
+                });
+
+            Ok(())
+        }
+
+        fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::CategoryStreamAlert,
+                    title: category_alert_title(stream, threshold),
+                    message: stream.title.clone(),
+                });
+
+            Ok(())
+        }
+
+        fn schedule_changed(
+            &self,
+            stream: &ScheduledStream,
+            change: ScheduleChange,
+        ) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::ScheduleChange,
+                    title: schedule_change_title(stream, change),
+                    message: schedule_change_message(stream, change),
+                });
+
+            Ok(())
+        }
+
+        fn overflow_summary(&self, suppressed: usize) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::Overflow,
+                    title: "Twitch Tray".to_string(),
+                    message: overflow_message(suppressed),
+                });
+
+            Ok(())
+        }
+
+        fn error(&self, _category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::Error,
+                    title: "Twitch Tray".to_string(),
+                    message: message.to_string(),
+                });
+
+            Ok(())
+        }
+
+        fn error_recovered(
+            &self,
+            category: ErrorCategory,
+            suppressed: usize,
+        ) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::ErrorRecovered,
+                    title: "Twitch Tray".to_string(),
+                    message: recovery_message(category, suppressed),
+                });
+
+            Ok(())
+        }
+
+        fn test(&self) -> anyhow::Result<()> {
+            self.notifications
+                .write()
+                .unwrap()
+                .push(RecordedNotification {
+                    notification_type: NotificationType::Test,
+                    title: "Test: Twitch Tray is now live!".to_string(),
+                    message: "Notifications are working".to_string(),
+                });
+
+            Ok(())
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::mock::{NotificationType, RecordingNotifier};
+    use super::*;
+    use chrono::Utc;
+
+    fn make_stream(user_name: &str, game_name: &str, title: &str) -> Stream {
+        Stream {
+            id: "123".to_string(),
+            user_id: "456".to_string(),
+            user_login: user_name.to_lowercase(),
+            user_name: user_name.to_string(),
+            game_id: "789".to_string(),
+            game_name: game_name.to_string(),
+            title: title.to_string(),
+            viewer_count: 1000,
+            started_at: Utc::now() - chrono::Duration::hours(1),
+            thumbnail_url: "https://example.com/thumb.jpg".to_string(),
+            tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
+            profile_image_url: String::new(),
+        }
+    }
+
+    // === end_of_local_day tests ===
+
+    #[test]
+    fn end_of_local_day_is_next_local_midnight() {
+        let now = Utc::now();
+        let end = end_of_local_day(now);
+
+        assert!(end > now);
+        assert!(end - now <= chrono::Duration::hours(25), "allows for DST");
+        let local = end.with_timezone(&Local);
+        assert_eq!(local.time(), NaiveTime::MIN);
+        assert_eq!(
+            local.date_naive(),
+            now.with_timezone(&Local).date_naive() + chrono::Duration::days(1)
+        );
+    }
+
+    // === RecordingNotifier tests ===
+
+    #[test]
+    fn recording_notifier_records_stream_live() {
+        let notifier = RecordingNotifier::new();
+        let stream = make_stream("TestStreamer", "Minecraft", "Building a castle!");
+
+        notifier.stream_live(&stream).unwrap();
+
+        let notifications = notifier.get_notifications();
+        assert_eq!(notifications.len(), 1);
+        assert_eq!(
+            notifications[0].notification_type,
+            NotificationType::StreamLive
+        );
+        assert!(notifications[0].title.contains("TestStreamer"));
+        assert!(notifications[0].message.contains("Minecraft"));
+    }
+
+    #[test]
+    fn recording_notifier_records_error() {
+        let notifier = RecordingNotifier::new();
+
+        notifier
+            .error(ErrorCategory::Api, "Something went wrong")
+            .unwrap();
+
+        let notifications = notifier.get_notifications();
+        assert_eq!(notifications.len(), 1);
+        assert_eq!(notifications[0].notification_type, NotificationType::Error);
+        assert_eq!(notifications[0].message, "Something went wrong");
+    }
+
+    #[test]
+    fn recording_notifier_get_by_type() {
+        let notifier = RecordingNotifier::new();
+        let stream = make_stream("Streamer", "Game", "Title");
+
+        notifier.stream_live(&stream).unwrap();
+        notifier.error(ErrorCategory::Api, "Error 1").unwrap();
+        notifier.stream_live(&stream).unwrap();
+        notifier.error(ErrorCategory::Api, "Error 2").unwrap();
+
+        let live_notifications = notifier.get_by_type(NotificationType::StreamLive);
+        assert_eq!(live_notifications.len(), 2);
+
+        let error_notifications = notifier.get_by_type(NotificationType::Error);
+        assert_eq!(error_notifications.len(), 2);
+    }
+
+    #[test]
+    fn recording_notifier_clear() {
+        let notifier = RecordingNotifier::new();
+
+        notifier.error(ErrorCategory::Api, "Test").unwrap();
+        assert_eq!(notifier.notification_count(), 1);
+
+        notifier.clear();
+        assert_eq!(notifier.notification_count(), 0);
+    }
+
+    #[test]
+    fn recording_notifier_records_category_change() {
+        let notifier = RecordingNotifier::new();
+        let stream = make_stream("TestStreamer", "Minecraft", "Building a castle!");
+
+        notifier.category_changed(&stream, "Fortnite").unwrap();
+
+        let notifications = notifier.get_notifications();
+        assert_eq!(notifications.len(), 1);
+        assert_eq!(
+            notifications[0].notification_type,
+            NotificationType::CategoryChange
+        );
+        assert!(notifications[0].title.contains("TestStreamer"));
+        assert!(notifications[0].message.contains("Fortnite"));
+        assert!(notifications[0].message.contains("Minecraft"));
+    }
+
+    #[test]
+    fn category_change_shows_arrow() {
+        let notifier = RecordingNotifier::new();
+        let stream = make_stream("Streamer", "New Game", "Title");
+
+        notifier.category_changed(&stream, "Old Game").unwrap();
+
+        let notifications = notifier.get_notifications();
+        assert!(notifications[0].message.contains("→"));
+        assert_eq!(notifications[0].message, "Old Game → New Game");
+    }
+
+    // === install_app_icon tests ===
+
+    #[test]
+    fn install_app_icon_writes_bundled_icon() {
+        let dir = tempfile::tempdir().unwrap();
+        let path = install_app_icon(&dir.path().join("twitch-tray")).unwrap();
+        assert_eq!(std::fs::read(&path).unwrap(), APP_ICON_BYTES);
+    }
+
+    #[test]
+    fn install_app_icon_replaces_outdated_icon() {
+        let dir = tempfile::tempdir().unwrap();
+        std::fs::write(dir.path().join(APP_ICON_FILE), b"old icon").unwrap();
+
+        let path = install_app_icon(dir.path()).unwrap();
+        assert_eq!(std::fs::read(&path).unwrap(), APP_ICON_BYTES);
+
+        // Already current, so installing again is a no-op
+        assert_eq!(install_app_icon(dir.path()).unwrap(), path);
+    }
+
+    #[test]
+    fn install_app_icon_fails_when_dir_is_unusable() {
+        let dir = tempfile::tempdir().unwrap();
+        let not_a_dir = dir.path().join("file");
+        std::fs::write(&not_a_dir, b"").unwrap();
+        assert!(install_app_icon(&not_a_dir).is_err());
+    }
+
+    // === MutingNotifier tests ===
+
+    fn muting(muted_login: &'static str) -> (Arc<RecordingNotifier>, MutingNotifier) {
+        let recorder = Arc::new(RecordingNotifier::new());
+        let notifier = MutingNotifier::new(
+            recorder.clone(),
+            Box::new(move |login| login == muted_login),
+        );
+        (recorder, notifier)
+    }
+
+    #[test]
+    fn muting_notifier_drops_muted_channels() {
+        let (recorder, notifier) = muting("quiet");
+        let quiet = make_stream("Quiet", "Game", "Title");
+
+        notifier.stream_live(&quiet).unwrap();
+        notifier.stream_reminder(&quiet).unwrap();
+        notifier.category_changed(&quiet, "Old Game").unwrap();
+        notifier.stream_offline(&quiet, Duration::hours(1)).unwrap();
+        notifier.title_changed(&quiet, "Old title").unwrap();
+        notifier
+            .stream_hot(
+                &quiet,
+                &HotnessInfo {
+                    broadcaster_id: quiet.user_id.clone(),
+                    z_score: 3.0,
+                    is_hot: true,
+                    mean_viewers: 100.0,
+                    stddev: 50.0,
+                    current_viewers: 1000,
+                    observation_count: 10,
+                    distinct_streams: 7,
+                },
+            )
+            .unwrap();
+
+        assert_eq!(recorder.notification_count(), 0);
+    }
+
+    #[test]
+    fn muting_notifier_passes_through_other_channels_and_errors() {
+        let (recorder, notifier) = muting("quiet");
+
+        notifier
+            .stream_live(&make_stream("Loud", "Game", "Title"))
+            .unwrap();
+        notifier.error(ErrorCategory::Api, "boom").unwrap();
+
+        let types: Vec<_> = recorder
+            .get_notifications()
+            .into_iter()
+            .map(|n| n.notification_type)
+            .collect();
+        assert_eq!(
+            types,
+            vec![NotificationType::StreamLive, NotificationType::Error]
+        );
+    }
+
+    #[test]
+    fn muting_notifier_removes_muted_channels_from_summary() {
+        let (recorder, notifier) = muting("quiet");
+        let streams = vec![
+            make_stream("Quiet", "Game", "Title"),
+            make_stream("A", "Game", "Title"),
+            make_stream("B", "Game", "Title"),
+        ];
+
+        notifier.streams_live_summary(&streams).unwrap();
+        notifier.streams_live_summary(&streams[..2]).unwrap();
+
+        let notifications = recorder.get_notifications();
+        assert_eq!(notifications.len(), 2);
+        assert_eq!(notifications[0].message, "A, B");
+        assert_eq!(
+            notifications[1].notification_type,
+            NotificationType::StreamLive
+        );
+    }
+
+    #[test]
+    fn category_alert_title_names_threshold_and_game() {
+        let stream = make_stream("GiantWaffle", "Factorio", "Title");
+        assert_eq!(
+            category_alert_title(&stream, 12_000),
+            "GiantWaffle just hit 12k viewers in Factorio"
+        );
+    }
+
+    #[test]
+    fn schedule_change_title_says_what_happened() {
+        let stream = crate::test_helpers::make_scheduled("Streamer", 2);
+        let from = stream.start_time - Duration::hours(1);
+
+        assert_eq!(
+            schedule_change_title(&stream, ScheduleChange::Cancelled),
+            "Streamer cancelled a scheduled stream"
+        );
+        assert_eq!(
+            schedule_change_title(&stream, ScheduleChange::Moved { from }),
+            "Streamer moved a scheduled stream"
+        );
+    }
+
+    #[test]
+    fn offline_title_includes_how_long_stream_ran() {
+        let stream = make_stream("Streamer", "Game", "Title");
+        assert_eq!(
+            offline_title(&stream, Duration::hours(4) + Duration::minutes(12)),
+            "Streamer went offline after 4h 12m"
+        );
+    }
+
+    // === live summary tests ===
+
+    #[test]
+    fn live_summary_names_first_three_and_counts_rest() {
+        let streams: Vec<Stream> = ["A", "B", "C", "D", "E"]
+            .iter()
+            .map(|name| make_stream(name, "Game", "Title"))
+            .collect();
+        assert_eq!(live_summary_message(&streams), "A, B, C and 2 more");
+        assert_eq!(live_summary_message(&streams[..2]), "A, B");
+    }
+}
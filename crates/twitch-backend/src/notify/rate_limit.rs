@@ -0,0 +1,311 @@
+//! Caps how many notifications are shown per minute
+//!
+//! A bug, an API hiccup or a very large follow list can produce a burst of
+//! events. [`RateLimitingNotifier`] lets the first few through each minute,
+//! counts the rest, and reports the count in one notification once the minute
+//! is up.
+
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+
+use chrono::{DateTime, Duration, Utc};
+
+use super::{ErrorCategory, Notifier, ScheduleChange};
+use crate::hotness_detection::HotnessInfo;
+use crate::twitch::{ScheduledStream, Stream};
+
+/// Length of the window the limit applies to
+const WINDOW_SECS: i64 = 60;
+
+/// The same error message is shown at most once per this many minutes
+const ERROR_DEDUP_MIN: i64 = 10;
+
+/// Returns the current per-minute limit; 0 means unlimited
+pub type LimitLookup = Box<dyn Fn() -> usize + Send + Sync>;
+
+/// Message for the notification that reports suppressed events
+pub fn overflow_message(suppressed: usize) -> String {
+    if suppressed == 1 {
+        "...and 1 more event".to_string()
+    } else {
+        format!("...and {suppressed} more events")
+    }
+}
+
+/// Fixed-window counter behind [`RateLimitingNotifier`]
+///
+/// Takes `now` explicitly so the windowing is deterministic in tests.
+#[derive(Debug, Default)]
+struct RateLimiter {
+    /// Start of the current window, or `None` until something is sent
+    window_start: Option<DateTime<Utc>>,
+    sent: usize,
+    suppressed: usize,
+    /// When each error message was last shown
+    recent_errors: HashMap<String, DateTime<Utc>>,
+}
+
+impl RateLimiter {
+    /// Closes the window if it has ended, returning how many notifications
+    /// were suppressed in it (if any)
+    fn roll(&mut self, now: DateTime<Utc>) -> Option<usize> {
+        let start = self.window_start?;
+        if now - start < Duration::seconds(WINDOW_SECS) {
+            return None;
+        }
+        self.window_start = None;
+        self.sent = 0;
+        Some(std::mem::take(&mut self.suppressed)).filter(|&n| n > 0)
+    }
+
+    /// Counts a notification, returning whether it may be sent
+    fn admit(&mut self, limit: usize, now: DateTime<Utc>) -> bool {
+        if limit == 0 {
+            return true;
+        }
+        self.window_start.get_or_insert(now);
+        if self.sent < limit {
+            self.sent += 1;
+            true
+        } else {
+            self.suppressed += 1;
+            false
+        }
+    }
+
+    /// Returns whether an error may be shown: always, unless the same message
+    /// was shown in the last [`ERROR_DEDUP_MIN`] minutes
+    fn admit_error(&mut self, message: &str, now: DateTime<Utc>) -> bool {
+        let cutoff = now - Duration::minutes(ERROR_DEDUP_MIN);
+        self.recent_errors.retain(|_, shown_at| *shown_at > cutoff);
+        if self.recent_errors.contains_key(message) {
+            return false;
+        }
+        self.recent_errors.insert(message.to_string(), now);
+        true
+    }
+}
+
+/// Sends at most a limited number of notifications per minute through `inner`
+///
+/// Notifications over the limit are dropped and counted; the count is sent
+/// as a single summary when the window rolls over, either on the next
+/// notification or when [`flush`](Self::flush) is called. Errors bypass the
+/// limit but repeats of the same message are dropped for ten minutes.
+pub struct RateLimitingNotifier {
+    inner: Arc<dyn Notifier>,
+    limit: LimitLookup,
+    limiter: Mutex<RateLimiter>,
+}
+
+impl RateLimitingNotifier {
+    /// The limit is looked up on each notification so config changes apply
+    /// immediately.
+    pub fn new(inner: Arc<dyn Notifier>, limit: LimitLookup) -> Self {
+        Self {
+            inner,
+            limit,
+            limiter: Mutex::new(RateLimiter::default()),
+        }
+    }
+
+    fn limiter(&self) -> std::sync::MutexGuard<'_, RateLimiter> {
+        self.limiter
+            .lock()
+            .unwrap_or_else(std::sync::PoisonError::into_inner)
+    }
+
+    /// Sends the summary of suppressed notifications if the window has ended.
+    /// Called periodically so the summary doesn't wait for the next event.
+    pub fn flush(&self) -> anyhow::Result<()> {
+        let overflow = self.limiter().roll(Utc::now());
+        match overflow {
+            Some(suppressed) => self.inner.overflow_summary(suppressed),
+            None => Ok(()),
+        }
+    }
+
+    /// Runs `send` if the limit allows, first reporting any overflow from the
+    /// previous window. The lock is released before calling `inner`.
+    fn limited(&self, send: impl FnOnce() -> anyhow::Result<()>) -> anyhow::Result<()> {
+        let limit = (self.limit)();
+        let now = Utc::now();
+        let (overflow, allowed) = {
+            let mut limiter = self.limiter();
+            let overflow = limiter.roll(now);
+            (overflow, limiter.admit(limit, now))
+        };
+
+        if let Some(suppressed) = overflow {
+            if let Err(e) = self.inner.overflow_summary(suppressed) {
+                tracing::warn!("Failed to send overflow summary: {}", e);
+            }
+        }
+        if allowed {
+            send()
+        } else {
+            tracing::debug!("Notification rate limit reached, suppressing");
+            Ok(())
+        }
+    }
+}
+
+impl Notifier for RateLimitingNotifier {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.limited(|| self.inner.stream_live(stream))
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        self.limited(|| self.inner.streams_live_summary(streams))
+    }
+
+    fn stream_reminder(&self, stream: &Stream) -> anyhow::Result<()> {
+        self.limited(|| self.inner.stream_reminder(stream))
+    }
+
+    fn category_changed(&self, stream: &Stream, old_category: &str) -> anyhow::Result<()> {
+        self.limited(|| self.inner.category_changed(stream, old_category))
+    }
+
+    fn title_changed(&self, stream: &Stream, old_title: &str) -> anyhow::Result<()> {
+        self.limited(|| self.inner.title_changed(stream, old_title))
+    }
+
+    fn stream_offline(&self, stream: &Stream, live_for: Duration) -> anyhow::Result<()> {
+        self.limited(|| self.inner.stream_offline(stream, live_for))
+    }
+
+    fn stream_hot(&self, stream: &Stream, info: &HotnessInfo) -> anyhow::Result<()> {
+        self.limited(|| self.inner.stream_hot(stream, info))
+    }
+
+    fn category_stream_alert(&self, stream: &Stream, threshold: u32) -> anyhow::Result<()> {
+        self.limited(|| self.inner.category_stream_alert(stream, threshold))
+    }
+
+    fn schedule_changed(
+        &self,
+        stream: &ScheduledStream,
+        change: ScheduleChange,
+    ) -> anyhow::Result<()> {
+        self.limited(|| self.inner.schedule_changed(stream, change))
+    }
+
+    fn error(&self, category: ErrorCategory, message: &str) -> anyhow::Result<()> {
+        if !self.limiter().admit_error(message, Utc::now()) {
+            tracing::debug!("Suppressed repeated error notification: {}", message);
+            return Ok(());
+        }
+        self.inner.error(category, message)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::notify::mock::{NotificationType, RecordingNotifier};
+    use crate::test_helpers::make_stream;
+
+    fn at(secs: i64) -> DateTime<Utc> {
+        DateTime::from_timestamp(1_700_000_000 + secs, 0).unwrap()
+    }
+
+    #[test]
+    fn admits_up_to_limit_per_window() {
+        let mut limiter = RateLimiter::default();
+
+        assert!(limiter.admit(2, at(0)));
+        assert!(limiter.admit(2, at(1)));
+        assert!(!limiter.admit(2, at(2)));
+        assert!(!limiter.admit(2, at(59)));
+        assert_eq!(limiter.roll(at(59)), None, "window still open");
+
+        assert_eq!(limiter.roll(at(60)), Some(2));
+        assert!(limiter.admit(2, at(60)));
+    }
+
+    #[test]
+    fn roll_reports_nothing_when_nothing_was_suppressed() {
+        let mut limiter = RateLimiter::default();
+        assert!(limiter.admit(2, at(0)));
+
+        assert_eq!(limiter.roll(at(61)), None);
+        assert_eq!(limiter.roll(at(200)), None);
+    }
+
+    #[test]
+    fn zero_limit_admits_everything() {
+        let mut limiter = RateLimiter::default();
+        for i in 0..100 {
+            assert!(limiter.admit(0, at(i)));
+        }
+        assert_eq!(limiter.roll(at(1000)), None);
+    }
+
+    #[test]
+    fn repeated_error_is_deduplicated_for_ten_minutes() {
+        let mut limiter = RateLimiter::default();
+
+        assert!(limiter.admit_error("Twitch is unreachable", at(0)));
+        assert!(!limiter.admit_error("Twitch is unreachable", at(599)));
+        assert!(limiter.admit_error("Login expired", at(599)));
+        assert!(limiter.admit_error("Twitch is unreachable", at(601)));
+    }
+
+    #[test]
+    fn errors_bypass_the_limit() {
+        let recorder = RecordingNotifier::new();
+        let notifier = RateLimitingNotifier::new(Arc::new(recorder.clone()), Box::new(|| 1));
+
+        notifier.stream_live(&make_stream("1", "Alice")).unwrap();
+        notifier.stream_live(&make_stream("2", "Bob")).unwrap();
+        notifier
+            .error(ErrorCategory::Api, "Twitch is unreachable")
+            .unwrap();
+        notifier
+            .error(ErrorCategory::Api, "Twitch is unreachable")
+            .unwrap();
+
+        assert_eq!(recorder.get_by_type(NotificationType::StreamLive).len(), 1);
+        assert_eq!(recorder.get_by_type(NotificationType::Error).len(), 1);
+    }
+
+    #[test]
+    fn concurrent_senders_never_exceed_limit() {
+        let recorder = RecordingNotifier::new();
+        let notifier = Arc::new(RateLimitingNotifier::new(
+            Arc::new(recorder.clone()),
+            Box::new(|| 5),
+        ));
+
+        let threads: Vec<_> = (0..8)
+            .map(|t| {
+                let notifier = notifier.clone();
+                std::thread::spawn(move || {
+                    for i in 0..50 {
+                        let id = format!("{t}-{i}");
+                        notifier.stream_live(&make_stream(&id, &id)).unwrap();
+                    }
+                })
+            })
+            .collect();
+        for thread in threads {
+            thread.join().unwrap();
+        }
+
+        assert_eq!(recorder.get_by_type(NotificationType::StreamLive).len(), 5);
+        let limiter = notifier.limiter();
+        assert_eq!(limiter.sent, 5);
+        assert_eq!(limiter.suppressed, 8 * 50 - 5);
+    }
+
+    #[test]
+    fn overflow_message_counts_events() {
+        assert_eq!(overflow_message(1), "...and 1 more event");
+        assert_eq!(overflow_message(7), "...and 7 more events");
+    }
+}
@@ -0,0 +1,189 @@
+//! Drops repeat "now live" notifications for streams that flap
+//!
+//! When a streamer's connection drops and recovers, the stream can go offline
+//! and come back as a new session within a few minutes. The state's grace
+//! period hides short gaps, but not longer ones, so [`RepeatLiveNotifier`]
+//! also remembers when each channel was last announced and skips a second
+//! announcement within the window unless the game changed.
+
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+
+use chrono::{DateTime, Duration, Utc};
+
+use super::Notifier;
+use crate::twitch::Stream;
+
+/// Returns the current repeat window
+pub type WindowLookup = Box<dyn Fn() -> Duration + Send + Sync>;
+
+/// When each channel was last announced live, and with which game
+#[derive(Debug, Default)]
+struct LiveAnnouncements {
+    /// user_login -> (announced at, game_id)
+    last: HashMap<String, (DateTime<Utc>, String)>,
+}
+
+impl LiveAnnouncements {
+    /// Returns whether `stream` should be announced, recording it if so
+    fn admit(&mut self, stream: &Stream, window: Duration, now: DateTime<Utc>) -> bool {
+        self.last.retain(|_, (at, _)| now - *at < window);
+        if let Some((_, game_id)) = self.last.get(&stream.user_login) {
+            if *game_id == stream.game_id {
+                return false;
+            }
+        }
+        self.last
+            .insert(stream.user_login.clone(), (now, stream.game_id.clone()));
+        true
+    }
+}
+
+/// Skips live notifications for channels already announced within the window,
+/// unless they are now playing a different game
+pub struct RepeatLiveNotifier {
+    inner: Arc<dyn Notifier>,
+    window: WindowLookup,
+    announced: Mutex<LiveAnnouncements>,
+}
+
+impl RepeatLiveNotifier {
+    pub fn new(inner: Arc<dyn Notifier>, window: WindowLookup) -> Self {
+        Self {
+            inner,
+            window,
+            announced: Mutex::new(LiveAnnouncements::default()),
+        }
+    }
+
+    fn admit(&self, stream: &Stream) -> bool {
+        let admitted = self
+            .announced
+            .lock()
+            .unwrap_or_else(std::sync::PoisonError::into_inner)
+            .admit(stream, (self.window)(), Utc::now());
+        if !admitted {
+            tracing::debug!(
+                "Suppressed repeat live notification for {}",
+                stream.user_login
+            );
+        }
+        admitted
+    }
+}
+
+impl Notifier for RepeatLiveNotifier {
+    fn inner(&self) -> Option<&dyn Notifier> {
+        Some(self.inner.as_ref())
+    }
+
+    fn stream_live(&self, stream: &Stream) -> anyhow::Result<()> {
+        if !self.admit(stream) {
+            return Ok(());
+        }
+        self.inner.stream_live(stream)
+    }
+
+    fn streams_live_summary(&self, streams: &[Stream]) -> anyhow::Result<()> {
+        let fresh: Vec<Stream> = streams.iter().filter(|s| self.admit(s)).cloned().collect();
+        match fresh.as_slice() {
+            [] => Ok(()),
+            [stream] => self.inner.stream_live(stream),
+            _ => self.inner.streams_live_summary(&fresh),
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::notify::mock::{NotificationType, RecordingNotifier};
+    use crate::test_helpers::{make_stream, make_stream_with_game};
+
+    fn at(mins: i64) -> DateTime<Utc> {
+        DateTime::from_timestamp(1_700_000_000, 0).unwrap() + Duration::minutes(mins)
+    }
+
+    const WINDOW_MIN: i64 = 15;
+
+    #[test]
+    fn repeat_within_window_is_suppressed() {
+        let mut announced = LiveAnnouncements::default();
+        let stream = make_stream("1", "Alice");
+        let window = Duration::minutes(WINDOW_MIN);
+
+        assert!(announced.admit(&stream, window, at(0)));
+        assert!(!announced.admit(&stream, window, at(2)));
+        assert!(
+            !announced.admit(&stream, window, at(WINDOW_MIN) - Duration::seconds(1)),
+            "last second of the window"
+        );
+    }
+
+    #[test]
+    fn repeat_at_window_end_is_announced() {
+        let mut announced = LiveAnnouncements::default();
+        let stream = make_stream("1", "Alice");
+        let window = Duration::minutes(WINDOW_MIN);
+
+        assert!(announced.admit(&stream, window, at(0)));
+        assert!(announced.admit(&stream, window, at(WINDOW_MIN)));
+    }
+
+    #[test]
+    fn suppressed_repeat_does_not_extend_window() {
+        let mut announced = LiveAnnouncements::default();
+        let stream = make_stream("1", "Alice");
+        let window = Duration::minutes(WINDOW_MIN);
+
+        assert!(announced.admit(&stream, window, at(0)));
+        assert!(!announced.admit(&stream, window, at(10)));
+        assert!(announced.admit(&stream, window, at(WINDOW_MIN)));
+    }
+
+    #[test]
+    fn game_change_is_announced_within_window() {
+        let mut announced = LiveAnnouncements::default();
+        let window = Duration::minutes(WINDOW_MIN);
+        let mut stream = make_stream_with_game("1", "g1", "Minecraft");
+
+        assert!(announced.admit(&stream, window, at(0)));
+        stream.game_id = "g2".to_string();
+        stream.game_name = "Factorio".to_string();
+        assert!(announced.admit(&stream, window, at(5)));
+        assert!(
+            !announced.admit(&stream, window, at(6)),
+            "the new game starts its own window"
+        );
+    }
+
+    #[test]
+    fn other_channels_are_unaffected() {
+        let mut announced = LiveAnnouncements::default();
+        let window = Duration::minutes(WINDOW_MIN);
+
+        assert!(announced.admit(&make_stream("1", "Alice"), window, at(0)));
+        assert!(announced.admit(&make_stream("2", "Bob"), window, at(1)));
+    }
+
+    #[test]
+    fn summary_drops_repeats_and_falls_back_to_single_notification() {
+        let recorder = RecordingNotifier::new();
+        let notifier = RepeatLiveNotifier::new(
+            Arc::new(recorder.clone()),
+            Box::new(|| Duration::minutes(WINDOW_MIN)),
+        );
+        let alice = make_stream("1", "Alice");
+        let bob = make_stream("2", "Bob");
+
+        notifier.stream_live(&alice).unwrap();
+        notifier.streams_live_summary(&[alice, bob]).unwrap();
+
+        let live = recorder.get_by_type(NotificationType::StreamLive);
+        assert_eq!(live.len(), 2);
+        assert_eq!(live[1].title, "Bob is now live!");
+        assert!(recorder
+            .get_by_type(NotificationType::StreamLiveSummary)
+            .is_empty());
+    }
+}
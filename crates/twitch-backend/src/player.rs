@@ -0,0 +1,168 @@
+//! Opening streams in an external player
+//!
+//! `external_player` is a command template such as `streamlink {url} best`.
+//! The template is split into arguments first and `{url}` / `{login}` are
+//! substituted into each argument afterwards, so a channel name can never add
+//! arguments of its own.
+
+use std::process::{Command, Stdio};
+
+use anyhow::Context;
+
+use crate::twitch::channel_url;
+
+/// Splits a command template into arguments.
+///
+/// Arguments are separated by whitespace. Single quotes keep everything
+/// inside them as is; double quotes do too, except that `\"` and `\\` are
+/// unescaped. Backslashes outside quotes are kept, so unquoted Windows paths
+/// work.
+pub fn split_args(template: &str) -> anyhow::Result<Vec<String>> {
+    let mut args = Vec::new();
+    let mut current = String::new();
+    // Distinguishes an empty quoted argument from no argument at all
+    let mut in_arg = false;
+    let mut chars = template.chars();
+
+    while let Some(c) = chars.next() {
+        match c {
+            '\'' => {
+                in_arg = true;
+                loop {
+                    match chars.next() {
+                        Some('\'') => break,
+                        Some(c) => current.push(c),
+                        None => anyhow::bail!("unclosed ' in external player command"),
+                    }
+                }
+            }
+            '"' => {
+                in_arg = true;
+                loop {
+                    match chars.next() {
+                        Some('"') => break,
+                        Some('\\') => match chars.next() {
+                            Some(c @ ('"' | '\\')) => current.push(c),
+                            Some(c) => {
+                                current.push('\\');
+                                current.push(c);
+                            }
+                            None => anyhow::bail!("unclosed \" in external player command"),
+                        },
+                        Some(c) => current.push(c),
+                        None => anyhow::bail!("unclosed \" in external player command"),
+                    }
+                }
+            }
+            c if c.is_whitespace() => {
+                if in_arg {
+                    args.push(std::mem::take(&mut current));
+                    in_arg = false;
+                }
+            }
+            c => {
+                in_arg = true;
+                current.push(c);
+            }
+        }
+    }
+    if in_arg {
+        args.push(current);
+    }
+    Ok(args)
+}
+
+/// Builds the command line for opening `user_login`'s stream
+pub fn player_command(template: &str, user_login: &str) -> anyhow::Result<Vec<String>> {
+    let url = channel_url(user_login);
+    let args: Vec<String> = split_args(template)?
+        .into_iter()
+        .map(|arg| arg.replace("{url}", &url).replace("{login}", user_login))
+        .collect();
+    anyhow::ensure!(!args.is_empty(), "external player command is empty");
+    Ok(args)
+}
+
+/// Starts the external player for `user_login`'s stream without waiting for
+/// it to exit
+pub fn open_in_player(template: &str, user_login: &str) -> anyhow::Result<()> {
+    let args = player_command(template, user_login)?;
+    let mut child = Command::new(&args[0])
+        .args(&args[1..])
+        .stdin(Stdio::null())
+        .stdout(Stdio::null())
+        .stderr(Stdio::null())
+        .spawn()
+        .with_context(|| format!("Couldn't start {}", args[0]))?;
+
+    // Reap the player when it exits so it doesn't linger as a zombie
+    std::thread::spawn(move || {
+        if let Err(e) = child.wait() {
+            tracing::warn!("Failed to wait for external player: {}", e);
+        }
+    });
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn substitutes_url_and_login() {
+        assert_eq!(
+            player_command("streamlink {url} best", "alice").unwrap(),
+            vec!["streamlink", "https://twitch.tv/alice", "best"]
+        );
+        assert_eq!(
+            player_command("mpv --title={login} https://twitch.tv/{login}", "bob").unwrap(),
+            vec!["mpv", "--title=bob", "https://twitch.tv/bob"]
+        );
+    }
+
+    #[test]
+    fn quotes_group_arguments() {
+        assert_eq!(
+            split_args(r#"streamlink --player "mpv --no-border" '{url}' best"#).unwrap(),
+            vec!["streamlink", "--player", "mpv --no-border", "{url}", "best"]
+        );
+        assert_eq!(
+            split_args(r#"echo "say \"hi\"" '' a"b"c"#).unwrap(),
+            vec!["echo", r#"say "hi""#, "", "abc"]
+        );
+    }
+
+    #[test]
+    fn backslashes_outside_quotes_are_kept() {
+        assert_eq!(
+            split_args(r"C:\mpv\mpv.exe   {url}").unwrap(),
+            vec![r"C:\mpv\mpv.exe", "{url}"]
+        );
+        assert_eq!(
+            split_args(r#""C:\Program Files\mpv\mpv.exe" {url}"#).unwrap(),
+            vec![r"C:\Program Files\mpv\mpv.exe", "{url}"]
+        );
+    }
+
+    #[test]
+    fn login_cannot_add_arguments() {
+        assert_eq!(
+            player_command("mpv {url}", "a b").unwrap(),
+            vec!["mpv", "https://twitch.tv/a b"]
+        );
+    }
+
+    #[test]
+    fn bad_templates_are_errors() {
+        assert!(split_args("mpv 'oops").is_err());
+        assert!(split_args("mpv \"oops").is_err());
+        assert!(player_command("   ", "alice").is_err());
+    }
+
+    #[test]
+    fn missing_program_fails_to_start() {
+        let err = open_in_player("/nonexistent/player {url}", "alice").unwrap_err();
+
+        assert!(err.to_string().contains("/nonexistent/player"));
+    }
+}
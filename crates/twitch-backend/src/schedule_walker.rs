@@ -1,45 +1,56 @@
 //! Schedule queue walker: checks one broadcaster's schedule per tick.
 //!
 //! Instead of bulk-fetching all channels at once, the walker picks the
-//! most-stale broadcaster every `schedule_check_interval_sec` seconds and
-//! fetches one at a time. This ensures all followed channels eventually get
-//! a fresh schedule, not just the first 50.
+//! few most-stale broadcasters every `schedule_check_interval_sec` seconds and
+//! fetches them concurrently, skipping channels that are live right now. This
+//! ensures all followed channels eventually get a fresh schedule, not just the
+//! first 50.
 
-use std::collections::HashMap;
+use std::collections::{HashMap, HashSet};
 use std::sync::Arc;
 
-use chrono::Utc;
+use chrono::{DateTime, Utc};
 use tokio::task::JoinHandle;
 use tokio::time::Duration;
 
 use crate::config::ConfigManager;
 use crate::db::Database;
+use crate::notify::{Notifier, ScheduleChange};
 use crate::session::SessionManager;
-use crate::state::AppState;
-use crate::twitch::{ScheduleData, ScheduleVacation, ScheduledStream, TwitchClient};
+use crate::state::{AppState, ScheduleDiff};
+use crate::twitch::http::{HttpClient, ReqwestClient};
+use crate::twitch::{ApiError, ScheduleData, ScheduleVacation, ScheduledStream, TwitchClient};
 
 /// Within this many seconds, an inferred schedule is considered a duplicate of an API schedule.
 const SCHEDULE_DEDUP_WINDOW_SECS: i64 = 3600;
 
+/// Broadcasters whose schedules are fetched concurrently per tick.
+const SCHEDULE_FETCH_CONCURRENCY: usize = 5;
+
+/// Start times closer together than this many minutes count as unchanged.
+const SCHEDULE_MOVE_EPSILON_MIN: i64 = 5;
+
 /// Owns the schedule-refresh queue walk.
 ///
-/// One broadcaster is checked per tick; results are stored in SQLite and read
-/// back via [`ScheduleWalker::refresh_schedules_from_db`].
-pub struct ScheduleWalker {
+/// A small batch of broadcasters is checked per tick; results are stored in
+/// SQLite and read back via [`ScheduleWalker::refresh_schedules_from_db`].
+pub struct ScheduleWalker<H: HttpClient = ReqwestClient> {
     db: Database,
-    client: TwitchClient,
+    client: TwitchClient<H>,
     state: Arc<AppState>,
     config: Arc<ConfigManager>,
-    session: SessionManager,
+    session: SessionManager<H>,
+    notifier: Arc<dyn Notifier>,
 }
 
-impl ScheduleWalker {
+impl<H: HttpClient + Clone + 'static> ScheduleWalker<H> {
     pub fn new(
         db: Database,
-        client: TwitchClient,
+        client: TwitchClient<H>,
         state: Arc<AppState>,
         config: Arc<ConfigManager>,
-        session: SessionManager,
+        session: SessionManager<H>,
+        notifier: Arc<dyn Notifier>,
     ) -> Self {
         Self {
             db,
@@ -47,36 +58,114 @@ impl ScheduleWalker {
             state,
             config,
             session,
+            notifier,
         }
     }
 
     /// Runs one iteration of the schedule queue: fetches the most-stale
-    /// broadcaster's schedule and stores the result in the DB.
+    /// broadcasters' schedules concurrently and stores the results in the DB.
     pub async fn tick(&self) -> anyhow::Result<()> {
         if !self.state.is_authenticated().await {
             return Ok(());
         }
 
-        let stale_threshold = (self.config.get().schedule_stale_hours * 3600) as i64;
-        let broadcaster = match self.db.get_next_stale_broadcaster(stale_threshold) {
-            Ok(Some(b)) => b,
-            Ok(None) => return Ok(()), // All are fresh
+        let cfg = self.config.get();
+        let stale_threshold = (cfg.schedule_stale_hours * 3600) as i64;
+
+        // Live channels are already in the live section and their next segment
+        // is hours away, so they wait in the queue until they go offline
+        let live: HashSet<i64> = self
+            .state
+            .get_followed_streams()
+            .await
+            .iter()
+            .filter_map(|s| s.user_id.parse().ok())
+            .collect();
+
+        let broadcasters = match self.db.get_next_stale_broadcasters(
+            stale_threshold,
+            SCHEDULE_FETCH_CONCURRENCY,
+            &live,
+        ) {
+            Ok(b) if b.is_empty() => return Ok(()), // All are fresh
+            Ok(b) => b,
             Err(e) => {
                 tracing::error!("Failed to query schedule queue: {}", e);
                 return Err(e);
             }
         };
 
-        let (bid, blogin, bname) = broadcaster;
-        let bid_str = bid.to_string();
-        tracing::debug!("Checking schedule for {} ({})", bname, bid);
+        // Channels that recently had no schedule are marked checked without
+        // asking again; most never set one up
+        self.client
+            .set_no_schedule_ttl(Duration::from_secs(cfg.no_schedule_skip_hours * 3600));
+        let (skipped, broadcasters): (Vec<_>, Vec<_>) = broadcasters
+            .into_iter()
+            .partition(|b| self.client.has_no_schedule(&b.0.to_string()));
+        if !skipped.is_empty() {
+            tracing::debug!("Skipped {} channel(s) with no schedule", skipped.len());
+            for (bid, blogin, _) in &skipped {
+                if let Err(e) = self.db.update_last_checked(*bid) {
+                    tracing::error!("Failed to update last_checked for {}: {}", blogin, e);
+                }
+            }
+        }
+        if broadcasters.is_empty() {
+            return Ok(());
+        }
 
-        match crate::twitch::with_retry(
-            || self.client.get_schedule(&bid_str),
-            || self.session.try_refresh_token(),
-        )
-        .await
+        let ids: Vec<String> = broadcasters.iter().map(|b| b.0.to_string()).collect();
+        tracing::debug!("Checking schedules for {} broadcaster(s)", ids.len());
+
+        // Stored segments must last until the broadcaster is next checked, and
+        // still fill the display window at that point
+        let start = Utc::now();
+        let until = start
+            + chrono::Duration::hours(
+                (cfg.schedule_stale_hours + cfg.schedule_lookahead_hours) as i64,
+            );
+
+        let mut results = self
+            .client
+            .get_schedules(&ids, start, until, SCHEDULE_FETCH_CONCURRENCY)
+            .await;
+
+        // One token refresh covers every request that hit an expired token
+        if results
+            .iter()
+            .any(|r| matches!(r, Err(ApiError::Unauthorized)))
         {
+            match self.session.try_refresh_token().await {
+                Ok(()) => {
+                    for (result, bid) in results.iter_mut().zip(&ids) {
+                        if matches!(result, Err(ApiError::Unauthorized)) {
+                            *result = self.client.get_schedule(bid, start, until).await;
+                        }
+                    }
+                }
+                Err(e) => tracing::warn!("Token refresh failed: {}", e),
+            }
+        }
+
+        let mut updated = false;
+        for ((bid, blogin, _), result) in broadcasters.iter().zip(results) {
+            updated |= self.store_schedule(*bid, blogin, result);
+        }
+        if updated {
+            self.refresh_schedules_from_db().await;
+        }
+
+        Ok(())
+    }
+
+    /// Stores one broadcaster's schedule fetch result, returning true if the DB changed.
+    fn store_schedule(
+        &self,
+        bid: i64,
+        blogin: &str,
+        result: Result<Option<ScheduleData>, ApiError>,
+    ) -> bool {
+        let segments = match result {
             Ok(Some(data)) => {
                 // Persist broadcaster timezone if the API returned one
                 if let Some(tz) = &data.broadcaster_timezone {
@@ -84,32 +173,24 @@ impl ScheduleWalker {
                         tracing::warn!("Failed to store timezone for {}: {}", blogin, e);
                     }
                 }
-                let segments = convert_schedule_segments(&data);
-                if let Err(e) = self.db.replace_future_schedules(bid, &segments) {
-                    tracing::error!("Failed to store schedules for {}: {}", blogin, e);
-                }
-                if let Err(e) = self.db.update_last_checked(bid) {
-                    tracing::error!("Failed to update last_checked for {}: {}", blogin, e);
-                }
-                self.refresh_schedules_from_db().await;
-            }
-            Ok(None) => {
-                // No schedule (404) — clear future entries for this broadcaster
-                if let Err(e) = self.db.replace_future_schedules(bid, &[]) {
-                    tracing::error!("Failed to clear schedules for {}: {}", blogin, e);
-                }
-                if let Err(e) = self.db.update_last_checked(bid) {
-                    tracing::error!("Failed to update last_checked for {}: {}", blogin, e);
-                }
-                self.refresh_schedules_from_db().await;
+                convert_schedule_segments(&data)
             }
+            // No schedule (404) — clear future entries for this broadcaster
+            Ok(None) => Vec::new(),
             Err(e) => {
                 // Don't update last_checked — will retry next cycle
                 tracing::warn!("Failed to fetch schedule for {}: {}", blogin, e);
+                return false;
             }
-        }
+        };
 
-        Ok(())
+        if let Err(e) = self.db.replace_future_schedules(bid, &segments) {
+            tracing::error!("Failed to store schedules for {}: {}", blogin, e);
+        }
+        if let Err(e) = self.db.update_last_checked(bid) {
+            tracing::error!("Failed to update last_checked for {}: {}", blogin, e);
+        }
+        true
     }
 
     /// Spawns the schedule walker polling loop.
@@ -134,7 +215,8 @@ impl ScheduleWalker {
     /// Both API and inferred schedules use the same display window:
     /// `[now - schedule_before_now_min, now + schedule_lookahead_hours]`.
     /// Deduplication removes inferred entries that overlap with an API schedule
-    /// for the same broadcaster within 60 minutes.
+    /// for the same broadcaster within 60 minutes. Cancelled and moved
+    /// segments are notified if `notify_on_schedule_change` is set.
     pub async fn refresh_schedules_from_db(&self) {
         let cfg = self.config.get();
         let now = Utc::now();
@@ -184,10 +266,83 @@ impl ScheduleWalker {
             }
         }
 
-        self.state.set_scheduled_streams(combined).await;
+        let diff = self.state.set_scheduled_streams(combined).await;
+        let changes = schedule_changes(
+            &diff,
+            Utc::now(),
+            |broadcaster_id| channel_lookup.contains_key(broadcaster_id),
+            |id| self.db.get_schedule_start(id),
+        );
+        for (stream, change) in &changes {
+            tracing::info!(
+                "Scheduled stream for {} changed: {:?}",
+                stream.broadcaster_login,
+                change
+            );
+            if !cfg.notify_on_schedule_change {
+                continue;
+            }
+            if let Err(e) = self.notifier.schedule_changed(stream, *change) {
+                tracing::error!("Notification error: {}", e);
+            }
+        }
     }
 }
 
+/// Picks the genuine cancellations and moves out of a schedule diff.
+///
+/// Segments drop out of the diff for reasons other than the streamer changing
+/// them, so only API segments that hadn't started yet, of channels still
+/// followed, count. A removed segment that is still stored (`stored_start`
+/// finds it) has moved out of the display window rather than been cancelled.
+/// Moves smaller than [`SCHEDULE_MOVE_EPSILON_MIN`] are ignored.
+fn schedule_changes(
+    diff: &ScheduleDiff,
+    now: DateTime<Utc>,
+    is_followed: impl Fn(&str) -> bool,
+    stored_start: impl Fn(&str) -> anyhow::Result<Option<DateTime<Utc>>>,
+) -> Vec<(ScheduledStream, ScheduleChange)> {
+    let moved = |from: DateTime<Utc>, to: DateTime<Utc>| {
+        (to - from).num_minutes().abs() >= SCHEDULE_MOVE_EPSILON_MIN
+    };
+    let counts = |s: &ScheduledStream| !s.is_inferred && is_followed(&s.broadcaster_id);
+
+    let removed_change = |s: &ScheduledStream| match stored_start(&s.id) {
+        Ok(None) => Some((s.clone(), ScheduleChange::Cancelled)),
+        Ok(Some(start)) if moved(s.start_time, start) => Some((
+            ScheduledStream {
+                start_time: start,
+                ..s.clone()
+            },
+            ScheduleChange::Moved { from: s.start_time },
+        )),
+        Ok(Some(_)) => None,
+        Err(e) => {
+            tracing::error!("Failed to look up segment {}: {}", s.id, e);
+            None
+        }
+    };
+
+    let removed = diff
+        .cancelled(now)
+        .filter(|s| counts(s))
+        .filter_map(removed_change);
+    let rescheduled = diff
+        .rescheduled
+        .iter()
+        .filter(|r| {
+            r.old_start > now && counts(&r.stream) && moved(r.old_start, r.stream.start_time)
+        })
+        .map(|r| {
+            (
+                r.stream.clone(),
+                ScheduleChange::Moved { from: r.old_start },
+            )
+        });
+
+    removed.chain(rescheduled).collect()
+}
+
 /// Returns true if the segment's time range overlaps with the vacation period.
 ///
 /// A segment with no `end_time` is treated as a point event at `start_time`.
@@ -198,7 +353,7 @@ fn overlaps_vacation(seg: &crate::twitch::ScheduleSegment, vacation: &ScheduleVa
 
 /// Converts raw API schedule segments into [`ScheduledStream`] structs.
 /// Skips canceled segments and segments that overlap with the broadcaster's vacation.
-/// Does NOT filter by time horizon (stores all future segments).
+/// Does NOT filter by time horizon; the request already covers the stored window.
 fn convert_schedule_segments(data: &ScheduleData) -> Vec<ScheduledStream> {
     let Some(segments) = &data.segments else {
         return Vec::new();
@@ -231,6 +386,8 @@ fn convert_schedule_segments(data: &ScheduleData) -> Vec<ScheduledStream> {
 #[cfg(test)]
 mod tests {
     use super::*;
+    use crate::state::Rescheduled;
+    use crate::test_helpers::make_scheduled;
     use crate::twitch::{ScheduleCategory, ScheduleSegment};
     use chrono::{Duration, TimeZone, Utc};
 
@@ -263,6 +420,97 @@ mod tests {
         }
     }
 
+    // === schedule_changes tests ===
+
+    fn diff_of(removed: Vec<ScheduledStream>, rescheduled: Vec<Rescheduled>) -> ScheduleDiff {
+        ScheduleDiff {
+            added: Vec::new(),
+            removed,
+            rescheduled,
+        }
+    }
+
+    fn changes(diff: &ScheduleDiff) -> Vec<(ScheduledStream, ScheduleChange)> {
+        schedule_changes(diff, Utc::now(), |_| true, |_| Ok(None))
+    }
+
+    #[test]
+    fn removed_future_segment_is_cancelled() {
+        let diff = diff_of(vec![make_scheduled("A", 2)], vec![]);
+
+        let changes = changes(&diff);
+        assert_eq!(changes.len(), 1);
+        assert_eq!(changes[0].1, ScheduleChange::Cancelled);
+    }
+
+    #[test]
+    fn segments_ageing_out_are_not_changes() {
+        let diff = diff_of(vec![make_scheduled("Started", -1)], vec![]);
+        assert!(changes(&diff).is_empty());
+    }
+
+    #[test]
+    fn inferred_and_unfollowed_segments_are_not_changes() {
+        let mut inferred = make_scheduled("Inferred", 2);
+        inferred.is_inferred = true;
+        let diff = diff_of(vec![inferred, make_scheduled("Unfollowed", 2)], vec![]);
+
+        let changes = schedule_changes(&diff, Utc::now(), |id| id != "unfollowed", |_| Ok(None));
+        assert!(changes.is_empty());
+    }
+
+    #[test]
+    fn removed_segment_still_stored_has_moved() {
+        let old = make_scheduled("A", 2);
+        let new_start = old.start_time + Duration::hours(30);
+        let diff = diff_of(vec![old.clone()], vec![]);
+
+        let changes = schedule_changes(&diff, Utc::now(), |_| true, |_| Ok(Some(new_start)));
+        assert_eq!(changes.len(), 1);
+        assert_eq!(changes[0].0.start_time, new_start);
+        assert_eq!(
+            changes[0].1,
+            ScheduleChange::Moved {
+                from: old.start_time
+            }
+        );
+    }
+
+    #[test]
+    fn removed_segment_stored_at_same_time_is_not_a_change() {
+        // e.g. the lookahead window was shortened
+        let old = make_scheduled("A", 2);
+        let start = old.start_time;
+        let diff = diff_of(vec![old], vec![]);
+
+        assert!(schedule_changes(&diff, Utc::now(), |_| true, |_| Ok(Some(start))).is_empty());
+    }
+
+    #[test]
+    fn rescheduled_segment_moves_beyond_epsilon() {
+        let stream = make_scheduled("A", 3);
+        let small = Rescheduled {
+            stream: stream.clone(),
+            old_start: stream.start_time - Duration::minutes(SCHEDULE_MOVE_EPSILON_MIN - 1),
+        };
+        let big = Rescheduled {
+            stream: stream.clone(),
+            old_start: stream.start_time - Duration::hours(1),
+        };
+
+        assert!(changes(&diff_of(vec![], vec![small])).is_empty());
+        let changes = changes(&diff_of(vec![], vec![big.clone()]));
+        assert_eq!(changes.len(), 1);
+        assert_eq!(
+            changes[0].1,
+            ScheduleChange::Moved {
+                from: big.old_start
+            }
+        );
+    }
+
+    // === convert_schedule_segments tests ===
+
     #[test]
     fn canceled_segments_filtered_out() {
         let start = Utc::now() + Duration::hours(1);
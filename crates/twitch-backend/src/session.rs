@@ -12,13 +12,14 @@ use tokio::sync::{watch, Mutex, RwLock};
 use crate::auth::{DeviceFlow, Token, TokenStore, CLIENT_ID};
 use crate::db::Database;
 use crate::handle::LoginProgress;
-use crate::state::AppState;
+use crate::state::{AppState, FollowDiff};
+use crate::twitch::http::{HttpClient, ReqwestClient};
 use crate::twitch::TwitchClient;
 
 /// Manages the auth lifecycle: session restore, login, logout, and token refresh.
-pub struct SessionManager {
+pub struct SessionManager<H: HttpClient = ReqwestClient> {
     pub(crate) store: TokenStore,
-    pub(crate) client: TwitchClient,
+    pub(crate) client: TwitchClient<H>,
     pub(crate) state: Arc<AppState>,
     pub(crate) db: Database,
     /// Serializes token refresh so only one task refreshes at a time.
@@ -33,13 +34,13 @@ pub struct SessionManager {
     pub(crate) login_progress_tx: watch::Sender<Option<LoginProgress>>,
 }
 
-impl SessionManager {
+impl<H: HttpClient> SessionManager<H> {
     /// Creates a new `SessionManager` and returns it together with the receiver
     /// end of the login-progress watch channel (for callers that need to observe
     /// the device code flow state).
     pub fn new(
         store: TokenStore,
-        client: TwitchClient,
+        client: TwitchClient<H>,
         state: Arc<AppState>,
         db: Database,
         initial_load_done: Arc<AtomicBool>,
@@ -114,7 +115,9 @@ impl SessionManager {
     }
 
     /// Fetches all followed channels from the API and syncs them to the DB.
-    pub async fn load_followed_channels(&self) -> anyhow::Result<()> {
+    ///
+    /// Returns the channels followed or unfollowed since the previous load.
+    pub async fn load_followed_channels(&self) -> anyhow::Result<FollowDiff> {
         let follows = crate::twitch::with_retry(
             || self.client.get_all_followed_channels(),
             || self.try_refresh_token(),
@@ -126,8 +129,7 @@ impl SessionManager {
         let ids = self.db.get_followed_ids()?;
         self.db.ensure_schedule_queue_entries(&ids)?;
 
-        self.state.set_followed_channels(follows).await;
-        Ok(())
+        Ok(self.state.set_followed_channels(follows).await)
     }
 
     /// Attempts to refresh the OAuth token.
@@ -256,7 +258,7 @@ where
     }
 }
 
-impl Clone for SessionManager {
+impl<H: HttpClient + Clone> Clone for SessionManager<H> {
     fn clone(&self) -> Self {
         Self {
             store: TokenStore::new().expect("Failed to create token store"),
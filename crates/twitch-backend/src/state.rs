@@ -1,8 +1,101 @@
-use std::collections::{HashMap, HashSet};
+use chrono::{DateTime, Duration, Utc};
+use serde::Serialize;
+use std::collections::{BTreeMap, HashMap, HashSet, VecDeque};
+use std::sync::atomic::{AtomicBool, Ordering};
 use std::sync::Arc;
-use tokio::sync::{broadcast, watch, RwLock};
+use tokio::sync::{broadcast, RwLock};
 
-use crate::twitch::{FollowedChannel, ScheduledStream, Stream};
+use crate::twitch::{ApiMetrics, FollowedChannel, ScheduledStream, Stream};
+
+/// Number of changes buffered for each ordered-change receiver before it lags.
+const ORDERED_CHANGE_CAPACITY: usize = 64;
+
+/// Maximum viewer-count samples kept per live stream.
+const TREND_SAMPLE_CAPACITY: usize = 10;
+
+/// Number of most recent samples considered when computing a trend.
+const TREND_WINDOW: usize = 3;
+
+/// Relative change in viewers (across the trend window) needed to count as rising/falling.
+const TREND_THRESHOLD: f64 = 0.05;
+
+/// Direction a live stream's viewer count is moving in
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize)]
+#[serde(rename_all = "snake_case")]
+pub enum ViewerTrend {
+    Rising,
+    Falling,
+    Steady,
+}
+
+/// Computes the trend from (timestamp, viewers) samples, oldest first.
+///
+/// Returns `None` when there are fewer than two samples.
+pub fn compute_viewer_trend(samples: &VecDeque<(DateTime<Utc>, u32)>) -> Option<ViewerTrend> {
+    if samples.len() < 2 {
+        return None;
+    }
+    let window_start = samples.len().saturating_sub(TREND_WINDOW);
+    let first = f64::from(samples[window_start].1);
+    let last = f64::from(samples[samples.len() - 1].1);
+
+    let change = (last - first) / first.max(1.0);
+    Some(if change >= TREND_THRESHOLD {
+        ViewerTrend::Rising
+    } else if change <= -TREND_THRESHOLD {
+        ViewerTrend::Falling
+    } else {
+        ViewerTrend::Steady
+    })
+}
+
+/// Ordering for live stream lists
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum SortOrder {
+    /// Most viewers first
+    #[default]
+    Viewers,
+    /// Display name, case-insensitive
+    Name,
+    /// Longest-running stream first
+    Uptime,
+    /// Most recently started stream first
+    RecentlyStarted,
+}
+
+/// Sorts streams in place by `order`, with streams whose login is in
+/// `favourites` moved to the front. The sort is stable, so ties keep their
+/// input order.
+pub fn sort_streams(streams: &mut [Stream], order: SortOrder, favourites: &HashSet<String>) {
+    match order {
+        SortOrder::Viewers => streams.sort_by(|a, b| b.viewer_count.cmp(&a.viewer_count)),
+        SortOrder::Name => streams.sort_by_cached_key(|s| s.user_name.to_lowercase()),
+        SortOrder::Uptime => streams.sort_by_key(|s| s.started_at),
+        SortOrder::RecentlyStarted => streams.sort_by(|a, b| b.started_at.cmp(&a.started_at)),
+    }
+    if !favourites.is_empty() {
+        streams.sort_by_key(|s| !favourites.contains(&s.user_login));
+    }
+}
+
+/// Vectors at least this large are shrunk once less than half their capacity is used.
+const COMPACT_MIN_CAPACITY: usize = 64;
+
+/// Releases spare capacity of a vector after a large shrink
+fn compact_vec<T>(v: &mut Vec<T>) {
+    if v.capacity() >= COMPACT_MIN_CAPACITY && v.len() * 2 < v.capacity() {
+        v.shrink_to_fit();
+    }
+}
+
+/// Clears fields of a stream that nothing renders (thumbnail URL and tags)
+fn strip_stream_details(stream: &mut Stream) {
+    stream.thumbnail_url = String::new();
+    stream.tags = Vec::new();
+}
+
+/// Minutes a stream may be missing from polls before it is treated as offline.
+const OFFLINE_GRACE_MIN: i64 = 5;
 
 /// Type of state change
 #[derive(Debug, Clone, Copy, PartialEq, Eq)]
@@ -10,6 +103,7 @@ pub enum ChangeType {
     FollowedStreams,
     ScheduledStreams,
     CategoryStreams,
+    FollowedChannels,
     Authentication,
 }
 
@@ -20,12 +114,102 @@ pub struct CategoryChange {
     pub old_category: String,
 }
 
+/// A title change within a single stream session
+#[derive(Debug, Clone)]
+pub struct TitleChange {
+    pub stream: Stream,
+    pub old_title: String,
+}
+
+/// A stream that went offline, detected once its offline grace period passed
+#[derive(Debug, Clone)]
+pub struct StreamOffline {
+    /// The stream as it was last seen live
+    pub stream: Stream,
+    pub last_seen_at: DateTime<Utc>,
+}
+
+impl StreamOffline {
+    /// How long the stream ran, from its start to when it was last seen live
+    pub fn live_for(&self) -> Duration {
+        self.last_seen_at - self.stream.started_at
+    }
+}
+
 /// Event sent when followed streams are updated
 #[derive(Debug, Clone)]
 pub struct StreamsUpdated {
     pub streams: Vec<Stream>,
     pub newly_live: Vec<Stream>,
     pub category_changes: Vec<CategoryChange>,
+    pub title_changes: Vec<TitleChange>,
+    pub went_offline: Vec<StreamOffline>,
+}
+
+/// A segment present in both sets of scheduled streams whose start time changed
+#[derive(Debug, Clone)]
+pub struct Rescheduled {
+    /// The segment as it is now
+    pub stream: ScheduledStream,
+    pub old_start: DateTime<Utc>,
+}
+
+/// Difference between two successive sets of scheduled streams, keyed by segment ID
+#[derive(Debug, Clone, Default)]
+pub struct ScheduleDiff {
+    pub added: Vec<ScheduledStream>,
+    pub removed: Vec<ScheduledStream>,
+    pub rescheduled: Vec<Rescheduled>,
+}
+
+impl ScheduleDiff {
+    /// Returns true if no segments were added, removed or rescheduled
+    pub fn is_empty(&self) -> bool {
+        self.added.is_empty() && self.removed.is_empty() && self.rescheduled.is_empty()
+    }
+
+    /// Returns removed segments that had not started yet (i.e. likely cancellations
+    /// rather than segments that simply aged out of the window)
+    pub fn cancelled(&self, now: DateTime<Utc>) -> impl Iterator<Item = &ScheduledStream> {
+        self.removed.iter().filter(move |s| s.start_time > now)
+    }
+}
+
+/// Difference between two successive follow lists, keyed by broadcaster ID
+#[derive(Debug, Clone, Default)]
+pub struct FollowDiff {
+    pub added: Vec<FollowedChannel>,
+    pub removed: Vec<FollowedChannel>,
+}
+
+impl FollowDiff {
+    /// Returns true if no channels were followed or unfollowed
+    pub fn is_empty(&self) -> bool {
+        self.added.is_empty() && self.removed.is_empty()
+    }
+}
+
+/// Exportable point-in-time view of [`AppState`] for debugging.
+///
+/// Contains no credentials; only what the menu is built from.
+#[derive(Debug, Clone, Serialize)]
+pub struct StateSnapshot {
+    pub taken_at: DateTime<Utc>,
+    pub authenticated: bool,
+    pub user_id: String,
+    pub user_login: String,
+    pub followed_streams: Vec<Stream>,
+    pub followed_streams_updated_at: Option<DateTime<Utc>>,
+    pub scheduled_streams: Vec<ScheduledStream>,
+    pub scheduled_streams_updated_at: Option<DateTime<Utc>>,
+    pub schedules_loaded: bool,
+    pub followed_channels: Vec<FollowedChannel>,
+    pub tracked_categories: BTreeMap<String, String>,
+    pub category_streams: BTreeMap<String, Vec<Stream>>,
+    pub category_streams_updated_at: Option<DateTime<Utc>>,
+    /// Twitch API request counters; filled in by the caller, which owns the client
+    #[serde(skip_serializing_if = "ApiMetrics::is_empty")]
+    pub api_metrics: ApiMetrics,
 }
 
 /// Application state
@@ -50,32 +234,46 @@ struct StateInner {
 
     // Streams by followed category (category_id -> streams)
     category_streams: HashMap<String, Vec<Stream>>,
+
+    // Logins of favourite streamers, sorted first by get_followed_streams_sorted
+    favourites: HashSet<String>,
+
+    // When each broadcaster was last seen live (user_id -> time)
+    last_seen: HashMap<String, DateTime<Utc>>,
+
+    // Each broadcaster's stream as last seen live, for offline events (user_id -> stream)
+    last_live: HashMap<String, Stream>,
+
+    // Stream sessions already reported as newly live (stream id -> user_id)
+    announced: HashMap<String, String>,
+
+    // When we first noticed each currently-live stream (user_id -> time)
+    first_seen: HashMap<String, DateTime<Utc>>,
+
+    // Earliest known start time per stream session (stream id -> start)
+    known_starts: HashMap<String, DateTime<Utc>>,
+
+    // Recent (timestamp, viewer_count) samples per live stream (user_id -> samples)
+    viewer_samples: HashMap<String, VecDeque<(DateTime<Utc>, u32)>>,
+
+    // When each data section was last written
+    followed_streams_updated_at: Option<DateTime<Utc>>,
+    scheduled_streams_updated_at: Option<DateTime<Utc>>,
+    category_streams_updated_at: Option<DateTime<Utc>>,
 }
 
 /// Thread-safe application state manager
 pub struct AppState {
     inner: RwLock<StateInner>,
-    change_tx: watch::Sender<Option<ChangeType>>,
-    change_rx: watch::Receiver<Option<ChangeType>>,
     streams_tx: broadcast::Sender<StreamsUpdated>,
+    ordered_tx: std::sync::Mutex<Option<broadcast::Sender<ChangeType>>>,
+    keep_stream_details: AtomicBool,
 }
 
 impl AppState {
     /// Creates a new state manager
     pub fn new() -> Arc<Self> {
-        let (change_tx, change_rx) = watch::channel(None);
-        let (streams_tx, _) = broadcast::channel(16);
-        Arc::new(Self {
-            inner: RwLock::new(StateInner::default()),
-            change_tx,
-            change_rx,
-            streams_tx,
-        })
-    }
-
-    /// Returns a receiver for state change notifications
-    pub fn subscribe(&self) -> watch::Receiver<Option<ChangeType>> {
-        self.change_rx.clone()
+        Arc::new(Self::default())
     }
 
     /// Returns a receiver for stream update events
@@ -83,8 +281,29 @@ impl AppState {
         self.streams_tx.subscribe()
     }
 
+    /// Returns a receiver that sees every state change, in commit order.
+    ///
+    /// Sending never blocks, so a slow receiver cannot stall setters; it lags
+    /// instead. After [`close`](Self::close) the returned receiver is already
+    /// closed.
+    pub fn subscribe_changes(&self) -> broadcast::Receiver<ChangeType> {
+        match self.ordered_tx.lock().unwrap().as_ref() {
+            Some(tx) => tx.subscribe(),
+            None => broadcast::channel(1).1,
+        }
+    }
+
+    /// Stops ordered change delivery. Receivers drain pending changes, then see `Closed`.
+    pub fn close(&self) {
+        self.ordered_tx.lock().unwrap().take();
+    }
+
+    /// Publishes a change. Callers hold the state write lock so that delivery
+    /// order matches commit order.
     fn notify_change(&self, change_type: ChangeType) {
-        let _ = self.change_tx.send(Some(change_type));
+        if let Some(tx) = self.ordered_tx.lock().unwrap().as_ref() {
+            let _ = tx.send(change_type);
+        }
     }
 
     /// Sets the authentication state
@@ -99,11 +318,10 @@ impl AppState {
         state.authenticated = authenticated;
         state.user_id = user_id;
         state.user_login = user_login;
-        drop(state);
-
         if changed {
             self.notify_change(ChangeType::Authentication);
         }
+        drop(state);
     }
 
     /// Returns whether the user is authenticated
@@ -111,23 +329,92 @@ impl AppState {
         self.inner.read().await.authenticated
     }
 
+    /// Returns the login of the signed-in user
+    pub async fn user_login(&self) -> String {
+        self.inner.read().await.user_login.clone()
+    }
+
     /// Updates the followed live streams and broadcasts changes
-    pub async fn set_followed_streams(&self, streams: Vec<Stream>) {
+    pub async fn set_followed_streams(&self, mut streams: Vec<Stream>) {
+        if !self.keep_stream_details() {
+            streams.iter_mut().for_each(strip_stream_details);
+        }
+
         let mut state = self.inner.write().await;
+        let now = Utc::now();
+
+        // Keep the earliest start time seen for each session so uptime never
+        // jumps backwards when a later poll reports a slightly later start
+        for stream in &mut streams {
+            let known = state
+                .known_starts
+                .entry(stream.id.clone())
+                .or_insert(stream.started_at);
+            *known = (*known).min(stream.started_at);
+            stream.started_at = *known;
+        }
+        let live_stream_ids: HashSet<&str> = streams.iter().map(|s| s.id.as_str()).collect();
+        state
+            .known_starts
+            .retain(|id, _| live_stream_ids.contains(id.as_str()));
 
-        // Build set for comparison
-        let old_by_id: HashSet<_> = state
-            .followed_streams
-            .iter()
-            .map(|s| s.user_id.clone())
-            .collect();
+        // Refresh when each broadcaster was last seen live. Streams missing from a
+        // poll keep their session bookkeeping for a grace period, so a stream that
+        // flaps out of one poll isn't treated as going offline and back online.
+        for stream in &streams {
+            state.last_seen.insert(stream.user_id.clone(), now);
+        }
+        let mut offline_since = Vec::new();
+        state.last_seen.retain(|user_id, seen| {
+            let recent = now - *seen < Duration::minutes(OFFLINE_GRACE_MIN);
+            if !recent {
+                offline_since.push((user_id.clone(), *seen));
+            }
+            recent
+        });
+        let recent: HashSet<String> = state.last_seen.keys().cloned().collect();
+
+        let mut went_offline = Vec::new();
+        for (user_id, last_seen_at) in offline_since {
+            if let Some(stream) = state.last_live.remove(&user_id) {
+                went_offline.push(StreamOffline {
+                    stream,
+                    last_seen_at,
+                });
+            }
+        }
+
+        // Title changes are only reported within a session; a new session
+        // starting with a different title is not a change
+        let mut title_changes = Vec::new();
+        for stream in &streams {
+            let previous = state
+                .last_live
+                .insert(stream.user_id.clone(), stream.clone());
+            if let Some(previous) = previous {
+                if previous.id == stream.id && previous.title != stream.title {
+                    title_changes.push(TitleChange {
+                        stream: stream.clone(),
+                        old_title: previous.title,
+                    });
+                }
+            }
+        }
 
-        // Find newly live streams
+        // Newly live means a stream session (stream ID) we haven't reported before
         let newly_live: Vec<_> = streams
             .iter()
-            .filter(|s| !old_by_id.contains(&s.user_id))
+            .filter(|s| !state.announced.contains_key(&s.id))
             .cloned()
             .collect();
+        state
+            .announced
+            .retain(|_, user_id| recent.contains(user_id));
+        for stream in &streams {
+            state
+                .announced
+                .insert(stream.id.clone(), stream.user_id.clone());
+        }
 
         // Find category changes for streams that were already live
         let mut category_changes = Vec::new();
@@ -145,7 +432,7 @@ impl AppState {
 
         // Update tracked categories based on current live streams
         state.tracked_categories.clear();
-        state.stream_games.clear();
+        state.stream_games.retain(|id, _| recent.contains(id));
         for stream in &streams {
             if !stream.game_id.is_empty() {
                 state
@@ -158,26 +445,126 @@ impl AppState {
             );
         }
 
-        state.followed_streams.clone_from(&streams);
-        drop(state);
+        // Remember when each stream was first noticed live (not Twitch's started_at,
+        // which differs for reruns and restarts)
+        state.first_seen.retain(|id, _| recent.contains(id));
+        for stream in &streams {
+            state
+                .first_seen
+                .entry(stream.user_id.clone())
+                .or_insert(now);
+        }
+
+        // Record viewer samples, dropping history for streams that went offline
+        state.viewer_samples.retain(|id, _| recent.contains(id));
+        for stream in &streams {
+            let samples = state
+                .viewer_samples
+                .entry(stream.user_id.clone())
+                .or_default();
+            if samples.len() == TREND_SAMPLE_CAPACITY {
+                samples.pop_front();
+            }
+            samples.push_back((now, stream.viewer_count));
+        }
 
+        state.followed_streams.clone_from(&streams);
+        compact_vec(&mut state.followed_streams);
+        state.followed_streams_updated_at = Some(now);
         self.notify_change(ChangeType::FollowedStreams);
+        drop(state);
 
         // Broadcast the event (ignore error if no receivers)
         let _ = self.streams_tx.send(StreamsUpdated {
             streams,
             newly_live,
             category_changes,
+            title_changes,
+            went_offline,
         });
     }
 
+    /// Sets whether stream thumbnail URLs and tags are kept when streams are stored
+    pub fn set_keep_stream_details(&self, keep: bool) {
+        self.keep_stream_details.store(keep, Ordering::Relaxed);
+    }
+
+    fn keep_stream_details(&self) -> bool {
+        self.keep_stream_details.load(Ordering::Relaxed)
+    }
+
+    /// Releases spare capacity held by stored collections.
+    ///
+    /// Collections can keep large backing allocations after the data shrinks
+    /// (e.g. after an evening with many live streams); this returns them.
+    pub async fn compact(&self) {
+        let mut state = self.inner.write().await;
+        state.followed_streams.shrink_to_fit();
+        state.scheduled_streams.shrink_to_fit();
+        state.followed_channels.shrink_to_fit();
+        state.category_streams.shrink_to_fit();
+        for streams in state.category_streams.values_mut() {
+            streams.shrink_to_fit();
+        }
+        state.tracked_categories.shrink_to_fit();
+        state.stream_games.shrink_to_fit();
+        state.last_live.shrink_to_fit();
+        state.first_seen.shrink_to_fit();
+        state.known_starts.shrink_to_fit();
+        state.viewer_samples.shrink_to_fit();
+    }
+
+    /// Returns the followed live streams sorted by `order`, favourites first
+    pub async fn get_followed_streams_sorted(&self, order: SortOrder) -> Vec<Stream> {
+        let state = self.inner.read().await;
+        let mut streams = state.followed_streams.clone();
+        sort_streams(&mut streams, order, &state.favourites);
+        streams
+    }
+
+    /// Registers the logins of favourite streamers used by sorted accessors
+    pub async fn set_favourites(&self, logins: HashSet<String>) {
+        self.inner.write().await.favourites = logins;
+    }
+
     /// Returns the current followed live streams
     pub async fn get_followed_streams(&self) -> Vec<Stream> {
         self.inner.read().await.followed_streams.clone()
     }
 
-    /// Updates the scheduled streams (skips rebuild if data unchanged)
-    pub async fn set_scheduled_streams(&self, streams: Vec<ScheduledStream>) {
+    /// Returns the viewer trend for a live stream, or `None` if it isn't live
+    /// or hasn't been sampled enough yet
+    pub async fn trend(&self, user_id: &str) -> Option<ViewerTrend> {
+        self.inner
+            .read()
+            .await
+            .viewer_samples
+            .get(user_id)
+            .and_then(compute_viewer_trend)
+    }
+
+    /// Returns when each live stream was first noticed, keyed by user ID.
+    /// The display marks streams seen within its "new" window.
+    pub async fn first_seen_times(&self) -> HashMap<String, DateTime<Utc>> {
+        self.inner.read().await.first_seen.clone()
+    }
+
+    /// Returns viewer trends for all live streams with enough samples
+    pub async fn viewer_trends(&self) -> HashMap<String, ViewerTrend> {
+        self.inner
+            .read()
+            .await
+            .viewer_samples
+            .iter()
+            .filter_map(|(id, samples)| Some((id.clone(), compute_viewer_trend(samples)?)))
+            .collect()
+    }
+
+    /// Updates the scheduled streams (skips rebuild if data unchanged).
+    ///
+    /// Returns the segments that were added, removed and rescheduled compared
+    /// to the previous set, matched by segment ID.
+    pub async fn set_scheduled_streams(&self, streams: Vec<ScheduledStream>) -> ScheduleDiff {
         let mut state = self.inner.write().await;
 
         // Only trigger a menu rebuild if the data actually changed.
@@ -190,13 +577,46 @@ impl AppState {
                 .zip(streams.iter())
                 .any(|(old, new)| old.id != new.id || old.start_time != new.start_time);
 
+        let old_starts: HashMap<&str, DateTime<Utc>> = state
+            .scheduled_streams
+            .iter()
+            .map(|s| (s.id.as_str(), s.start_time))
+            .collect();
+        let new_ids: HashSet<&str> = streams.iter().map(|s| s.id.as_str()).collect();
+
+        let diff = ScheduleDiff {
+            added: streams
+                .iter()
+                .filter(|s| !old_starts.contains_key(s.id.as_str()))
+                .cloned()
+                .collect(),
+            removed: state
+                .scheduled_streams
+                .iter()
+                .filter(|s| !new_ids.contains(s.id.as_str()))
+                .cloned()
+                .collect(),
+            rescheduled: streams
+                .iter()
+                .filter_map(|s| {
+                    let old_start = *old_starts.get(s.id.as_str())?;
+                    (old_start != s.start_time).then(|| Rescheduled {
+                        stream: s.clone(),
+                        old_start,
+                    })
+                })
+                .collect(),
+        };
+
         state.scheduled_streams = streams;
         state.schedules_loaded = true;
-        drop(state);
-
+        state.scheduled_streams_updated_at = Some(Utc::now());
         if changed {
             self.notify_change(ChangeType::ScheduledStreams);
         }
+        drop(state);
+
+        diff
     }
 
     /// Returns whether schedules have been fetched at least once
@@ -209,10 +629,39 @@ impl AppState {
         self.inner.read().await.scheduled_streams.clone()
     }
 
-    /// Sets the list of followed channels
-    pub async fn set_followed_channels(&self, channels: Vec<FollowedChannel>) {
+    /// Sets the list of followed channels, returning which channels were
+    /// followed or unfollowed since the previous list
+    ///
+    /// Notifies [`ChangeType::FollowedChannels`] only if the set of broadcasters
+    /// changed; ordering is ignored.
+    pub async fn set_followed_channels(&self, channels: Vec<FollowedChannel>) -> FollowDiff {
         let mut state = self.inner.write().await;
+        let old_ids: HashSet<&str> = state
+            .followed_channels
+            .iter()
+            .map(|c| c.broadcaster_id.as_str())
+            .collect();
+        let new_ids: HashSet<&str> = channels.iter().map(|c| c.broadcaster_id.as_str()).collect();
+
+        let diff = FollowDiff {
+            added: channels
+                .iter()
+                .filter(|c| !old_ids.contains(c.broadcaster_id.as_str()))
+                .cloned()
+                .collect(),
+            removed: state
+                .followed_channels
+                .iter()
+                .filter(|c| !new_ids.contains(c.broadcaster_id.as_str()))
+                .cloned()
+                .collect(),
+        };
+
         state.followed_channels = channels;
+        if !diff.is_empty() {
+            self.notify_change(ChangeType::FollowedChannels);
+        }
+        diff
     }
 
     /// Returns the list of followed channels
@@ -221,11 +670,14 @@ impl AppState {
     }
 
     /// Updates streams for a specific category
-    pub async fn set_category_streams(&self, category_id: String, streams: Vec<Stream>) {
+    pub async fn set_category_streams(&self, category_id: String, mut streams: Vec<Stream>) {
+        if !self.keep_stream_details() {
+            streams.iter_mut().for_each(strip_stream_details);
+        }
+
         let mut state = self.inner.write().await;
         state.category_streams.insert(category_id, streams);
-        drop(state);
-
+        state.category_streams_updated_at = Some(Utc::now());
         self.notify_change(ChangeType::CategoryStreams);
     }
 
@@ -234,25 +686,76 @@ impl AppState {
         self.inner.read().await.category_streams.clone()
     }
 
+    /// Drops the streams of categories that are no longer listed
+    pub async fn retain_category_streams(&self, category_ids: &HashSet<String>) {
+        let mut state = self.inner.write().await;
+        let before = state.category_streams.len();
+        state
+            .category_streams
+            .retain(|id, _| category_ids.contains(id));
+        if state.category_streams.len() != before {
+            self.notify_change(ChangeType::CategoryStreams);
+        }
+    }
+
+    /// Returns the games followed streamers are playing, keyed by game ID
+    pub async fn tracked_categories(&self) -> HashMap<String, String> {
+        self.inner.read().await.tracked_categories.clone()
+    }
+
+    /// Returns a serializable copy of the current state
+    pub async fn snapshot(&self) -> StateSnapshot {
+        let state = self.inner.read().await;
+        StateSnapshot {
+            taken_at: Utc::now(),
+            authenticated: state.authenticated,
+            user_id: state.user_id.clone(),
+            user_login: state.user_login.clone(),
+            followed_streams: state.followed_streams.clone(),
+            followed_streams_updated_at: state.followed_streams_updated_at,
+            scheduled_streams: state.scheduled_streams.clone(),
+            scheduled_streams_updated_at: state.scheduled_streams_updated_at,
+            schedules_loaded: state.schedules_loaded,
+            followed_channels: state.followed_channels.clone(),
+            tracked_categories: state
+                .tracked_categories
+                .iter()
+                .map(|(k, v)| (k.clone(), v.clone()))
+                .collect(),
+            category_streams: state
+                .category_streams
+                .iter()
+                .map(|(k, v)| (k.clone(), v.clone()))
+                .collect(),
+            category_streams_updated_at: state.category_streams_updated_at,
+            api_metrics: ApiMetrics::new(),
+        }
+    }
+
     /// Clears all state (used on logout)
+    ///
+    /// Emits [`ChangeType::Authentication`] followed by a change for every data
+    /// section, so subscribers that only watch a section also reset.
     pub async fn clear(&self) {
         let mut state = self.inner.write().await;
         *state = StateInner::default();
-        drop(state);
-
         self.notify_change(ChangeType::Authentication);
+        self.notify_change(ChangeType::FollowedStreams);
+        self.notify_change(ChangeType::ScheduledStreams);
+        self.notify_change(ChangeType::CategoryStreams);
+        self.notify_change(ChangeType::FollowedChannels);
     }
 }
 
 impl Default for AppState {
     fn default() -> Self {
-        let (change_tx, change_rx) = watch::channel(None);
         let (streams_tx, _) = broadcast::channel(16);
+        let (ordered_tx, _) = broadcast::channel(ORDERED_CHANGE_CAPACITY);
         Self {
             inner: RwLock::new(StateInner::default()),
-            change_tx,
-            change_rx,
             streams_tx,
+            ordered_tx: std::sync::Mutex::new(Some(ordered_tx)),
+            keep_stream_details: AtomicBool::new(true),
         }
     }
 }
@@ -260,7 +763,7 @@ impl Default for AppState {
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::test_helpers::{make_stream, make_stream_with_game};
+    use crate::test_helpers::{make_scheduled, make_stream, make_stream_with_game};
 
     // === set_followed_streams change detection tests ===
 
@@ -563,6 +1066,31 @@ mod tests {
         assert!(streams.contains_key("game2"));
     }
 
+    #[tokio::test]
+    async fn retain_category_streams_drops_unlisted_categories() {
+        let state = AppState::new();
+        state
+            .set_category_streams(
+                "game1".to_string(),
+                vec![make_stream_with_game("1", "game1", "Fortnite")],
+            )
+            .await;
+        state
+            .set_category_streams(
+                "game2".to_string(),
+                vec![make_stream_with_game("2", "game2", "Minecraft")],
+            )
+            .await;
+
+        state
+            .retain_category_streams(&HashSet::from(["game2".to_string()]))
+            .await;
+
+        let streams = state.get_category_streams().await;
+        assert_eq!(streams.len(), 1);
+        assert!(streams.contains_key("game2"));
+    }
+
     #[tokio::test]
     async fn category_streams_cleared_on_full_clear() {
         let state = AppState::new();
@@ -577,4 +1105,754 @@ mod tests {
         let streams = state.get_category_streams().await;
         assert!(streams.is_empty());
     }
+
+    // === scheduled stream diff tests ===
+
+    #[tokio::test]
+    async fn initial_schedule_load_reports_all_added() {
+        let state = AppState::new();
+
+        let diff = state
+            .set_scheduled_streams(vec![make_scheduled("A", 2), make_scheduled("B", 3)])
+            .await;
+
+        assert_eq!(diff.added.len(), 2);
+        assert!(diff.removed.is_empty());
+    }
+
+    #[tokio::test]
+    async fn unchanged_schedule_produces_empty_diff() {
+        let state = AppState::new();
+        let schedules = vec![make_scheduled("A", 2), make_scheduled("B", 3)];
+
+        state.set_scheduled_streams(schedules.clone()).await;
+        let diff = state.set_scheduled_streams(schedules).await;
+
+        assert!(diff.is_empty());
+    }
+
+    #[tokio::test]
+    async fn schedule_diff_detects_added_and_removed_segments() {
+        let state = AppState::new();
+        state
+            .set_scheduled_streams(vec![make_scheduled("A", 2), make_scheduled("B", 3)])
+            .await;
+
+        let diff = state
+            .set_scheduled_streams(vec![make_scheduled("B", 3), make_scheduled("C", 4)])
+            .await;
+
+        assert_eq!(diff.added.len(), 1);
+        assert_eq!(diff.added[0].id, "sched_C");
+        assert_eq!(diff.removed.len(), 1);
+        assert_eq!(diff.removed[0].id, "sched_A");
+    }
+
+    #[tokio::test]
+    async fn rescheduled_segment_is_not_added_or_removed() {
+        let state = AppState::new();
+        let original = make_scheduled("A", 2);
+        state.set_scheduled_streams(vec![original.clone()]).await;
+
+        // Same segment ID, new start time
+        let diff = state
+            .set_scheduled_streams(vec![make_scheduled("A", 5)])
+            .await;
+
+        assert!(diff.added.is_empty());
+        assert!(diff.removed.is_empty());
+        assert_eq!(diff.rescheduled.len(), 1);
+        assert_eq!(diff.rescheduled[0].old_start, original.start_time);
+        assert!(diff.rescheduled[0].stream.start_time > original.start_time);
+    }
+
+    #[tokio::test]
+    async fn cancelled_only_includes_future_removed_segments() {
+        let state = AppState::new();
+        state
+            .set_scheduled_streams(vec![
+                make_scheduled("Past", -1),
+                make_scheduled("Future", 2),
+            ])
+            .await;
+
+        let diff = state.set_scheduled_streams(vec![]).await;
+        let cancelled: Vec<_> = diff.cancelled(Utc::now()).collect();
+
+        assert_eq!(diff.removed.len(), 2);
+        assert_eq!(cancelled.len(), 1);
+        assert_eq!(cancelled[0].id, "sched_Future");
+    }
+
+    // === ordered change delivery tests ===
+
+    #[tokio::test]
+    async fn ordered_changes_delivered_in_commit_order() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_changes();
+
+        state
+            .set_authenticated(true, "user123".to_string(), "testuser".to_string())
+            .await;
+        state.set_followed_streams(vec![]).await;
+        state.set_scheduled_streams(vec![]).await;
+        state
+            .set_category_streams("game1".to_string(), vec![])
+            .await;
+
+        assert_eq!(rx.recv().await.unwrap(), ChangeType::Authentication);
+        assert_eq!(rx.recv().await.unwrap(), ChangeType::FollowedStreams);
+        assert_eq!(rx.recv().await.unwrap(), ChangeType::ScheduledStreams);
+        assert_eq!(rx.recv().await.unwrap(), ChangeType::CategoryStreams);
+    }
+
+    #[tokio::test]
+    async fn blocked_subscriber_does_not_stall_setters() {
+        let state = AppState::new();
+        // Subscriber that never reads
+        let _rx = state.subscribe_changes();
+
+        let setter = async {
+            for i in 0..(ORDERED_CHANGE_CAPACITY * 2) {
+                state
+                    .set_followed_streams(vec![make_stream(&i.to_string(), "Streamer")])
+                    .await;
+            }
+        };
+
+        tokio::time::timeout(std::time::Duration::from_secs(1), setter)
+            .await
+            .expect("setters should not block on a slow subscriber");
+    }
+
+    #[tokio::test]
+    async fn close_ends_ordered_delivery() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_changes();
+
+        state.set_followed_streams(vec![]).await;
+        state.close();
+
+        // Pending change is still drained, then the channel reports closed
+        assert_eq!(rx.recv().await.unwrap(), ChangeType::FollowedStreams);
+        assert!(matches!(
+            rx.recv().await,
+            Err(broadcast::error::RecvError::Closed)
+        ));
+        assert!(matches!(
+            state.subscribe_changes().recv().await,
+            Err(broadcast::error::RecvError::Closed)
+        ));
+    }
+
+    // === snapshot tests ===
+
+    #[tokio::test]
+    async fn snapshot_reflects_current_state() {
+        let state = AppState::new();
+        state
+            .set_authenticated(true, "user123".to_string(), "testuser".to_string())
+            .await;
+        state
+            .set_followed_streams(vec![make_stream_with_game("1", "game1", "Fortnite")])
+            .await;
+
+        let snapshot = state.snapshot().await;
+
+        assert!(snapshot.authenticated);
+        assert_eq!(snapshot.user_login, "testuser");
+        assert_eq!(snapshot.followed_streams.len(), 1);
+        assert!(snapshot.followed_streams_updated_at.is_some());
+        assert!(snapshot.scheduled_streams_updated_at.is_none());
+        assert_eq!(
+            snapshot.tracked_categories.get("game1"),
+            Some(&"Fortnite".to_string())
+        );
+    }
+
+    /// Backdates every last-seen time so the next update treats missing streams as offline.
+    async fn expire_offline_grace(state: &AppState) {
+        let expired = Utc::now() - Duration::minutes(OFFLINE_GRACE_MIN + 1);
+        for seen in state.inner.write().await.last_seen.values_mut() {
+            *seen = expired;
+        }
+    }
+
+    // === viewer trend tests ===
+
+    fn with_viewers(user_id: &str, viewer_count: u32) -> Stream {
+        let mut s = make_stream(user_id, "Streamer");
+        s.viewer_count = viewer_count;
+        s
+    }
+
+    #[tokio::test]
+    async fn trend_unknown_until_two_samples() {
+        let state = AppState::new();
+        assert_eq!(state.trend("1").await, None);
+
+        state
+            .set_followed_streams(vec![with_viewers("1", 100)])
+            .await;
+        assert_eq!(state.trend("1").await, None);
+
+        state
+            .set_followed_streams(vec![with_viewers("1", 100)])
+            .await;
+        assert_eq!(state.trend("1").await, Some(ViewerTrend::Steady));
+    }
+
+    #[tokio::test]
+    async fn trend_rising_and_falling() {
+        let state = AppState::new();
+        for viewers in [100, 120, 150] {
+            state
+                .set_followed_streams(vec![
+                    with_viewers("up", viewers),
+                    with_viewers("down", 300 - viewers),
+                ])
+                .await;
+        }
+
+        assert_eq!(state.trend("up").await, Some(ViewerTrend::Rising));
+        assert_eq!(state.trend("down").await, Some(ViewerTrend::Falling));
+    }
+
+    #[tokio::test]
+    async fn trend_only_considers_recent_window() {
+        let state = AppState::new();
+        // Big early growth followed by a plateau reads as steady
+        for viewers in [10, 100, 1000, 1000, 1010] {
+            state
+                .set_followed_streams(vec![with_viewers("1", viewers)])
+                .await;
+        }
+
+        assert_eq!(state.trend("1").await, Some(ViewerTrend::Steady));
+    }
+
+    #[tokio::test]
+    async fn trend_samples_capped_and_dropped_when_offline() {
+        let state = AppState::new();
+        for i in 0..(TREND_SAMPLE_CAPACITY as u32 * 2) {
+            state
+                .set_followed_streams(vec![with_viewers("1", 100 + i)])
+                .await;
+        }
+        assert_eq!(
+            state.inner.read().await.viewer_samples["1"].len(),
+            TREND_SAMPLE_CAPACITY
+        );
+
+        expire_offline_grace(&state).await;
+        state.set_followed_streams(vec![]).await;
+        assert!(state.inner.read().await.viewer_samples.is_empty());
+        assert_eq!(state.trend("1").await, None);
+    }
+
+    #[tokio::test]
+    async fn viewer_trends_lists_sampled_streams() {
+        let state = AppState::new();
+        state
+            .set_followed_streams(vec![with_viewers("1", 100)])
+            .await;
+        state
+            .set_followed_streams(vec![with_viewers("1", 200), with_viewers("2", 50)])
+            .await;
+
+        let trends = state.viewer_trends().await;
+        assert_eq!(trends.len(), 1);
+        assert_eq!(trends.get("1"), Some(&ViewerTrend::Rising));
+    }
+
+    // === earliest known start time tests ===
+
+    #[tokio::test]
+    async fn start_time_does_not_jump_backwards_on_later_polls() {
+        let state = AppState::new();
+        let stream = make_stream("1", "Streamer");
+        let first_start = stream.started_at;
+        state.set_followed_streams(vec![stream.clone()]).await;
+
+        // A later poll reports a slightly later start for the same session
+        let mut later = stream;
+        later.started_at = first_start + Duration::minutes(1);
+        state.set_followed_streams(vec![later]).await;
+
+        assert_eq!(
+            state.get_followed_streams().await[0].started_at,
+            first_start
+        );
+    }
+
+    #[tokio::test]
+    async fn new_stream_session_uses_its_own_start_time() {
+        let state = AppState::new();
+        let stream = make_stream("1", "Streamer");
+        state.set_followed_streams(vec![stream.clone()]).await;
+        state.set_followed_streams(vec![]).await;
+
+        // Same broadcaster, new stream session
+        let mut next = stream;
+        next.id = "stream_1_next".to_string();
+        next.started_at = Utc::now();
+        state.set_followed_streams(vec![next.clone()]).await;
+
+        assert_eq!(
+            state.get_followed_streams().await[0].started_at,
+            next.started_at
+        );
+    }
+
+    // === sorting tests ===
+
+    fn named(user_id: &str, user_name: &str, viewers: u32, started_mins_ago: i64) -> Stream {
+        let mut s = make_stream(user_id, user_name);
+        s.viewer_count = viewers;
+        s.started_at = Utc::now() - Duration::minutes(started_mins_ago);
+        s
+    }
+
+    fn ids(streams: &[Stream]) -> Vec<&str> {
+        streams.iter().map(|s| s.user_id.as_str()).collect()
+    }
+
+    #[test]
+    fn sort_by_viewers_descending_keeps_ties_in_order() {
+        let mut streams = vec![
+            named("a", "A", 100, 10),
+            named("b", "B", 500, 10),
+            named("c", "C", 100, 10),
+        ];
+        sort_streams(&mut streams, SortOrder::Viewers, &HashSet::new());
+        assert_eq!(ids(&streams), vec!["b", "a", "c"]);
+    }
+
+    #[test]
+    fn sort_by_name_is_case_insensitive_and_unicode_aware() {
+        let mut streams = vec![
+            named("z", "zeta", 1, 10),
+            named("e", "Émile", 1, 10),
+            named("a", "alpha", 1, 10),
+            named("b", "Beta", 1, 10),
+            named("j", "日本語", 1, 10),
+        ];
+        sort_streams(&mut streams, SortOrder::Name, &HashSet::new());
+        assert_eq!(ids(&streams), vec!["a", "b", "z", "e", "j"]);
+    }
+
+    #[test]
+    fn sort_by_uptime_and_recently_started() {
+        let mut streams = vec![
+            named("mid", "Mid", 1, 60),
+            named("old", "Old", 1, 300),
+            named("new", "New", 1, 5),
+        ];
+        sort_streams(&mut streams, SortOrder::Uptime, &HashSet::new());
+        assert_eq!(ids(&streams), vec!["old", "mid", "new"]);
+
+        sort_streams(&mut streams, SortOrder::RecentlyStarted, &HashSet::new());
+        assert_eq!(ids(&streams), vec!["new", "mid", "old"]);
+    }
+
+    #[test]
+    fn favourites_sorted_first_preserving_order_within_groups() {
+        let mut streams = vec![
+            named("a", "A", 300, 10),
+            named("b", "B", 200, 10),
+            named("c", "C", 100, 10),
+            named("d", "D", 50, 10),
+        ];
+        let favourites = HashSet::from(["c".to_string(), "d".to_string()]);
+        sort_streams(&mut streams, SortOrder::Viewers, &favourites);
+        assert_eq!(ids(&streams), vec!["c", "d", "a", "b"]);
+    }
+
+    #[tokio::test]
+    async fn get_followed_streams_sorted_uses_registered_favourites() {
+        let state = AppState::new();
+        state
+            .set_followed_streams(vec![named("a", "A", 300, 10), named("b", "B", 100, 10)])
+            .await;
+        state.set_favourites(HashSet::from(["b".to_string()])).await;
+
+        let sorted = state.get_followed_streams_sorted(SortOrder::Viewers).await;
+        assert_eq!(ids(&sorted), vec!["b", "a"]);
+    }
+
+    // === first seen tests ===
+
+    #[tokio::test]
+    async fn newly_seen_stream_gets_first_seen_time() {
+        let before = Utc::now();
+        let state = AppState::new();
+        state
+            .set_followed_streams(vec![make_stream("1", "Streamer")])
+            .await;
+
+        let first_seen = state.first_seen_times().await;
+        assert!(first_seen["1"] >= before);
+        assert!(!first_seen.contains_key("2"));
+    }
+
+    #[tokio::test]
+    async fn first_seen_kept_across_polls_and_reset_after_offline() {
+        let state = AppState::new();
+        let stream = make_stream("1", "Streamer");
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let first = state.first_seen_times().await["1"];
+
+        state.set_followed_streams(vec![stream.clone()]).await;
+        assert_eq!(state.first_seen_times().await["1"], first);
+
+        expire_offline_grace(&state).await;
+        state.set_followed_streams(vec![]).await;
+        assert!(state.first_seen_times().await.is_empty());
+    }
+
+    #[tokio::test]
+    async fn went_offline_reported_once_after_grace_period() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+        let mut stream = make_stream("1", "Streamer");
+        stream.started_at = Utc::now() - Duration::hours(4);
+        state.set_followed_streams(vec![stream]).await;
+        rx.recv().await.unwrap();
+
+        // Missing from one poll is not enough
+        state.set_followed_streams(vec![]).await;
+        assert!(rx.recv().await.unwrap().went_offline.is_empty());
+
+        expire_offline_grace(&state).await;
+        state.set_followed_streams(vec![]).await;
+        let event = rx.recv().await.unwrap();
+        assert_eq!(event.went_offline.len(), 1);
+        assert_eq!(event.went_offline[0].stream.user_id, "1");
+        let live_for = event.went_offline[0].live_for();
+        assert!(live_for > Duration::hours(3) && live_for < Duration::hours(4));
+
+        state.set_followed_streams(vec![]).await;
+        assert!(rx.recv().await.unwrap().went_offline.is_empty());
+    }
+
+    #[tokio::test]
+    async fn title_change_reported_within_session_only() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+        let mut stream = make_stream("1", "Streamer");
+        state.set_followed_streams(vec![stream.clone()]).await;
+        rx.recv().await.unwrap();
+
+        stream.title = "!drops now live".to_string();
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let event = rx.recv().await.unwrap();
+        assert_eq!(event.title_changes.len(), 1);
+        assert_eq!(event.title_changes[0].old_title, "Test Stream");
+        assert_eq!(event.title_changes[0].stream.title, "!drops now live");
+
+        // A new session (stream id) with another title is not a change
+        stream.id = "stream_2".to_string();
+        stream.title = "New day".to_string();
+        state.set_followed_streams(vec![stream]).await;
+        assert!(rx.recv().await.unwrap().title_changes.is_empty());
+    }
+
+    // === followed channels change tests ===
+
+    fn channel(id: &str) -> FollowedChannel {
+        FollowedChannel {
+            broadcaster_id: id.to_string(),
+            broadcaster_login: format!("login_{id}"),
+            broadcaster_name: format!("Name {id}"),
+            followed_at: Utc::now(),
+        }
+    }
+
+    #[tokio::test]
+    async fn followed_channels_change_notified_when_set_differs() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_changes();
+
+        state
+            .set_followed_channels(vec![channel("1"), channel("2")])
+            .await;
+
+        assert_eq!(rx.try_recv().unwrap(), ChangeType::FollowedChannels);
+    }
+
+    #[tokio::test]
+    async fn followed_channels_reorder_is_not_a_change() {
+        let state = AppState::new();
+        state
+            .set_followed_channels(vec![channel("1"), channel("2")])
+            .await;
+        let mut rx = state.subscribe_changes();
+
+        state
+            .set_followed_channels(vec![channel("2"), channel("1")])
+            .await;
+
+        assert!(rx.try_recv().is_err());
+        assert_eq!(state.get_followed_channels().await[0].broadcaster_id, "2");
+    }
+
+    #[tokio::test]
+    async fn followed_channels_diff_reports_added_and_removed() {
+        let state = AppState::new();
+        state
+            .set_followed_channels(vec![channel("1"), channel("2")])
+            .await;
+
+        let diff = state
+            .set_followed_channels(vec![channel("2"), channel("3")])
+            .await;
+
+        let added: Vec<&str> = diff
+            .added
+            .iter()
+            .map(|c| c.broadcaster_id.as_str())
+            .collect();
+        let removed: Vec<&str> = diff
+            .removed
+            .iter()
+            .map(|c| c.broadcaster_id.as_str())
+            .collect();
+        assert_eq!(added, vec!["3"]);
+        assert_eq!(removed, vec!["1"]);
+    }
+
+    #[tokio::test]
+    async fn followed_channels_first_load_is_all_added() {
+        let state = AppState::new();
+
+        let diff = state
+            .set_followed_channels(vec![channel("1"), channel("2")])
+            .await;
+
+        assert_eq!(diff.added.len(), 2);
+        assert!(diff.removed.is_empty());
+    }
+
+    #[tokio::test]
+    async fn followed_channels_unchanged_diff_is_empty() {
+        let state = AppState::new();
+        state
+            .set_followed_channels(vec![channel("1"), channel("2")])
+            .await;
+
+        let diff = state
+            .set_followed_channels(vec![channel("2"), channel("1")])
+            .await;
+
+        assert!(diff.is_empty());
+    }
+
+    #[tokio::test]
+    async fn clear_emits_authentication_and_all_data_changes() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_changes();
+
+        state.clear().await;
+
+        let mut received = Vec::new();
+        while let Ok(change) = rx.try_recv() {
+            received.push(change);
+        }
+        assert_eq!(
+            received,
+            vec![
+                ChangeType::Authentication,
+                ChangeType::FollowedStreams,
+                ChangeType::ScheduledStreams,
+                ChangeType::CategoryStreams,
+                ChangeType::FollowedChannels,
+            ]
+        );
+    }
+
+    // === stream detail stripping and compaction tests ===
+
+    /// Approximate heap bytes held by stream fields that nothing renders.
+    fn detail_bytes(streams: &[Stream]) -> usize {
+        streams
+            .iter()
+            .map(|s| {
+                s.thumbnail_url.capacity()
+                    + s.tags.capacity() * std::mem::size_of::<String>()
+                    + s.tags.iter().map(String::capacity).sum::<usize>()
+            })
+            .sum()
+    }
+
+    fn detailed_streams(count: usize) -> Vec<Stream> {
+        (0..count)
+            .map(|i| {
+                let mut s = make_stream(&i.to_string(), &format!("Streamer{i}"));
+                s.thumbnail_url = format!(
+                    "https://static-cdn.jtvnw.net/previews-ttv/live_user_streamer{i}-{{width}}x{{height}}.jpg"
+                );
+                s.tags = vec!["English".to_string(), "DropsEnabled".to_string()];
+                s
+            })
+            .collect()
+    }
+
+    #[tokio::test]
+    async fn stream_details_kept_by_default() {
+        let state = AppState::new();
+        state.set_followed_streams(detailed_streams(1)).await;
+
+        let stored = state.get_followed_streams().await;
+        assert!(!stored[0].thumbnail_url.is_empty());
+        assert_eq!(stored[0].tags.len(), 2);
+    }
+
+    #[tokio::test]
+    async fn stream_details_stripped_when_disabled() {
+        let state = AppState::new();
+        state.set_keep_stream_details(false);
+
+        state.set_followed_streams(detailed_streams(1)).await;
+        state
+            .set_category_streams("game1".to_string(), detailed_streams(1))
+            .await;
+
+        let stored = state.get_followed_streams().await;
+        assert!(stored[0].thumbnail_url.is_empty());
+        assert!(stored[0].tags.is_empty());
+        assert_eq!(stored[0].user_name, "Streamer0");
+        assert!(state.get_category_streams().await["game1"][0]
+            .thumbnail_url
+            .is_empty());
+    }
+
+    /// Compares retained detail memory for 500 streams with and without stripping.
+    #[tokio::test]
+    async fn stripping_500_streams_releases_detail_memory() {
+        let kept = AppState::new();
+        kept.set_followed_streams(detailed_streams(500)).await;
+        let kept_bytes = detail_bytes(&kept.inner.read().await.followed_streams);
+
+        let stripped = AppState::new();
+        stripped.set_keep_stream_details(false);
+        stripped.set_followed_streams(detailed_streams(500)).await;
+        let stripped_bytes = detail_bytes(&stripped.inner.read().await.followed_streams);
+
+        assert!(kept_bytes > 500 * 64, "kept: {kept_bytes} bytes");
+        assert_eq!(stripped_bytes, 0);
+    }
+
+    #[tokio::test]
+    async fn large_shrink_releases_capacity() {
+        let state = AppState::new();
+        state.set_followed_streams(detailed_streams(500)).await;
+        state.set_followed_streams(detailed_streams(10)).await;
+
+        let capacity = state.inner.read().await.followed_streams.capacity();
+        assert!(capacity < 500, "capacity should shrink, was {capacity}");
+    }
+
+    #[tokio::test]
+    async fn compact_shrinks_all_collections() {
+        let state = AppState::new();
+        state
+            .set_scheduled_streams(crate::test_helpers::make_many_scheduled(200))
+            .await;
+        state.inner.write().await.scheduled_streams.truncate(1);
+
+        state.compact().await;
+
+        assert!(state.inner.read().await.scheduled_streams.capacity() < 200);
+    }
+
+    // === stream session dedup and offline grace tests ===
+
+    #[tokio::test]
+    async fn stream_missing_for_one_poll_is_not_newly_live_again() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+        let stream = make_stream("a", "StreamerA");
+
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let _ = rx.recv().await;
+
+        // Connection hiccup: missing from one poll
+        state.set_followed_streams(vec![]).await;
+        let _ = rx.recv().await;
+
+        state.set_followed_streams(vec![stream]).await;
+        let event = rx.recv().await.unwrap();
+
+        assert!(event.newly_live.is_empty());
+    }
+
+    #[tokio::test]
+    async fn new_stream_session_is_newly_live() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+        let stream = make_stream("a", "StreamerA");
+
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let _ = rx.recv().await;
+
+        let mut restarted = stream;
+        restarted.id = "stream_a_restarted".to_string();
+        state.set_followed_streams(vec![restarted]).await;
+        let event = rx.recv().await.unwrap();
+
+        assert_eq!(event.newly_live.len(), 1);
+    }
+
+    #[tokio::test]
+    async fn same_session_after_grace_period_is_newly_live() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+        let stream = make_stream("a", "StreamerA");
+
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let _ = rx.recv().await;
+        expire_offline_grace(&state).await;
+        state.set_followed_streams(vec![]).await;
+        let _ = rx.recv().await;
+
+        state.set_followed_streams(vec![stream]).await;
+        let event = rx.recv().await.unwrap();
+
+        assert_eq!(event.newly_live.len(), 1);
+    }
+
+    #[tokio::test]
+    async fn flapping_stream_keeps_first_seen_time() {
+        let state = AppState::new();
+        let stream = make_stream("a", "StreamerA");
+
+        state.set_followed_streams(vec![stream.clone()]).await;
+        let first = state.first_seen_times().await["a"];
+        state.set_followed_streams(vec![]).await;
+        state.set_followed_streams(vec![stream]).await;
+
+        assert_eq!(state.first_seen_times().await["a"], first);
+    }
+
+    #[tokio::test]
+    async fn category_change_detected_across_missed_poll() {
+        let state = AppState::new();
+        let mut rx = state.subscribe_streams();
+
+        state
+            .set_followed_streams(vec![make_stream_with_game("1", "game1", "Fortnite")])
+            .await;
+        let _ = rx.recv().await;
+        state.set_followed_streams(vec![]).await;
+        let _ = rx.recv().await;
+
+        state
+            .set_followed_streams(vec![make_stream_with_game("1", "game2", "Minecraft")])
+            .await;
+        let event = rx.recv().await.unwrap();
+
+        assert!(event.newly_live.is_empty());
+        assert_eq!(event.category_changes.len(), 1);
+        assert_eq!(event.category_changes[0].old_category, "Fortnite");
+    }
 }
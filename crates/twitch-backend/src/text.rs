@@ -0,0 +1,244 @@
+//! Unicode-aware text helpers for labels, tooltips and notifications
+//!
+//! Lengths are counted in user-perceived characters (grapheme clusters), so
+//! truncating never cuts through an emoji, a flag or a letter with combining
+//! accents. Clusters are found with a small built-in approximation of the
+//! UAX #29 rules that covers combining marks, variation selectors, skin tone
+//! modifiers, emoji ZWJ sequences and flags; scripts with more involved
+//! rules, like Hangul jamo or Indic conjuncts, may count a few characters
+//! more than they show.
+
+/// Appended to truncated text
+const ELLIPSIS: &str = "...";
+
+/// Whether `c` extends the preceding character rather than starting a new one
+fn is_extend(c: char) -> bool {
+    matches!(
+        c,
+        // Combining diacritical marks, including supplements and symbols
+        '\u{0300}'..='\u{036F}'
+            | '\u{1AB0}'..='\u{1AFF}'
+            | '\u{1DC0}'..='\u{1DFF}'
+            | '\u{20D0}'..='\u{20FF}'
+            | '\u{FE20}'..='\u{FE2F}'
+            // Cyrillic and Hebrew combining marks
+            | '\u{0483}'..='\u{0489}'
+            | '\u{0591}'..='\u{05BD}'
+            // Combining kana voiced sound marks
+            | '\u{3099}'..='\u{309A}'
+            // Zero width non-joiner and joiner
+            | '\u{200C}'..='\u{200D}'
+            // Variation selectors, e.g. emoji presentation
+            | '\u{FE00}'..='\u{FE0F}'
+            | '\u{E0100}'..='\u{E01EF}'
+            // Skin tone modifiers
+            | '\u{1F3FB}'..='\u{1F3FF}'
+            // Tags, used by subdivision flags
+            | '\u{E0020}'..='\u{E007F}'
+    )
+}
+
+/// Regional indicator letters, which pair up into flags
+fn is_regional_indicator(c: char) -> bool {
+    matches!(c, '\u{1F1E6}'..='\u{1F1FF}')
+}
+
+/// Splits `s` into grapheme clusters
+pub fn graphemes(s: &str) -> impl Iterator<Item = &str> {
+    let mut rest = s;
+    std::iter::from_fn(move || {
+        let mut chars = rest.char_indices();
+        let (_, mut prev) = chars.next()?;
+        // A flag is exactly two regional indicators
+        let mut flag_open = is_regional_indicator(prev);
+        let mut end = rest.len();
+        for (i, c) in chars {
+            let joins = is_extend(c)
+                || prev == '\u{200D}'
+                || (prev == '\r' && c == '\n')
+                || (flag_open && is_regional_indicator(c));
+            if !joins {
+                end = i;
+                break;
+            }
+            flag_open = false;
+            prev = c;
+        }
+        let (cluster, tail) = rest.split_at(end);
+        rest = tail;
+        Some(cluster)
+    })
+}
+
+/// Keeps whole clusters of `s` up to `max` in total `width`, replacing the
+/// end with "..." if anything was cut. With `max` of 3 or less the text is
+/// cut without an ellipsis.
+fn truncate_with(s: &str, max: usize, width: impl Fn(&str) -> usize) -> String {
+    if graphemes(s).map(&width).sum::<usize>() <= max {
+        return s.to_string();
+    }
+
+    let ellipsis = if max <= ELLIPSIS.len() { "" } else { ELLIPSIS };
+    let budget = max - ellipsis.len();
+    let mut used = 0;
+    let mut end = 0;
+    for cluster in graphemes(s) {
+        used += width(cluster);
+        if used > budget {
+            break;
+        }
+        end += cluster.len();
+    }
+    format!("{}{}", &s[..end], ellipsis)
+}
+
+/// Truncates `s` to at most `max` characters (grapheme clusters), ending
+/// with "..." when it was cut
+pub fn truncate(s: &str, max: usize) -> String {
+    truncate_with(s, max, |_| 1)
+}
+
+/// Truncates `s` to at most `max` UTF-16 code units, the unit Windows uses
+/// for its length limits, without splitting a character. Ends with "..."
+/// when it was cut.
+pub fn truncate_utf16(s: &str, max: usize) -> String {
+    truncate_with(s, max, |cluster| cluster.encode_utf16().count())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    // === graphemes tests ===
+
+    #[test]
+    fn graphemes_keep_marks_and_emoji_sequences_together() {
+        let clusters: Vec<&str> = graphemes(
+            "e\u{301}\u{1F44B}\u{1F3FD}\u{1F468}\u{200D}\u{1F469}\u{200D}\u{1F467}\u{2764}\u{FE0F}",
+        )
+        .collect();
+
+        assert_eq!(
+            clusters,
+            vec![
+                "e\u{301}",
+                "\u{1F44B}\u{1F3FD}",
+                "\u{1F468}\u{200D}\u{1F469}\u{200D}\u{1F467}",
+                "\u{2764}\u{FE0F}",
+            ]
+        );
+    }
+
+    #[test]
+    fn graphemes_pair_regional_indicators_into_flags() {
+        // Three flags: JP, GB, US
+        let flags = "\u{1F1EF}\u{1F1F5}\u{1F1EC}\u{1F1E7}\u{1F1FA}\u{1F1F8}";
+
+        assert_eq!(
+            graphemes(flags).collect::<Vec<_>>(),
+            vec![
+                "\u{1F1EF}\u{1F1F5}",
+                "\u{1F1EC}\u{1F1E7}",
+                "\u{1F1FA}\u{1F1F8}"
+            ]
+        );
+    }
+
+    #[test]
+    fn graphemes_of_plain_and_empty_text() {
+        assert_eq!(
+            graphemes("ab\r\nc").collect::<Vec<_>>(),
+            vec!["a", "b", "\r\n", "c"]
+        );
+        assert_eq!(graphemes("").count(), 0);
+    }
+
+    // === truncate tests ===
+
+    #[test]
+    fn truncate_short_string() {
+        assert_eq!(truncate("Hello", 10), "Hello");
+    }
+
+    #[test]
+    fn truncate_long_string() {
+        assert_eq!(
+            truncate("This is a very long title that should be truncated", 20),
+            "This is a very lo..."
+        );
+    }
+
+    #[test]
+    fn truncate_exact_length() {
+        assert_eq!(truncate("Hello", 5), "Hello");
+    }
+
+    #[test]
+    fn truncate_max_3() {
+        assert_eq!(truncate("Hello", 3), "Hel");
+    }
+
+    #[test]
+    fn truncate_max_4() {
+        assert_eq!(truncate("Hello", 4), "H...");
+    }
+
+    #[test]
+    fn truncate_empty_string() {
+        assert_eq!(truncate("", 10), "");
+        assert_eq!(truncate("Hello", 0), "");
+    }
+
+    #[test]
+    fn truncate_game_name_realistic() {
+        let long_game = "Counter-Strike: Global Offensive";
+        assert_eq!(truncate(long_game, 20), "Counter-Strike: G...");
+    }
+
+    #[test]
+    fn truncate_multibyte_emoji() {
+        let s = "🚨GOOD TAKES🚨";
+
+        assert_eq!(truncate(s, 12), s, "12 characters fit in 12");
+        assert_eq!(truncate(s, 11), "🚨GOOD TA...");
+        assert_eq!(truncate(s, 10), "🚨GOOD T...");
+    }
+
+    #[test]
+    fn truncate_counts_characters_not_bytes() {
+        let title = "日本語のタイトル";
+
+        assert_eq!(truncate(title, 8), title);
+        assert_eq!(truncate(title, 7), "日本語の...");
+        assert_eq!(truncate(title, 2), "日本");
+    }
+
+    #[test]
+    fn truncate_never_splits_a_cluster() {
+        let family = "\u{1F468}\u{200D}\u{1F469}\u{200D}\u{1F467}";
+        let s = format!("ab{family}cdef");
+
+        assert_eq!(truncate(&s, 7), s);
+        assert_eq!(truncate(&s, 6), format!("ab{family}..."));
+        assert_eq!(truncate(&s, 5), "ab...");
+
+        let accented = "cafe\u{301} cafe\u{301}";
+        assert_eq!(truncate(accented, 9), accented);
+        assert_eq!(truncate(accented, 7), "cafe\u{301}...");
+        assert_eq!(truncate(accented, 3), "caf");
+    }
+
+    // === truncate_utf16 tests ===
+
+    #[test]
+    fn truncate_utf16_counts_code_units() {
+        // Each emoji outside the BMP is two UTF-16 code units
+        let s = "🚨🚨🚨ab";
+
+        assert_eq!(truncate_utf16(s, 8), s);
+        assert_eq!(truncate_utf16(s, 7), "🚨🚨...");
+        assert_eq!(truncate_utf16(s, 6), "🚨...");
+        assert_eq!(truncate_utf16(s, 3), "🚨");
+        assert_eq!(truncate_utf16("日本語", 3), "日本語");
+    }
+}
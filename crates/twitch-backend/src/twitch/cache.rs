@@ -0,0 +1,146 @@
+//! Small in-memory cache with a fixed time-to-live per entry
+
+use std::borrow::Borrow;
+use std::collections::HashMap;
+use std::hash::Hash;
+use std::time::{Duration, Instant};
+
+/// Map whose entries expire a fixed time after insertion
+#[derive(Debug)]
+pub struct TtlCache<K, V> {
+    ttl: Duration,
+    entries: HashMap<K, (V, Instant)>,
+}
+
+impl<K: Eq + Hash, V: Clone> TtlCache<K, V> {
+    /// Creates an empty cache whose entries live for `ttl`
+    pub fn new(ttl: Duration) -> Self {
+        Self {
+            ttl,
+            entries: HashMap::new(),
+        }
+    }
+
+    /// Changes the time-to-live; applies to existing entries too
+    pub fn set_ttl(&mut self, ttl: Duration) {
+        self.ttl = ttl;
+    }
+
+    /// Returns the value for `key` if present and not expired
+    pub fn get<Q>(&self, key: &Q) -> Option<V>
+    where
+        K: Borrow<Q>,
+        Q: Eq + Hash + ?Sized,
+    {
+        self.get_at(key, Instant::now())
+    }
+
+    /// Inserts or replaces a value, restarting its time-to-live
+    pub fn insert(&mut self, key: K, value: V) {
+        self.insert_at(key, value, Instant::now());
+    }
+
+    /// Removes the entry for `key`, if any
+    pub fn remove<Q>(&mut self, key: &Q)
+    where
+        K: Borrow<Q>,
+        Q: Eq + Hash + ?Sized,
+    {
+        self.entries.remove(key);
+    }
+
+    /// Removes every entry
+    pub fn clear(&mut self) {
+        self.entries.clear();
+    }
+
+    /// Drops all expired entries
+    pub fn purge_expired(&mut self) {
+        let now = Instant::now();
+        let ttl = self.ttl;
+        self.entries
+            .retain(|_, (_, inserted)| now.duration_since(*inserted) < ttl);
+    }
+
+    fn get_at<Q>(&self, key: &Q, now: Instant) -> Option<V>
+    where
+        K: Borrow<Q>,
+        Q: Eq + Hash + ?Sized,
+    {
+        self.entries
+            .get(key)
+            .filter(|(_, inserted)| now.duration_since(*inserted) < self.ttl)
+            .map(|(value, _)| value.clone())
+    }
+
+    fn insert_at(&mut self, key: K, value: V, now: Instant) {
+        self.entries.insert(key, (value, now));
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn returns_fresh_entries() {
+        let mut cache = TtlCache::new(Duration::from_secs(60));
+        cache.insert("a".to_string(), 1);
+
+        assert_eq!(cache.get("a"), Some(1));
+        assert_eq!(cache.get("b"), None);
+    }
+
+    #[test]
+    fn expired_entries_are_misses() {
+        let mut cache = TtlCache::new(Duration::from_secs(60));
+        let inserted = Instant::now();
+        cache.insert_at("a".to_string(), 1, inserted);
+
+        assert_eq!(
+            cache.get_at("a", inserted + Duration::from_secs(59)),
+            Some(1)
+        );
+        assert_eq!(cache.get_at("a", inserted + Duration::from_secs(60)), None);
+    }
+
+    #[test]
+    fn remove_and_clear_drop_entries() {
+        let mut cache = TtlCache::new(Duration::from_secs(60));
+        cache.insert("a".to_string(), 1);
+        cache.insert("b".to_string(), 2);
+
+        cache.remove("a");
+        assert_eq!(cache.get("a"), None);
+        assert_eq!(cache.get("b"), Some(2));
+
+        cache.clear();
+        assert_eq!(cache.get("b"), None);
+    }
+
+    #[test]
+    fn purge_drops_expired_entries() {
+        let mut cache = TtlCache::new(Duration::from_secs(60));
+        cache.insert_at(
+            "old".to_string(),
+            1,
+            Instant::now() - Duration::from_secs(120),
+        );
+        cache.insert("new".to_string(), 2);
+
+        cache.purge_expired();
+
+        assert_eq!(cache.entries.len(), 1);
+        assert_eq!(cache.get("new"), Some(2));
+    }
+
+    #[test]
+    fn set_ttl_applies_to_existing_entries() {
+        let mut cache = TtlCache::new(Duration::from_secs(60));
+        cache.insert_at("a".to_string(), 1, Instant::now() - Duration::from_secs(30));
+
+        cache.set_ttl(Duration::from_secs(10));
+
+        assert_eq!(cache.get("a"), None);
+    }
+}
@@ -0,0 +1,181 @@
+//! Circuit breaker that stops hammering Helix during an outage
+//!
+//! After [`FAILURE_THRESHOLD`] consecutive failed requests the circuit opens
+//! and requests fail fast for [`COOLDOWN_SECS`]. Then a single probe request is
+//! let through: success closes the circuit, failure opens it again.
+
+use chrono::{DateTime, Duration, Utc};
+use std::sync::Mutex;
+
+/// Consecutive failures that open the circuit
+pub(super) const FAILURE_THRESHOLD: u32 = 5;
+
+/// How long the circuit stays open before allowing a probe
+pub(super) const COOLDOWN_SECS: i64 = 60;
+
+/// State of the circuit breaker
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CircuitState {
+    /// Requests flow normally
+    Closed,
+    /// Requests fail fast until `until`
+    Open { until: DateTime<Utc> },
+    /// Cooldown is over; the next request is a probe
+    HalfOpen,
+}
+
+#[derive(Debug, Default)]
+struct BreakerInner {
+    consecutive_failures: u32,
+    open_until: Option<DateTime<Utc>>,
+    probing: bool,
+}
+
+/// Tracks consecutive failures across all endpoints of a client
+#[derive(Debug, Default)]
+pub struct CircuitBreaker {
+    inner: Mutex<BreakerInner>,
+}
+
+impl CircuitBreaker {
+    /// Returns the current state
+    pub fn state(&self, now: DateTime<Utc>) -> CircuitState {
+        let inner = self.inner.lock().unwrap();
+        match inner.open_until {
+            None => CircuitState::Closed,
+            Some(until) if now < until => CircuitState::Open { until },
+            Some(_) => CircuitState::HalfOpen,
+        }
+    }
+
+    /// Checks whether a request may be sent
+    ///
+    /// Returns the time the circuit may next be retried when it's open, or
+    /// when another request is already probing a half-open circuit.
+    pub fn allow(&self, now: DateTime<Utc>) -> Result<(), DateTime<Utc>> {
+        let mut inner = self.inner.lock().unwrap();
+        let Some(until) = inner.open_until else {
+            return Ok(());
+        };
+        if now < until || inner.probing {
+            return Err(until);
+        }
+        inner.probing = true;
+        Ok(())
+    }
+
+    /// Records a successful request, closing the circuit
+    pub fn record_success(&self) {
+        *self.inner.lock().unwrap() = BreakerInner::default();
+    }
+
+    /// Records a failed request, opening the circuit once failures pile up
+    /// (or straight away if the request was a probe)
+    pub fn record_failure(&self, now: DateTime<Utc>) {
+        let mut inner = self.inner.lock().unwrap();
+        inner.consecutive_failures += 1;
+        if inner.probing || inner.consecutive_failures >= FAILURE_THRESHOLD {
+            if inner.open_until.is_none() {
+                tracing::warn!(
+                    "Twitch API failing ({} consecutive errors), pausing requests",
+                    inner.consecutive_failures
+                );
+            }
+            inner.open_until = Some(now + Duration::seconds(COOLDOWN_SECS));
+            inner.probing = false;
+        }
+    }
+
+    /// Releases a probe that ended without a verdict (e.g. it was cancelled)
+    pub fn release_probe(&self) {
+        self.inner.lock().unwrap().probing = false;
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn tripped(now: DateTime<Utc>) -> CircuitBreaker {
+        let breaker = CircuitBreaker::default();
+        for _ in 0..FAILURE_THRESHOLD {
+            breaker.record_failure(now);
+        }
+        breaker
+    }
+
+    #[test]
+    fn stays_closed_below_threshold() {
+        let now = Utc::now();
+        let breaker = CircuitBreaker::default();
+        for _ in 0..FAILURE_THRESHOLD - 1 {
+            breaker.record_failure(now);
+        }
+
+        assert_eq!(breaker.state(now), CircuitState::Closed);
+        assert!(breaker.allow(now).is_ok());
+    }
+
+    #[test]
+    fn opens_at_threshold_and_fails_fast() {
+        let now = Utc::now();
+        let breaker = tripped(now);
+        let until = now + Duration::seconds(COOLDOWN_SECS);
+
+        assert_eq!(breaker.state(now), CircuitState::Open { until });
+        assert_eq!(breaker.allow(now), Err(until));
+    }
+
+    #[test]
+    fn success_resets_failure_count() {
+        let now = Utc::now();
+        let breaker = CircuitBreaker::default();
+        for _ in 0..FAILURE_THRESHOLD - 1 {
+            breaker.record_failure(now);
+        }
+        breaker.record_success();
+        breaker.record_failure(now);
+
+        assert_eq!(breaker.state(now), CircuitState::Closed);
+    }
+
+    #[test]
+    fn half_open_allows_one_probe() {
+        let now = Utc::now();
+        let breaker = tripped(now);
+        let later = now + Duration::seconds(COOLDOWN_SECS);
+
+        assert_eq!(breaker.state(later), CircuitState::HalfOpen);
+        assert!(breaker.allow(later).is_ok());
+        assert!(breaker.allow(later).is_err());
+    }
+
+    #[test]
+    fn failed_probe_reopens() {
+        let now = Utc::now();
+        let breaker = tripped(now);
+        let later = now + Duration::seconds(COOLDOWN_SECS);
+        breaker.allow(later).unwrap();
+
+        breaker.record_failure(later);
+
+        assert_eq!(
+            breaker.state(later),
+            CircuitState::Open {
+                until: later + Duration::seconds(COOLDOWN_SECS)
+            }
+        );
+    }
+
+    #[test]
+    fn successful_probe_closes() {
+        let now = Utc::now();
+        let breaker = tripped(now);
+        let later = now + Duration::seconds(COOLDOWN_SECS);
+        breaker.allow(later).unwrap();
+
+        breaker.record_success();
+
+        assert_eq!(breaker.state(later), CircuitState::Closed);
+    }
+}
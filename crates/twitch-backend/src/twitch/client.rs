@@ -1,17 +1,83 @@
 use anyhow::{Context, Result};
+use chrono::{DateTime, SecondsFormat, Utc};
 use reqwest::header::HeaderMap;
-use std::sync::Arc;
-use tokio::sync::RwLock;
-
-use super::http::{HttpClient, ReqwestClient};
+use std::collections::HashMap;
+use std::sync::{Arc, Mutex};
+use std::time::Duration;
+use tokio::sync::{watch, RwLock};
+
+use super::cache::TtlCache;
+use super::circuit::{CircuitBreaker, CircuitState};
+use super::http::{HttpClient, HttpResponse, ReqwestClient};
+use super::metrics::{ApiHealth, ApiMetrics, MetricsRecorder};
+use super::rate_limit::{RateLimitStatus, RateLimiter};
 use super::types::{
-    Category, FollowedChannel, FollowedChannelsResponse, GamesResponse, ScheduleData,
-    ScheduleResponse, SearchCategoriesResponse, Stream, StreamsResponse, User, UsersResponse,
+    Category, ChannelInfo, ChannelInfoResponse, ChannelSearchResult, Clip, FollowedChannel,
+    FollowedChannelsResponse, GamesResponse, Pagination, ScheduleData, ScheduleResponse,
+    ScheduleSegment, Stream, StreamsResponse, TopGame, User, UsersResponse,
 };
 use super::ApiError;
 
 const HELIX_BASE_URL: &str = "https://api.twitch.tv/helix";
 
+/// One page of a paginated Helix response
+#[derive(Debug, serde::Deserialize)]
+struct Page<T> {
+    data: Vec<T>,
+    #[serde(default)]
+    pagination: Option<Pagination>,
+}
+
+/// How long looked-up games are cached; names and box art rarely change
+pub const DEFAULT_GAMES_CACHE_TTL: Duration = Duration::from_secs(24 * 60 * 60);
+
+/// Largest page size Helix accepts
+const MAX_PAGE_SIZE: usize = 100;
+
+/// How long looked-up users are cached; profile images rarely change
+pub const DEFAULT_USERS_CACHE_TTL: Duration = Duration::from_secs(7 * 24 * 60 * 60);
+
+/// Default time a broadcaster with no schedule (404) is skipped before being
+/// checked again
+pub const DEFAULT_NO_SCHEDULE_TTL: Duration = Duration::from_secs(72 * 60 * 60);
+
+/// Most streams fetched for one category, however large the requested limit;
+/// huge categories have tens of thousands of live streams
+pub const MAX_CATEGORY_STREAMS: usize = 1000;
+
+/// How many times `limit` streams are fetched when filtering a category by
+/// tag, since most streams won't match
+const TAG_FILTER_FETCH_FACTOR: usize = 10;
+
+/// Segments per schedule page (the Helix maximum)
+const SCHEDULE_PAGE_SIZE: usize = 25;
+
+/// Upper bound on schedule pages fetched for one broadcaster
+const MAX_SCHEDULE_PAGES: usize = 10;
+
+/// Batches of 100 user IDs fetched concurrently by
+/// [`TwitchClient::get_streams_by_user_ids`]
+pub const STREAM_BATCH_CONCURRENCY: usize = 4;
+
+/// Attempts per request when Twitch returns a 5xx or the request fails to send
+const MAX_ATTEMPTS: u32 = 3;
+
+/// Backoff before the first retry; doubles for each retry after that
+const RETRY_BASE_DELAY_MS: u64 = 250;
+
+/// Returns true for server errors worth retrying
+fn is_transient_status(status: u16) -> bool {
+    matches!(status, 500 | 502 | 503 | 504)
+}
+
+/// Returns the backoff before retry number `attempt`, with up to 50% jitter
+fn retry_delay(attempt: u32) -> std::time::Duration {
+    let base = RETRY_BASE_DELAY_MS << (attempt - 1);
+    // Cheap jitter from the clock; it only needs to spread out concurrent retries
+    let jitter = base * u64::from(Utc::now().timestamp_subsec_nanos() % 1000) / 2000;
+    std::time::Duration::from_millis(base + jitter)
+}
+
 /// Twitch Helix API client
 ///
 /// Generic over the HTTP client implementation for testability.
@@ -20,6 +86,35 @@ pub struct TwitchClient<H: HttpClient = ReqwestClient> {
     client_id: String,
     access_token: Arc<RwLock<Option<String>>>,
     user_id: Arc<RwLock<Option<String>>>,
+    rate_limiter: Arc<RateLimiter>,
+    games_cache: Arc<Mutex<TtlCache<String, Category>>>,
+    users_cache: Arc<Mutex<UserCache>>,
+    /// Broadcasters whose schedule request last returned 404
+    no_schedule: Arc<Mutex<TtlCache<String, ()>>>,
+    cancel_tx: Arc<watch::Sender<u64>>,
+    breaker: Arc<CircuitBreaker>,
+    metrics: Arc<MetricsRecorder>,
+}
+
+/// Users cached by ID, with a login index for lookups by name
+struct UserCache {
+    by_id: TtlCache<String, User>,
+    id_by_login: TtlCache<String, String>,
+}
+
+impl UserCache {
+    fn new(ttl: Duration) -> Self {
+        Self {
+            by_id: TtlCache::new(ttl),
+            id_by_login: TtlCache::new(ttl),
+        }
+    }
+
+    fn insert(&mut self, user: User) {
+        self.id_by_login
+            .insert(user.login.to_lowercase(), user.id.clone());
+        self.by_id.insert(user.id.clone(), user);
+    }
 }
 
 impl TwitchClient<ReqwestClient> {
@@ -30,6 +125,13 @@ impl TwitchClient<ReqwestClient> {
             client_id,
             access_token: Arc::new(RwLock::new(None)),
             user_id: Arc::new(RwLock::new(None)),
+            rate_limiter: Arc::new(RateLimiter::default()),
+            games_cache: Arc::new(Mutex::new(TtlCache::new(DEFAULT_GAMES_CACHE_TTL))),
+            users_cache: Arc::new(Mutex::new(UserCache::new(DEFAULT_USERS_CACHE_TTL))),
+            no_schedule: Arc::new(Mutex::new(TtlCache::new(DEFAULT_NO_SCHEDULE_TTL))),
+            cancel_tx: Arc::new(watch::channel(0).0),
+            breaker: Arc::new(CircuitBreaker::default()),
+            metrics: Arc::new(MetricsRecorder::default()),
         }
     }
 }
@@ -57,6 +159,11 @@ impl<H: HttpClient> TwitchClient<H> {
         self.user_id.read().await.clone()
     }
 
+    /// The underlying HTTP client, for requests that aren't to the Twitch API
+    pub(crate) fn http(&self) -> &H {
+        &self.http
+    }
+
     /// Builds the headers for an authenticated request
     async fn build_headers(&self) -> Result<HeaderMap> {
         let token = self
@@ -73,29 +180,220 @@ impl<H: HttpClient> TwitchClient<H> {
         Ok(headers)
     }
 
-    /// Makes an authenticated GET request to the Helix API
+    /// Returns the Helix rate-limit values from the most recent response
+    pub fn rate_limit_status(&self) -> RateLimitStatus {
+        self.rate_limiter.status()
+    }
+
+    /// Returns when requests may resume after Twitch rate-limited the client,
+    /// so polling loops can skip a cycle rather than spend it failing
+    pub fn next_allowed_at(&self) -> Option<DateTime<Utc>> {
+        self.rate_limiter.next_allowed_at(Utc::now())
+    }
+
+    /// Returns the circuit breaker state, so callers can report an outage
+    pub fn circuit_state(&self) -> CircuitState {
+        self.breaker.state(Utc::now())
+    }
+
+    /// Returns request counters per endpoint path
+    pub fn metrics(&self) -> ApiMetrics {
+        self.metrics.snapshot()
+    }
+
+    /// Returns when a request last succeeded and the most recent failure
+    pub fn health(&self) -> ApiHealth {
+        self.metrics.health()
+    }
+
+    /// Sets how long game lookups are cached
+    pub fn set_games_cache_ttl(&self, ttl: Duration) {
+        self.games_cache.lock().unwrap().set_ttl(ttl);
+    }
+
+    /// Sets how long a broadcaster with no schedule is remembered
+    pub fn set_no_schedule_ttl(&self, ttl: Duration) {
+        self.no_schedule.lock().unwrap().set_ttl(ttl);
+    }
+
+    /// Returns true if the broadcaster's schedule request recently returned 404
     ///
-    /// Returns `ApiError::Unauthorized` for 401 responses, allowing callers
-    /// to handle token refresh and retry.
-    async fn get<T: serde::de::DeserializeOwned + Send>(
+    /// Most channels never set up a schedule, so callers skip these rather
+    /// than asking again every cycle.
+    pub fn has_no_schedule(&self, broadcaster_id: &str) -> bool {
+        self.no_schedule
+            .lock()
+            .unwrap()
+            .get(broadcaster_id)
+            .is_some()
+    }
+
+    /// Forgets which broadcasters had no schedule, so a manual refresh checks
+    /// every channel again
+    pub fn forget_missing_schedules(&self) {
+        self.no_schedule.lock().unwrap().clear();
+    }
+
+    /// Aborts every request currently in flight, including any waiting on a
+    /// rate-limit reset or retry backoff. Requests made afterwards are unaffected.
+    pub fn cancel_requests(&self) {
+        self.cancel_tx.send_modify(|generation| *generation += 1);
+    }
+
+    /// Sends an authenticated GET request and records its outcome in the metrics
+    async fn send_get(&self, endpoint: &str) -> Result<HttpResponse, ApiError> {
+        let result = self.send_guarded(endpoint).await;
+        let now = Utc::now();
+        match &result {
+            Ok(response) if response.is_success() => self.metrics.record_success(endpoint, now),
+            Ok(response) => {
+                self.metrics.record_error(
+                    endpoint,
+                    Some(response.status),
+                    format!("HTTP {}", response.status),
+                    now,
+                );
+            }
+            Err(e) => self
+                .metrics
+                .record_error(endpoint, e.status(), e.to_string(), now),
+        }
+        result
+    }
+
+    /// Sends an authenticated GET request, abandoning it if
+    /// [`TwitchClient::cancel_requests`] is called while it's in flight
+    ///
+    /// Fails fast with [`ApiError::CircuitOpen`] while the circuit breaker is open.
+    async fn send_guarded(&self, endpoint: &str) -> Result<HttpResponse, ApiError> {
+        let headers = self.build_headers().await?;
+        self.breaker
+            .allow(Utc::now())
+            .map_err(|retry_at| ApiError::CircuitOpen { retry_at })?;
+
+        // Subscribing marks the current generation as seen, so only a later
+        // cancel_requests() call wakes this up
+        let mut cancel_rx = self.cancel_tx.subscribe();
+        let result = tokio::select! {
+            result = self.send_with_retries(endpoint, &headers) => result,
+            _ = cancel_rx.changed() => {
+                self.breaker.release_probe();
+                return Err(ApiError::Other(anyhow::anyhow!(
+                    "Request to {endpoint} cancelled"
+                )));
+            }
+        };
+
+        // Only an unreachable or failing server counts against the breaker;
+        // 4xx responses (including rate limiting) show Twitch is up
+        match &result {
+            Ok(response) if !is_transient_status(response.status) => {
+                self.breaker.record_success();
+            }
+            Err(ApiError::RateLimited { .. }) => self.breaker.record_success(),
+            _ => self.breaker.record_failure(Utc::now()),
+        }
+        result
+    }
+
+    /// Sends an authenticated GET request, retrying transient failures
+    ///
+    /// 5xx responses and transport errors are retried up to [`MAX_ATTEMPTS`]
+    /// times with exponential backoff and jitter. 4xx responses are never retried.
+    async fn send_with_retries(
         &self,
         endpoint: &str,
-    ) -> Result<T, ApiError> {
-        let headers = self.build_headers().await?;
+        headers: &HeaderMap,
+    ) -> Result<HttpResponse, ApiError> {
         let url = format!("{HELIX_BASE_URL}{endpoint}");
 
-        let response = self.http.get_response(&url, &headers).await?;
+        let mut attempt = 1;
+        loop {
+            let result = self.send_once(&url, headers).await;
+            let transient = match &result {
+                Ok(response) => is_transient_status(response.status),
+                // Transport failures; a long rate-limit penalty isn't worth retrying
+                Err(ApiError::RateLimited { .. }) => false,
+                Err(_) => true,
+            };
+            if !transient || attempt >= MAX_ATTEMPTS {
+                return result;
+            }
 
-        if response.is_unauthorized() {
-            return Err(ApiError::Unauthorized);
+            let delay = retry_delay(attempt);
+            match &result {
+                Ok(response) => tracing::warn!(
+                    "Twitch API returned {} for {}, retrying in {:?}",
+                    response.status,
+                    endpoint,
+                    delay
+                ),
+                Err(e) => tracing::warn!(
+                    "Request to {} failed: {}, retrying in {:?}",
+                    endpoint,
+                    e,
+                    delay
+                ),
+            }
+            tokio::time::sleep(delay).await;
+            attempt += 1;
+        }
+    }
+
+    /// Sends a single GET request through the shared rate limiter
+    ///
+    /// Waits while the rate-limit bucket is nearly empty. After a 429, short
+    /// penalties are waited out and the request retried once; longer ones
+    /// fail fast with [`ApiError::RateLimited`].
+    async fn send_once(&self, url: &str, headers: &HeaderMap) -> Result<HttpResponse, ApiError> {
+        self.wait_out_penalty().await?;
+        self.rate_limiter.acquire().await;
+        let response = self.http.get_response(url, headers).await?;
+        self.rate_limiter.update(&response.headers);
+
+        if !response.is_rate_limited() {
+            return Ok(response);
+        }
+
+        self.rate_limiter
+            .record_rate_limited(&response.headers, Utc::now());
+        self.wait_out_penalty().await?;
+
+        let response = self.http.get_response(url, headers).await?;
+        self.rate_limiter.update(&response.headers);
+        if response.is_rate_limited() {
+            self.rate_limiter
+                .record_rate_limited(&response.headers, Utc::now());
+        }
+        Ok(response)
+    }
+
+    /// Sleeps through a short 429 penalty, or fails fast if it's a long one
+    async fn wait_out_penalty(&self) -> Result<(), ApiError> {
+        match self.rate_limiter.penalty_wait(Utc::now()) {
+            Ok(None) => Ok(()),
+            Ok(Some(wait)) => {
+                tracing::warn!("Rate limited by Twitch, waiting {:?}", wait);
+                tokio::time::sleep(wait).await;
+                Ok(())
+            }
+            Err(resume_at) => Err(ApiError::RateLimited {
+                reset_at: Some(resume_at),
+            }),
         }
+    }
+
+    /// Makes an authenticated GET request to the Helix API
+    ///
+    /// Non-success responses become typed errors; see [`TwitchClient::error_for`].
+    async fn get<T: serde::de::DeserializeOwned + Send>(
+        &self,
+        endpoint: &str,
+    ) -> Result<T, ApiError> {
+        let response = self.send_get(endpoint).await?;
 
         if !response.is_success() {
-            return Err(ApiError::Other(anyhow::anyhow!(
-                "API error {}: {}",
-                response.status,
-                response.body
-            )));
+            return Err(self.error_for(endpoint, &response));
         }
 
         Ok(response.json()?)
@@ -103,35 +401,99 @@ impl<H: HttpClient> TwitchClient<H> {
 
     /// Makes an authenticated GET request that may return 404
     ///
-    /// Returns `ApiError::Unauthorized` for 401 responses, allowing callers
-    /// to handle token refresh and retry. Returns `Ok(None)` for 404.
+    /// Returns `Ok(None)` for 404; other failures are typed errors as for
+    /// [`TwitchClient::get`].
     async fn get_optional<T: serde::de::DeserializeOwned + Send>(
         &self,
         endpoint: &str,
     ) -> Result<Option<T>, ApiError> {
-        let headers = self.build_headers().await?;
-        let url = format!("{HELIX_BASE_URL}{endpoint}");
-
-        let response = self.http.get_response(&url, &headers).await?;
-
-        if response.is_unauthorized() {
-            return Err(ApiError::Unauthorized);
-        }
+        let response = self.send_get(endpoint).await?;
 
         if response.is_not_found() {
             return Ok(None);
         }
 
         if !response.is_success() {
-            tracing::warn!("API error {}: {}", response.status, response.body);
-            return Ok(None);
+            return Err(self.error_for(endpoint, &response));
         }
 
         Ok(Some(response.json()?))
     }
 
-    /// Clears authentication state
+    /// Maps a non-success response to a typed error
+    ///
+    /// 401 is `Unauthorized` (callers refresh the token and retry), 429 is
+    /// `RateLimited` with the reset time, 404 is `NotFound`, and anything else
+    /// is `Status` carrying the message from Helix's error envelope.
+    fn error_for(&self, endpoint: &str, response: &HttpResponse) -> ApiError {
+        if response.is_unauthorized() {
+            return ApiError::Unauthorized;
+        }
+        if response.is_rate_limited() {
+            return ApiError::RateLimited {
+                reset_at: self
+                    .rate_limiter
+                    .next_allowed_at(Utc::now())
+                    .or(self.rate_limiter.status().reset_at),
+            };
+        }
+        if response.is_not_found() {
+            return ApiError::NotFound {
+                endpoint: endpoint.to_string(),
+            };
+        }
+
+        // Helix errors look like {"error":"Bad Request","status":400,"message":"..."}
+        let message = serde_json::from_str::<serde_json::Value>(&response.body)
+            .ok()
+            .and_then(|v| v.get("message")?.as_str().map(str::to_string))
+            .unwrap_or_else(|| response.body.clone());
+        ApiError::Status {
+            status: response.status,
+            endpoint: endpoint.to_string(),
+            message,
+        }
+    }
+
+    /// Collects up to `limit` items from a paginated endpoint
+    ///
+    /// The page size and cursor are appended to `endpoint`'s query string.
+    /// Stops early when a page is empty or there is no cursor.
+    async fn get_paged<T: serde::de::DeserializeOwned + Send>(
+        &self,
+        endpoint: &str,
+        limit: usize,
+    ) -> Result<Vec<T>, ApiError> {
+        let separator = if endpoint.contains('?') { '&' } else { '?' };
+        let mut items = Vec::new();
+        let mut cursor: Option<String> = None;
+
+        while items.len() < limit {
+            let first = (limit - items.len()).min(MAX_PAGE_SIZE);
+            let page_endpoint = match &cursor {
+                Some(c) => format!("{endpoint}{separator}first={first}&after={c}"),
+                None => format!("{endpoint}{separator}first={first}"),
+            };
+
+            let page: Page<T> = self.get(&page_endpoint).await?;
+            if page.data.is_empty() {
+                break;
+            }
+            items.extend(page.data);
+
+            match page.pagination.and_then(|p| p.cursor) {
+                Some(c) if !c.is_empty() => cursor = Some(c),
+                _ => break,
+            }
+        }
+
+        items.truncate(limit);
+        Ok(items)
+    }
+
+    /// Clears authentication state and cancels requests made with the old token
     pub async fn clear_auth(&self) {
+        self.cancel_requests();
         *self.access_token.write().await = None;
         *self.user_id.write().await = None;
     }
@@ -144,6 +506,13 @@ impl<H: HttpClient + Clone> Clone for TwitchClient<H> {
             client_id: self.client_id.clone(),
             access_token: self.access_token.clone(),
             user_id: self.user_id.clone(),
+            rate_limiter: self.rate_limiter.clone(),
+            games_cache: self.games_cache.clone(),
+            users_cache: self.users_cache.clone(),
+            no_schedule: self.no_schedule.clone(),
+            cancel_tx: self.cancel_tx.clone(),
+            breaker: self.breaker.clone(),
+            metrics: self.metrics.clone(),
         }
     }
 }
@@ -180,6 +549,17 @@ impl<H: HttpClient> TwitchClient<H> {
 
         Ok(all_streams)
     }
+
+    /// Gets a single channel's stream, or `None` if it's offline
+    ///
+    /// Much cheaper than [`TwitchClient::get_followed_streams`] for re-checking
+    /// one channel.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn get_stream_by_user_id(&self, user_id: &str) -> Result<Option<Stream>, ApiError> {
+        let endpoint = format!("/streams?user_id={user_id}");
+        let response: StreamsResponse = self.get(&endpoint).await?;
+        Ok(response.data.into_iter().next())
+    }
 }
 
 // Channel-related methods
@@ -208,6 +588,27 @@ impl<H: HttpClient> TwitchClient<H> {
         Ok((response.data, next_cursor))
     }
 
+    /// Gets channel information (title, category, language) for broadcasters
+    ///
+    /// Requests are made in batches of 100 IDs. Unknown IDs are omitted.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn get_channel_info(
+        &self,
+        broadcaster_ids: &[&str],
+    ) -> Result<Vec<ChannelInfo>, ApiError> {
+        let mut channels = Vec::new();
+        for chunk in broadcaster_ids.chunks(MAX_PAGE_SIZE) {
+            let params: Vec<String> = chunk
+                .iter()
+                .map(|id| format!("broadcaster_id={id}"))
+                .collect();
+            let endpoint = format!("/channels?{}", params.join("&"));
+            let response: ChannelInfoResponse = self.get(&endpoint).await?;
+            channels.extend(response.data);
+        }
+        Ok(channels)
+    }
+
     /// Gets all channels the user follows (handles pagination)
     ///
     /// Returns `ApiError::Unauthorized` if the token has expired.
@@ -217,6 +618,7 @@ impl<H: HttpClient> TwitchClient<H> {
 
         loop {
             let (follows, next_cursor) = self.get_followed_channels(cursor.as_deref()).await?;
+            self.warm_users_cache(&follows);
             all_follows.extend(follows);
 
             match next_cursor {
@@ -233,77 +635,481 @@ impl<H: HttpClient> TwitchClient<H> {
 impl<H: HttpClient> TwitchClient<H> {
     /// Searches for categories/games by name
     ///
+    /// The query is trimmed and `limit` is capped at 100. Follows pagination
+    /// until `limit` results are collected or the results run out. A result
+    /// whose name exactly matches the query (ignoring case) warms the games cache.
     /// Returns `ApiError::Unauthorized` if the token has expired.
-    pub async fn search_categories(&self, query: &str) -> Result<Vec<Category>, ApiError> {
+    pub async fn search_categories(
+        &self,
+        query: &str,
+        limit: usize,
+    ) -> Result<Vec<Category>, ApiError> {
+        let query = query.trim();
+        let limit = limit.min(MAX_PAGE_SIZE);
+        if query.is_empty() || limit == 0 {
+            return Ok(vec![]);
+        }
+
         let encoded_query = urlencoding::encode(query);
-        let endpoint = format!("/search/categories?query={encoded_query}&first=10");
-        let response: SearchCategoriesResponse = self.get(&endpoint).await?;
-        Ok(response.data)
+        let results: Vec<Category> = self
+            .get_paged(&format!("/search/categories?query={encoded_query}"), limit)
+            .await?;
+
+        if let Some(exact) = results.iter().find(|c| c.name.eq_ignore_ascii_case(query)) {
+            self.games_cache
+                .lock()
+                .unwrap()
+                .insert(exact.id.clone(), exact.clone());
+        }
+
+        Ok(results)
     }
 
-    /// Gets games/categories by their IDs (up to 100 per request)
+    /// Gets the most-watched categories right now, up to `limit`
     ///
+    /// The top games endpoint carries no viewership, so when `count_first` is
+    /// non-zero the first `count_first` games are annotated with a rough count
+    /// of live streams (capped at 100), at the cost of one request each.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn get_top_games(
+        &self,
+        limit: usize,
+        count_first: usize,
+    ) -> Result<Vec<TopGame>, ApiError> {
+        let games: Vec<Category> = self.get_paged("/games/top", limit).await?;
+
+        {
+            let mut cache = self.games_cache.lock().unwrap();
+            for game in &games {
+                cache.insert(game.id.clone(), game.clone());
+            }
+        }
+
+        let mut top = Vec::with_capacity(games.len());
+        for (index, category) in games.into_iter().enumerate() {
+            let live_streams = if index < count_first {
+                let streams = self
+                    .get_streams_by_category(&category.id, None, MAX_PAGE_SIZE)
+                    .await?;
+                Some(streams.len())
+            } else {
+                None
+            };
+            top.push(TopGame {
+                category,
+                live_streams,
+            });
+        }
+
+        Ok(top)
+    }
+
+    /// Gets games/categories by their IDs
+    ///
+    /// Duplicate IDs are fetched once, cached games are served without a
+    /// request, and the misses are requested 100 at a time. Results follow the
+    /// first occurrence of each ID in `game_ids`, skipping IDs Twitch doesn't know.
     /// Returns `ApiError::Unauthorized` if the token has expired.
     pub async fn get_games_by_ids(&self, game_ids: &[&str]) -> Result<Vec<Category>, ApiError> {
-        if game_ids.is_empty() {
+        let mut unique: Vec<&str> = Vec::with_capacity(game_ids.len());
+        for id in game_ids {
+            if !unique.contains(id) {
+                unique.push(id);
+            }
+        }
+        if unique.is_empty() {
             return Ok(vec![]);
         }
 
-        let params: Vec<String> = game_ids.iter().map(|id| format!("id={id}")).collect();
-        let endpoint = format!("/games?{}", params.join("&"));
-        let response: GamesResponse = self.get(&endpoint).await?;
-        Ok(response.data)
+        let mut found: HashMap<String, Category> = HashMap::new();
+        let mut missing = Vec::new();
+        {
+            let cache = self.games_cache.lock().unwrap();
+            for id in &unique {
+                match cache.get(*id) {
+                    Some(game) => {
+                        found.insert(game.id.clone(), game);
+                    }
+                    None => missing.push(*id),
+                }
+            }
+        }
+
+        for chunk in missing.chunks(MAX_PAGE_SIZE) {
+            let params: Vec<String> = chunk.iter().map(|id| format!("id={id}")).collect();
+            let endpoint = format!("/games?{}", params.join("&"));
+            let response: GamesResponse = self.get(&endpoint).await?;
+
+            let mut cache = self.games_cache.lock().unwrap();
+            cache.purge_expired();
+            for game in response.data {
+                cache.insert(game.id.clone(), game.clone());
+                found.insert(game.id.clone(), game);
+            }
+        }
+
+        Ok(unique
+            .iter()
+            .filter_map(|id| found.get(*id).cloned())
+            .collect())
     }
 
-    /// Gets top streams for a specific category/game, optionally filtered by language.
+    /// Gets the top `limit` streams for a category/game, optionally filtered by language.
     ///
-    /// `language` is an ISO 639-1 two-letter code (e.g. "en", "es").
+    /// `language` is an ISO 639-1 two-letter code (e.g. "en", "es"). Limits
+    /// above 100 follow pagination, up to [`MAX_CATEGORY_STREAMS`] streams.
     /// Returns `ApiError::Unauthorized` if the token has expired.
     pub async fn get_streams_by_category(
         &self,
         game_id: &str,
         language: Option<&str>,
+        limit: usize,
+    ) -> Result<Vec<Stream>, ApiError> {
+        self.get_streams_by_category_with_tags(game_id, language, &[], limit)
+            .await
+    }
+
+    /// Like [`TwitchClient::get_streams_by_category`], keeping only streams
+    /// that match every tag in `tags` (see [`Stream::has_tag`])
+    ///
+    /// Helix can't filter by tag, so when `tags` is non-empty more streams are
+    /// fetched and filtered here; the result may hold fewer than `limit`.
+    pub async fn get_streams_by_category_with_tags(
+        &self,
+        game_id: &str,
+        language: Option<&str>,
+        tags: &[String],
+        limit: usize,
     ) -> Result<Vec<Stream>, ApiError> {
         let endpoint = match language {
-            Some(lang) => format!("/streams?game_id={game_id}&first=10&language={lang}"),
-            None => format!("/streams?game_id={game_id}&first=10"),
+            Some(lang) => format!("/streams?game_id={game_id}&language={lang}"),
+            None => format!("/streams?game_id={game_id}"),
         };
-        let response: StreamsResponse = self.get(&endpoint).await?;
-        Ok(response.data)
+        let fetch = if tags.is_empty() {
+            limit
+        } else {
+            limit.saturating_mul(TAG_FILTER_FETCH_FACTOR)
+        };
+        let mut streams: Vec<Stream> = self
+            .get_paged(&endpoint, fetch.min(MAX_CATEGORY_STREAMS))
+            .await?;
+
+        if !tags.is_empty() {
+            streams.retain(|stream| tags.iter().all(|tag| stream.has_tag(tag)));
+            streams.truncate(limit);
+        }
+        Ok(streams)
+    }
+}
+
+// Search-related methods
+impl<H: HttpClient> TwitchClient<H> {
+    /// Searches channels by name, including ones the user doesn't follow
+    ///
+    /// Follows pagination until `limit` results are collected or the results
+    /// run out. An empty (or whitespace-only) query returns no results without
+    /// a request.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn search_channels(
+        &self,
+        query: &str,
+        live_only: bool,
+        limit: usize,
+    ) -> Result<Vec<ChannelSearchResult>, ApiError> {
+        let query = query.trim();
+        if query.is_empty() || limit == 0 {
+            return Ok(vec![]);
+        }
+
+        let encoded_query = urlencoding::encode(query);
+        let mut endpoint = format!("/search/channels?query={encoded_query}");
+        if live_only {
+            endpoint.push_str("&live_only=true");
+        }
+        self.get_paged(&endpoint, limit).await
     }
 }
 
 // User-related methods
 impl<H: HttpClient> TwitchClient<H> {
-    /// Gets users by their IDs (up to 100 per request)
+    /// Gets users by their IDs, in batches of 100 per request
     ///
+    /// Always asks Twitch; the results refresh the user cache.
     /// Returns `ApiError::Unauthorized` if the token has expired.
     pub async fn get_users_by_ids(&self, user_ids: &[&str]) -> Result<Vec<User>, ApiError> {
-        if user_ids.is_empty() {
-            return Ok(vec![]);
+        let mut users = Vec::new();
+        for chunk in user_ids.chunks(MAX_PAGE_SIZE) {
+            let params: Vec<String> = chunk.iter().map(|id| format!("id={id}")).collect();
+            let endpoint = format!("/users?{}", params.join("&"));
+            let response: UsersResponse = self.get(&endpoint).await?;
+            users.extend(response.data);
+        }
+
+        let mut cache = self.users_cache.lock().unwrap();
+        for user in &users {
+            cache.insert(user.clone());
+        }
+        Ok(users)
+    }
+
+    /// Returns profile image URLs for users, keyed by user ID
+    ///
+    /// Cached users are served without a request; only the misses are fetched.
+    /// Users Twitch doesn't know are omitted.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn get_user_profiles(
+        &self,
+        user_ids: &[&str],
+    ) -> Result<HashMap<String, String>, ApiError> {
+        let mut profiles = HashMap::new();
+        let mut missing = Vec::new();
+        {
+            let cache = self.users_cache.lock().unwrap();
+            for id in user_ids {
+                // Users warmed from the follow list have no image yet
+                match cache.by_id.get(*id) {
+                    Some(user) if !user.profile_image_url.is_empty() => {
+                        profiles.insert(user.id, user.profile_image_url);
+                    }
+                    _ => missing.push(*id),
+                }
+            }
+        }
+
+        if !missing.is_empty() {
+            for user in self.get_users_by_ids(&missing).await? {
+                profiles.insert(user.id, user.profile_image_url);
+            }
         }
 
-        let params: Vec<String> = user_ids.iter().map(|id| format!("id={id}")).collect();
-        let endpoint = format!("/users?{}", params.join("&"));
-        let response: UsersResponse = self.get(&endpoint).await?;
-        Ok(response.data)
+        Ok(profiles)
+    }
+
+    /// Returns the cached user with the given login, if known
+    pub fn cached_user_by_login(&self, login: &str) -> Option<User> {
+        let cache = self.users_cache.lock().unwrap();
+        let id = cache.id_by_login.get(login.to_lowercase().as_str())?;
+        cache.by_id.get(&id)
+    }
+
+    /// Records followed channels as users, without replacing fuller cached entries
+    fn warm_users_cache(&self, follows: &[FollowedChannel]) {
+        let mut cache = self.users_cache.lock().unwrap();
+        for follow in follows {
+            if cache.by_id.get(&follow.broadcaster_id).is_some() {
+                continue;
+            }
+            cache.insert(User {
+                id: follow.broadcaster_id.clone(),
+                login: follow.broadcaster_login.clone(),
+                display_name: follow.broadcaster_name.clone(),
+                profile_image_url: String::new(),
+            });
+        }
     }
 }
 
 // Schedule-related methods
 impl<H: HttpClient> TwitchClient<H> {
-    /// Gets scheduled streams for a broadcaster
+    /// Gets a broadcaster's scheduled streams starting in `[start, until)`
+    ///
+    /// Twitch filters by `start` server-side and returns segments in start
+    /// order, so pages are fetched only until a segment starts at or after
+    /// `until`. Segments repeated across pages are returned once.
     ///
     /// Returns `ApiError::Unauthorized` if the token has expired.
-    /// Returns `Ok(None)` if the broadcaster has no schedule (404).
+    /// Returns `Ok(None)` if the broadcaster has no schedule (404), and
+    /// remembers that for [`TwitchClient::has_no_schedule`].
     pub async fn get_schedule(
         &self,
         broadcaster_id: &str,
+        start: DateTime<Utc>,
+        until: DateTime<Utc>,
     ) -> Result<Option<ScheduleData>, ApiError> {
-        let endpoint = format!("/schedule?broadcaster_id={broadcaster_id}&first=10");
+        let start_time = start.to_rfc3339_opts(SecondsFormat::Secs, true);
+        let base = format!(
+            "/schedule?broadcaster_id={broadcaster_id}&start_time={}&first={SCHEDULE_PAGE_SIZE}",
+            urlencoding::encode(&start_time)
+        );
+
+        let mut schedule: Option<ScheduleData> = None;
+        let mut segments: Vec<ScheduleSegment> = Vec::new();
+        let mut after: Option<String> = None;
+        for _ in 0..MAX_SCHEDULE_PAGES {
+            let endpoint = match &after {
+                Some(cursor) => format!("{base}&after={cursor}"),
+                None => base.clone(),
+            };
+            let Some(mut response) = self.get_optional::<ScheduleResponse>(&endpoint).await? else {
+                if schedule.is_none() {
+                    self.no_schedule
+                        .lock()
+                        .unwrap()
+                        .insert(broadcaster_id.to_string(), ());
+                }
+                break;
+            };
+
+            let page = response.data.segments.take().unwrap_or_default();
+            let reached_cutoff = page.last().is_none_or(|seg| seg.start_time >= until);
+            for seg in page {
+                if seg.start_time < until && !segments.iter().any(|s| s.id == seg.id) {
+                    segments.push(seg);
+                }
+            }
+            schedule.get_or_insert(response.data);
+
+            after = response.pagination.and_then(|p| p.cursor);
+            if reached_cutoff || after.is_none() {
+                break;
+            }
+        }
+
+        if schedule.is_some() {
+            self.no_schedule.lock().unwrap().remove(broadcaster_id);
+        }
+        Ok(schedule.map(|mut data| {
+            data.segments = Some(segments);
+            data
+        }))
+    }
+}
+
+impl<H: HttpClient + Clone + 'static> TwitchClient<H> {
+    /// Gets the live streams of the given channels, fetching batches of 100 IDs
+    /// at most [`STREAM_BATCH_CONCURRENCY`] at a time
+    ///
+    /// A failed batch is logged and skipped so the others still return their
+    /// streams; an error is returned only if every batch failed, or if any
+    /// batch hit `ApiError::Unauthorized` (so the caller can refresh the token).
+    /// Dropping the returned future aborts any requests still in flight.
+    pub async fn get_streams_by_user_ids(
+        &self,
+        user_ids: &[String],
+    ) -> Result<Vec<Stream>, ApiError> {
+        let batches: Vec<String> = user_ids
+            .chunks(MAX_PAGE_SIZE)
+            .map(|chunk| {
+                let params: Vec<String> = chunk.iter().map(|id| format!("user_id={id}")).collect();
+                format!("/streams?{}&first={MAX_PAGE_SIZE}", params.join("&"))
+            })
+            .collect();
+        if batches.is_empty() {
+            return Ok(vec![]);
+        }
+
+        let semaphore = Arc::new(tokio::sync::Semaphore::new(STREAM_BATCH_CONCURRENCY));
+        let mut tasks = tokio::task::JoinSet::new();
+        for (index, endpoint) in batches.iter().enumerate() {
+            let client = self.clone();
+            let endpoint = endpoint.clone();
+            let semaphore = semaphore.clone();
+            tasks.spawn(async move {
+                let _permit = semaphore.acquire_owned().await;
+                let result: Result<StreamsResponse, ApiError> = client.get(&endpoint).await;
+                (index, result)
+            });
+        }
+
+        let mut pages: Vec<Option<Vec<Stream>>> = batches.iter().map(|_| None).collect();
+        let mut first_error = None;
+        while let Some(joined) = tasks.join_next().await {
+            match joined {
+                Ok((index, Ok(response))) => pages[index] = Some(response.data),
+                Ok((_, Err(ApiError::Unauthorized))) => return Err(ApiError::Unauthorized),
+                Ok((index, Err(e))) => {
+                    tracing::warn!(
+                        "Stream batch {} of {} failed: {}",
+                        index + 1,
+                        batches.len(),
+                        e
+                    );
+                    first_error.get_or_insert(e);
+                }
+                Err(e) => tracing::error!("Stream batch task failed: {}", e),
+            }
+        }
+
+        if pages.iter().all(Option::is_none) {
+            return Err(first_error
+                .unwrap_or_else(|| ApiError::Other(anyhow::anyhow!("All stream batches failed"))));
+        }
+
+        // Keep batch order so results are deterministic
+        let streams: Vec<Stream> = pages.into_iter().flatten().flatten().collect();
+        Ok(streams)
+    }
+
+    /// Gets schedules for several broadcasters, at most `concurrency` at a time
+    ///
+    /// Each schedule covers `[start, until)` as in [`TwitchClient::get_schedule`].
+    /// Results are in the same order as `broadcaster_ids`, and one broadcaster
+    /// failing doesn't affect the others. Dropping the returned future aborts
+    /// any requests still in flight.
+    pub async fn get_schedules(
+        &self,
+        broadcaster_ids: &[String],
+        start: DateTime<Utc>,
+        until: DateTime<Utc>,
+        concurrency: usize,
+    ) -> Vec<Result<Option<ScheduleData>, ApiError>> {
+        let semaphore = Arc::new(tokio::sync::Semaphore::new(concurrency.max(1)));
+        let mut tasks = tokio::task::JoinSet::new();
+        for (index, broadcaster_id) in broadcaster_ids.iter().enumerate() {
+            let client = self.clone();
+            let broadcaster_id = broadcaster_id.clone();
+            let semaphore = semaphore.clone();
+            tasks.spawn(async move {
+                let _permit = semaphore.acquire_owned().await;
+                (
+                    index,
+                    client.get_schedule(&broadcaster_id, start, until).await,
+                )
+            });
+        }
 
-        let response: Option<ScheduleResponse> = self.get_optional(&endpoint).await?;
-        Ok(response.map(|r| r.data))
+        let mut results: Vec<Option<Result<Option<ScheduleData>, ApiError>>> =
+            broadcaster_ids.iter().map(|_| None).collect();
+        while let Some(joined) = tasks.join_next().await {
+            match joined {
+                Ok((index, result)) => results[index] = Some(result),
+                Err(e) => tracing::error!("Schedule fetch task failed: {}", e),
+            }
+        }
+
+        results
+            .into_iter()
+            .map(|result| {
+                result.unwrap_or_else(|| {
+                    Err(ApiError::Other(anyhow::anyhow!(
+                        "Schedule fetch task failed"
+                    )))
+                })
+            })
+            .collect()
+    }
+}
+
+// Clip-related methods
+impl<H: HttpClient> TwitchClient<H> {
+    /// Gets a broadcaster's most-viewed clips created since `started_at`
+    ///
+    /// Returns up to `limit` clips, most viewed first.
+    /// Returns `ApiError::Unauthorized` if the token has expired.
+    pub async fn get_clips(
+        &self,
+        broadcaster_id: &str,
+        started_at: DateTime<Utc>,
+        limit: usize,
+    ) -> Result<Vec<Clip>, ApiError> {
+        let started_at = started_at.to_rfc3339_opts(SecondsFormat::Secs, true);
+        let endpoint = format!(
+            "/clips?broadcaster_id={broadcaster_id}&started_at={}",
+            urlencoding::encode(&started_at)
+        );
+        let mut clips: Vec<Clip> = self.get_paged(&endpoint, limit).await?;
+        clips.sort_by(|a, b| b.view_count.cmp(&a.view_count));
+        Ok(clips)
     }
 }
 
@@ -317,6 +1123,13 @@ impl<H: HttpClient> TwitchClient<H> {
             client_id,
             access_token: Arc::new(RwLock::new(None)),
             user_id: Arc::new(RwLock::new(None)),
+            rate_limiter: Arc::new(RateLimiter::default()),
+            games_cache: Arc::new(Mutex::new(TtlCache::new(DEFAULT_GAMES_CACHE_TTL))),
+            users_cache: Arc::new(Mutex::new(UserCache::new(DEFAULT_USERS_CACHE_TTL))),
+            no_schedule: Arc::new(Mutex::new(TtlCache::new(DEFAULT_NO_SCHEDULE_TTL))),
+            cancel_tx: Arc::new(watch::channel(0).0),
+            breaker: Arc::new(CircuitBreaker::default()),
+            metrics: Arc::new(MetricsRecorder::default()),
         }
     }
 }
@@ -325,8 +1138,9 @@ impl<H: HttpClient> TwitchClient<H> {
 mod tests {
     use super::*;
     use crate::test_helpers::make_stream;
+    use crate::twitch::circuit::{COOLDOWN_SECS, FAILURE_THRESHOLD};
     use crate::twitch::http::mock::MockHttpClient;
-    use crate::twitch::types::Pagination;
+    use crate::twitch::types::SearchCategoriesResponse;
 
     fn make_streams_response(streams: Vec<Stream>, cursor: Option<&str>) -> StreamsResponse {
         StreamsResponse {
@@ -503,6 +1317,7 @@ mod tests {
                     box_art_url: "https://example.com/mc.jpg".to_string(),
                 },
             ],
+            pagination: None,
         };
 
         let mock = MockHttpClient::new().on_get_json(
@@ -513,7 +1328,7 @@ mod tests {
         let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
         client.set_access_token("test_token".to_string()).await;
 
-        let result = client.search_categories("chat").await.unwrap();
+        let result = client.search_categories("chat", 10).await.unwrap();
 
         assert_eq!(result.len(), 2);
         assert_eq!(result[0].id, "509658");
@@ -522,7 +1337,10 @@ mod tests {
 
     #[tokio::test]
     async fn search_categories_encodes_query() {
-        let response = SearchCategoriesResponse { data: vec![] };
+        let response = SearchCategoriesResponse {
+            data: vec![],
+            pagination: None,
+        };
 
         let mock = MockHttpClient::new().on_get_json(
             "https://api.twitch.tv/helix/search/categories?query=just%20chatting&first=10",
@@ -532,7 +1350,7 @@ mod tests {
         let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
         client.set_access_token("test_token".to_string()).await;
 
-        let result = client.search_categories("just chatting").await.unwrap();
+        let result = client.search_categories("just chatting", 10).await.unwrap();
         assert!(result.is_empty());
     }
 
@@ -554,7 +1372,7 @@ mod tests {
         client.set_access_token("test_token".to_string()).await;
 
         let result = client
-            .get_streams_by_category("509658", None)
+            .get_streams_by_category("509658", None, 10)
             .await
             .unwrap();
 
@@ -568,7 +1386,7 @@ mod tests {
         let streams = vec![make_stream("1", "StreamerOne")];
 
         let mock = MockHttpClient::new().on_get_json(
-            "https://api.twitch.tv/helix/streams?game_id=509658&first=10&language=en",
+            "https://api.twitch.tv/helix/streams?game_id=509658&language=en&first=10",
             &make_streams_response(streams, None),
         );
 
@@ -576,7 +1394,7 @@ mod tests {
         client.set_access_token("test_token".to_string()).await;
 
         let result = client
-            .get_streams_by_category("509658", Some("en"))
+            .get_streams_by_category("509658", Some("en"), 10)
             .await
             .unwrap();
 
@@ -595,9 +1413,1580 @@ mod tests {
         client.set_access_token("test_token".to_string()).await;
 
         let result = client
-            .get_streams_by_category("999999", None)
+            .get_streams_by_category("999999", None, 10)
             .await
             .unwrap();
         assert!(result.is_empty());
     }
+
+    // === rate limiting tests ===
+
+    fn rate_limit_headers(remaining: u32, reset: chrono::DateTime<Utc>) -> HeaderMap {
+        let mut headers = HeaderMap::new();
+        headers.insert("Ratelimit-Limit", 800.into());
+        headers.insert("Ratelimit-Remaining", remaining.into());
+        headers.insert("Ratelimit-Reset", reset.timestamp().into());
+        headers
+    }
+
+    #[tokio::test]
+    async fn rate_limit_status_tracks_response_headers() {
+        let reset = Utc::now() + chrono::Duration::seconds(30);
+        let mock = MockHttpClient::new().on_get_with_headers(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            200,
+            rate_limit_headers(650, reset),
+            serde_json::to_string(&make_streams_response(vec![], None)).unwrap(),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+        assert_eq!(client.rate_limit_status(), RateLimitStatus::default());
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        let status = client.rate_limit_status();
+        assert_eq!(status.limit, Some(800));
+        assert_eq!(status.remaining, Some(650));
+        assert_eq!(status.reset_at.unwrap().timestamp(), reset.timestamp());
+    }
+
+    #[tokio::test]
+    async fn rate_limited_request_retried_after_reset() {
+        let url = "https://api.twitch.tv/helix/streams?game_id=509658&first=10";
+        let streams = vec![make_stream("1", "StreamerOne")];
+        // Reset already passed, so the retry only waits the short default penalty
+        let mock = MockHttpClient::new()
+            .on_get_json(url, &make_streams_response(streams, None))
+            .on_get_once(
+                url,
+                429,
+                rate_limit_headers(0, Utc::now() - chrono::Duration::seconds(1)),
+                "Too Many Requests",
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 1);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn long_rate_limit_penalty_fails_fast() {
+        let url = "https://api.twitch.tv/helix/streams?game_id=509658&first=10";
+        let mut headers = rate_limit_headers(0, Utc::now() + chrono::Duration::seconds(2));
+        headers.insert("Retry-After", 30.into());
+        let mock =
+            MockHttpClient::new().on_get_with_headers(url, 429, headers, "Too Many Requests");
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let started = std::time::Instant::now();
+        let result = client.get_streams_by_category("509658", None, 10).await;
+
+        assert!(started.elapsed() < std::time::Duration::from_secs(1));
+        let Err(ApiError::RateLimited {
+            reset_at: Some(reset_at),
+        }) = result
+        else {
+            panic!("expected RateLimited with a reset time, got {result:?}");
+        };
+        assert_eq!(client.next_allowed_at(), Some(reset_at));
+        assert!(reset_at > Utc::now() + chrono::Duration::seconds(20));
+        assert_eq!(mock.get_requests().len(), 1);
+
+        // Later calls fail without a request until the penalty passes
+        let result = client.get_streams_by_category("509658", None, 10).await;
+        assert!(matches!(result, Err(ApiError::RateLimited { .. })));
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn next_allowed_at_is_none_without_penalty() {
+        let client =
+            TwitchClient::with_http_client("test_client_id".to_string(), MockHttpClient::new());
+
+        assert_eq!(client.next_allowed_at(), None);
+    }
+
+    #[tokio::test]
+    async fn rate_limiter_shared_between_clones() {
+        let mock = MockHttpClient::new().on_get_with_headers(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            200,
+            rate_limit_headers(650, Utc::now() + chrono::Duration::seconds(30)),
+            serde_json::to_string(&make_streams_response(vec![], None)).unwrap(),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        let clone = client.clone();
+        client.set_access_token("test_token".to_string()).await;
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(clone.rate_limit_status().remaining, Some(650));
+    }
+
+    // === retry tests ===
+
+    #[test]
+    fn retry_delay_backs_off_exponentially() {
+        let first = retry_delay(1).as_millis() as u64;
+        let second = retry_delay(2).as_millis() as u64;
+
+        assert!((RETRY_BASE_DELAY_MS..RETRY_BASE_DELAY_MS * 3 / 2).contains(&first));
+        assert!((RETRY_BASE_DELAY_MS * 2..RETRY_BASE_DELAY_MS * 3).contains(&second));
+    }
+
+    #[tokio::test]
+    async fn server_error_retried_until_success() {
+        let url = "https://api.twitch.tv/helix/streams?game_id=509658&first=10";
+        let streams = vec![make_stream("1", "StreamerOne")];
+        let mock = MockHttpClient::new()
+            .on_get_json(url, &make_streams_response(streams, None))
+            .on_get_once(url, 503, HeaderMap::new(), "Service Unavailable");
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 1);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn server_error_gives_up_after_max_attempts() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            500,
+            "Internal Server Error",
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_streams_by_category("509658", None, 10).await;
+
+        assert!(result.is_err());
+        assert_eq!(mock.get_requests().len(), MAX_ATTEMPTS as usize);
+    }
+
+    #[tokio::test]
+    async fn client_error_not_retried() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            400,
+            "Bad Request",
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_streams_by_category("509658", None, 10).await;
+
+        assert!(result.is_err());
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn failed_page_retried_without_aborting_pagination() {
+        let page1 = "https://api.twitch.tv/helix/streams/followed?user_id=user123&first=100";
+        let page2 =
+            "https://api.twitch.tv/helix/streams/followed?user_id=user123&first=100&after=cursor1";
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                page1,
+                &make_streams_response(vec![make_stream("1", "StreamerOne")], Some("cursor1")),
+            )
+            .on_get_json(
+                page2,
+                &make_streams_response(vec![make_stream("2", "StreamerTwo")], None),
+            )
+            .on_get_once(page2, 502, HeaderMap::new(), "Bad Gateway");
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+        client.set_user_id("user123".to_string()).await;
+
+        let result = client.get_followed_streams().await.unwrap();
+
+        assert_eq!(result.len(), 2);
+    }
+
+    // === circuit breaker tests ===
+
+    fn trip_breaker(client: &TwitchClient<MockHttpClient>, at: DateTime<Utc>) {
+        for _ in 0..FAILURE_THRESHOLD {
+            client.breaker.record_failure(at);
+        }
+    }
+
+    #[tokio::test]
+    async fn open_circuit_fails_fast() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            &make_streams_response(vec![], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+        trip_breaker(&client, Utc::now());
+
+        let result = client.get_streams_by_category("509658", None, 10).await;
+
+        assert!(matches!(result, Err(ApiError::CircuitOpen { .. })));
+        assert!(matches!(client.circuit_state(), CircuitState::Open { .. }));
+        assert!(mock.get_requests().is_empty());
+    }
+
+    #[tokio::test]
+    async fn successful_probe_closes_circuit() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            &make_streams_response(vec![], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+        let cooldown = chrono::Duration::seconds(COOLDOWN_SECS);
+        trip_breaker(&client, Utc::now() - cooldown);
+        assert_eq!(client.circuit_state(), CircuitState::HalfOpen);
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(client.circuit_state(), CircuitState::Closed);
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn client_errors_do_not_trip_circuit() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            400,
+            "Bad Request",
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        for _ in 0..FAILURE_THRESHOLD {
+            let _ = client.get_streams_by_category("509658", None, 10).await;
+        }
+
+        assert_eq!(client.circuit_state(), CircuitState::Closed);
+    }
+
+    #[tokio::test]
+    async fn circuit_shared_between_clones() {
+        let client =
+            TwitchClient::with_http_client("test_client_id".to_string(), MockHttpClient::new());
+        let clone = client.clone();
+
+        trip_breaker(&client, Utc::now());
+
+        assert!(matches!(clone.circuit_state(), CircuitState::Open { .. }));
+    }
+
+    // === tag filter tests ===
+
+    #[tokio::test]
+    async fn category_streams_filtered_by_tags() {
+        let mut drops = make_stream("1", "DropsStreamer");
+        drops.tags = vec!["English".to_string(), "DropsEnabled".to_string()];
+        let mut english = make_stream("2", "EnglishStreamer");
+        english.tags = vec!["English".to_string()];
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=20",
+            &make_streams_response(vec![drops, english], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let tags = vec!["drops".to_string(), "english".to_string()];
+        let result = client
+            .get_streams_by_category_with_tags("509658", None, &tags, 2)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 1);
+        assert_eq!(result[0].user_name, "DropsStreamer");
+    }
+
+    // === get_stream_by_user_id tests ===
+
+    #[tokio::test]
+    async fn get_stream_by_user_id_returns_live_stream() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?user_id=1",
+            &make_streams_response(vec![make_stream("1", "StreamerOne")], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let stream = client.get_stream_by_user_id("1").await.unwrap().unwrap();
+
+        assert_eq!(stream.user_name, "StreamerOne");
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn get_stream_by_user_id_offline_is_none() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?user_id=1",
+            &make_streams_response(vec![], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        assert!(client.get_stream_by_user_id("1").await.unwrap().is_none());
+    }
+
+    // === get_streams_by_user_ids tests ===
+
+    fn user_ids_url(ids: &[String]) -> String {
+        let params: Vec<String> = ids.iter().map(|id| format!("user_id={id}")).collect();
+        format!(
+            "https://api.twitch.tv/helix/streams?{}&first=100",
+            params.join("&")
+        )
+    }
+
+    #[tokio::test]
+    async fn get_streams_by_user_ids_fetches_batches_concurrently() {
+        let ids: Vec<String> = (1..=300).map(|i| i.to_string()).collect();
+        let mut mock = MockHttpClient::new().with_delay(std::time::Duration::from_millis(100));
+        for chunk in ids.chunks(100) {
+            mock = mock.on_get_json(
+                &user_ids_url(chunk),
+                &make_streams_response(vec![make_stream(&chunk[0], "Streamer")], None),
+            );
+        }
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let started = std::time::Instant::now();
+        let streams = client.get_streams_by_user_ids(&ids).await.unwrap();
+        let elapsed = started.elapsed();
+
+        // Serially this would take 3 x 100ms
+        assert!(
+            elapsed < std::time::Duration::from_millis(250),
+            "took {elapsed:?}"
+        );
+        let user_ids: Vec<&str> = streams.iter().map(|s| s.user_id.as_str()).collect();
+        assert_eq!(user_ids, vec!["1", "101", "201"]);
+        assert_eq!(mock.get_requests().len(), 3);
+    }
+
+    #[tokio::test]
+    async fn get_streams_by_user_ids_keeps_successful_batches() {
+        let ids: Vec<String> = (1..=150).map(|i| i.to_string()).collect();
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                &user_ids_url(&ids[..100]),
+                &make_streams_response(vec![make_stream("1", "StreamerOne")], None),
+            )
+            .on_get(&user_ids_url(&ids[100..]), 400, "Bad Request");
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let streams = client.get_streams_by_user_ids(&ids).await.unwrap();
+
+        assert_eq!(streams.len(), 1);
+    }
+
+    #[tokio::test]
+    async fn get_streams_by_user_ids_surfaces_unauthorized() {
+        let ids: Vec<String> = (1..=150).map(|i| i.to_string()).collect();
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                &user_ids_url(&ids[..100]),
+                &make_streams_response(vec![], None),
+            )
+            .on_get(&user_ids_url(&ids[100..]), 401, "Unauthorized");
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_streams_by_user_ids(&ids).await;
+
+        assert!(matches!(result, Err(ApiError::Unauthorized)));
+    }
+
+    // === metrics tests ===
+
+    #[tokio::test]
+    async fn metrics_count_requests_and_errors_per_endpoint() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+                &make_streams_response(vec![], None),
+            )
+            .on_get(
+                "https://api.twitch.tv/helix/streams?game_id=1&first=10",
+                400,
+                "Bad Request",
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+        assert!(client.metrics().is_empty());
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+        let _ = client.get_streams_by_category("1", None, 10).await;
+
+        let metrics = &client.metrics()["/streams"];
+        assert_eq!(metrics.requests, 2);
+        assert_eq!(metrics.errors, 1);
+        assert_eq!(metrics.last_error.as_deref(), Some("HTTP 400"));
+        assert!(metrics.last_success_at.is_some());
+    }
+
+    #[tokio::test]
+    async fn metrics_shared_between_clones() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            &make_streams_response(vec![], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        let clone = client.clone();
+        client.set_access_token("test_token".to_string()).await;
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(clone.metrics()["/streams"].requests, 1);
+    }
+
+    // === games cache tests ===
+
+    fn game(id: &str, name: &str) -> Category {
+        Category {
+            id: id.to_string(),
+            name: name.to_string(),
+            box_art_url: format!("https://example.com/{id}.jpg"),
+        }
+    }
+
+    #[tokio::test]
+    async fn get_games_by_ids_served_from_cache() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/games?id=1&id=2",
+            &GamesResponse {
+                data: vec![game("1", "One"), game("2", "Two")],
+            },
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        client.get_games_by_ids(&["1", "2"]).await.unwrap();
+        let result = client.get_games_by_ids(&["2", "1"]).await.unwrap();
+
+        assert_eq!(mock.get_requests().len(), 1);
+        assert_eq!(result[0].name, "Two");
+        assert_eq!(result[1].name, "One");
+    }
+
+    #[tokio::test]
+    async fn get_games_by_ids_fetches_only_misses_in_input_order() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/games?id=1",
+                &GamesResponse {
+                    data: vec![game("1", "One")],
+                },
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/games?id=3&id=2",
+                &GamesResponse {
+                    data: vec![game("2", "Two"), game("3", "Three")],
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        client.get_games_by_ids(&["1"]).await.unwrap();
+        let result = client.get_games_by_ids(&["3", "1", "2"]).await.unwrap();
+
+        let names: Vec<_> = result.iter().map(|g| g.name.as_str()).collect();
+        assert_eq!(names, vec!["Three", "One", "Two"]);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn get_games_by_ids_deduplicates_input() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/games?id=1&id=2",
+            &GamesResponse {
+                data: vec![game("1", "One"), game("2", "Two")],
+            },
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .get_games_by_ids(&["1", "2", "1", "1"])
+            .await
+            .unwrap();
+
+        let names: Vec<_> = result.iter().map(|g| g.name.as_str()).collect();
+        assert_eq!(names, vec!["One", "Two"]);
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn get_games_by_ids_chunks_over_100() {
+        let ids: Vec<String> = (1..=150).map(|i| i.to_string()).collect();
+        let id_refs: Vec<&str> = ids.iter().map(String::as_str).collect();
+        let games = |chunk: &[&str]| GamesResponse {
+            data: chunk
+                .iter()
+                .map(|id| game(id, &format!("Game {id}")))
+                .collect(),
+        };
+        let url = |chunk: &[&str]| {
+            let params: Vec<String> = chunk.iter().map(|id| format!("id={id}")).collect();
+            format!("https://api.twitch.tv/helix/games?{}", params.join("&"))
+        };
+        let mock = MockHttpClient::new()
+            .on_get_json(&url(&id_refs[..100]), &games(&id_refs[..100]))
+            .on_get_json(&url(&id_refs[100..]), &games(&id_refs[100..]));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_games_by_ids(&id_refs).await.unwrap();
+
+        let returned: Vec<&str> = result.iter().map(|g| g.id.as_str()).collect();
+        assert_eq!(returned, id_refs);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn expired_games_are_fetched_again() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/games?id=1",
+            &GamesResponse {
+                data: vec![game("1", "One")],
+            },
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+        client.set_games_cache_ttl(Duration::ZERO);
+
+        client.get_games_by_ids(&["1"]).await.unwrap();
+        client.get_games_by_ids(&["1"]).await.unwrap();
+
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    // === schedule tests ===
+
+    fn schedule_start() -> DateTime<Utc> {
+        "2026-10-16T12:00:00Z".parse().unwrap()
+    }
+
+    fn schedule_until() -> DateTime<Utc> {
+        schedule_start() + chrono::Duration::hours(24)
+    }
+
+    fn schedule_url(broadcaster_id: &str) -> String {
+        format!(
+            "https://api.twitch.tv/helix/schedule?broadcaster_id={broadcaster_id}\
+             &start_time=2026-10-16T12%3A00%3A00Z&first=25"
+        )
+    }
+
+    fn segment_json(id: &str, hours_from_start: i64) -> serde_json::Value {
+        let start = schedule_start() + chrono::Duration::hours(hours_from_start);
+        serde_json::json!({
+            "id": id,
+            "start_time": start,
+            "end_time": start + chrono::Duration::hours(2),
+            "title": format!("Stream {id}"),
+            "is_recurring": true,
+        })
+    }
+
+    fn schedule_page(segments: Vec<serde_json::Value>, cursor: Option<&str>) -> String {
+        serde_json::json!({
+            "data": {
+                "segments": segments,
+                "broadcaster_id": "1",
+                "broadcaster_name": "Streamer1",
+                "broadcaster_login": "streamer1",
+            },
+            "pagination": { "cursor": cursor },
+        })
+        .to_string()
+    }
+
+    fn segment_ids(data: &ScheduleData) -> Vec<&str> {
+        data.segments
+            .as_ref()
+            .unwrap()
+            .iter()
+            .map(|seg| seg.id.as_str())
+            .collect()
+    }
+
+    #[tokio::test]
+    async fn schedule_paginates_until_cutoff() {
+        let url = schedule_url("1");
+        let mock = MockHttpClient::new()
+            .on_get(
+                &url,
+                200,
+                schedule_page(vec![segment_json("a", 0), segment_json("b", 8)], Some("c1")),
+            )
+            .on_get(
+                &format!("{url}&after=c1"),
+                200,
+                schedule_page(
+                    vec![segment_json("c", 16), segment_json("d", 30)],
+                    Some("c2"),
+                ),
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let data = client
+            .get_schedule("1", schedule_start(), schedule_until())
+            .await
+            .unwrap()
+            .unwrap();
+
+        assert_eq!(segment_ids(&data), vec!["a", "b", "c"]);
+        // Page two crossed the cutoff, so the third page is never requested
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn schedule_boundary_segments_included_once() {
+        let url = schedule_url("1");
+        let mock = MockHttpClient::new()
+            .on_get(
+                &url,
+                200,
+                schedule_page(vec![segment_json("a", 0), segment_json("b", 4)], Some("c1")),
+            )
+            .on_get(
+                &format!("{url}&after=c1"),
+                200,
+                schedule_page(vec![segment_json("b", 4), segment_json("c", 24)], None),
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let data = client
+            .get_schedule("1", schedule_start(), schedule_until())
+            .await
+            .unwrap()
+            .unwrap();
+
+        // "a" starts exactly at the window start; "c" exactly at the cutoff
+        assert_eq!(segment_ids(&data), vec!["a", "b"]);
+    }
+
+    #[tokio::test]
+    async fn schedule_without_segments_is_empty() {
+        let mock = MockHttpClient::new().on_get(
+            &schedule_url("1"),
+            200,
+            serde_json::json!({
+                "data": {
+                    "segments": null,
+                    "broadcaster_id": "1",
+                    "broadcaster_name": "Streamer1",
+                    "broadcaster_login": "streamer1",
+                },
+                "pagination": {},
+            })
+            .to_string(),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let data = client
+            .get_schedule("1", schedule_start(), schedule_until())
+            .await
+            .unwrap()
+            .unwrap();
+
+        assert!(segment_ids(&data).is_empty());
+    }
+
+    fn schedule_body(broadcaster_id: &str) -> String {
+        serde_json::json!({
+            "data": {
+                "segments": [],
+                "broadcaster_id": broadcaster_id,
+                "broadcaster_name": format!("Streamer{broadcaster_id}"),
+                "broadcaster_login": format!("streamer{broadcaster_id}"),
+            }
+        })
+        .to_string()
+    }
+
+    #[tokio::test]
+    async fn get_schedules_runs_concurrently_and_keeps_order() {
+        let ids: Vec<String> = (1..=10).map(|i| i.to_string()).collect();
+        let mut mock = MockHttpClient::new().with_delay(std::time::Duration::from_millis(100));
+        for id in &ids {
+            mock = mock.on_get(&schedule_url(id), 200, schedule_body(id));
+        }
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let started = std::time::Instant::now();
+        let results = client
+            .get_schedules(&ids, schedule_start(), schedule_until(), 5)
+            .await;
+        let elapsed = started.elapsed();
+
+        // Serially this would take 10 x 100ms; five at a time takes about 200ms
+        assert!(
+            elapsed < std::time::Duration::from_millis(600),
+            "took {elapsed:?}"
+        );
+        let returned: Vec<String> = results
+            .into_iter()
+            .map(|r| r.unwrap().unwrap().broadcaster_id)
+            .collect();
+        assert_eq!(returned, ids);
+    }
+
+    #[tokio::test]
+    async fn get_schedules_isolates_failures() {
+        let mock = MockHttpClient::new()
+            .on_get(&schedule_url("1"), 200, schedule_body("1"))
+            .on_get(&schedule_url("2"), 401, "Unauthorized")
+            .on_get_not_found(&schedule_url("3"));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let ids = vec!["1".to_string(), "2".to_string(), "3".to_string()];
+        let results = client
+            .get_schedules(&ids, schedule_start(), schedule_until(), 5)
+            .await;
+
+        assert!(matches!(results[0], Ok(Some(_))));
+        assert!(matches!(results[1], Err(ApiError::Unauthorized)));
+        assert!(matches!(results[2], Ok(None)));
+    }
+
+    #[tokio::test]
+    async fn missing_schedule_is_remembered_until_ttl_expires() {
+        let mock = MockHttpClient::new()
+            .on_get_not_found(&schedule_url("1"))
+            .on_get(&schedule_url("2"), 200, schedule_body("2"));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        for id in ["1", "2"] {
+            client
+                .get_schedule(id, schedule_start(), schedule_until())
+                .await
+                .unwrap();
+        }
+
+        assert!(client.has_no_schedule("1"));
+        assert!(!client.has_no_schedule("2"));
+
+        client.set_no_schedule_ttl(Duration::ZERO);
+        assert!(!client.has_no_schedule("1"));
+    }
+
+    #[tokio::test]
+    async fn missing_schedule_is_cleared_by_refresh_or_new_schedule() {
+        let mock = MockHttpClient::new()
+            .on_get_once(&schedule_url("1"), 404, HeaderMap::new(), "Not Found")
+            .on_get(&schedule_url("1"), 200, schedule_body("1"))
+            .on_get_not_found(&schedule_url("2"));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        for id in ["1", "1", "2"] {
+            client
+                .get_schedule(id, schedule_start(), schedule_until())
+                .await
+                .unwrap();
+        }
+        assert!(!client.has_no_schedule("1"));
+        assert!(client.has_no_schedule("2"));
+
+        client.forget_missing_schedules();
+        assert!(!client.has_no_schedule("2"));
+    }
+
+    // === search_channels tests ===
+
+    /// Trimmed response recorded from the Helix search channels endpoint
+    const SEARCH_CHANNELS_FIXTURE: &str = r#"{
+        "data": [
+            {
+                "broadcaster_language": "en",
+                "broadcaster_login": "loserfruit",
+                "display_name": "Loserfruit",
+                "game_id": "498000",
+                "game_name": "House Flipper",
+                "id": "41245072",
+                "is_live": false,
+                "tag_ids": [],
+                "tags": ["English"],
+                "thumbnail_url": "https://static-cdn.jtvnw.net/jtv_user_pictures/loserfruit.png",
+                "title": "loserfruit",
+                "started_at": ""
+            },
+            {
+                "broadcaster_language": "en",
+                "broadcaster_login": "loserfruitlive",
+                "display_name": "LoserfruitLive",
+                "game_id": "",
+                "game_name": "",
+                "id": "41245073",
+                "is_live": true,
+                "tag_ids": [],
+                "tags": [],
+                "thumbnail_url": "",
+                "title": "Just vibing",
+                "started_at": "2026-10-16T10:00:00Z"
+            }
+        ],
+        "pagination": {"cursor": "eyJiIjpudWxsLCJhIjp7Ik9mZnNldCI6Mn19"}
+    }"#;
+
+    #[tokio::test]
+    async fn search_channels_maps_fixture() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/search/channels?query=loserfruit&first=2",
+            200,
+            SEARCH_CHANNELS_FIXTURE,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .search_channels("loserfruit", false, 2)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 2);
+        assert_eq!(result[0].broadcaster_login, "loserfruit");
+        assert_eq!(result[0].game_name, "House Flipper");
+        assert!(!result[0].is_live);
+        assert!(result[1].is_live);
+        assert_eq!(result[1].title, "Just vibing");
+    }
+
+    #[tokio::test]
+    async fn search_channels_paginates_up_to_limit() {
+        let mock = MockHttpClient::new()
+            .on_get(
+                "https://api.twitch.tv/helix/search/channels?query=loserfruit&live_only=true&first=3",
+                200,
+                SEARCH_CHANNELS_FIXTURE,
+            )
+            .on_get(
+                "https://api.twitch.tv/helix/search/channels?query=loserfruit&live_only=true&first=1&after=eyJiIjpudWxsLCJhIjp7Ik9mZnNldCI6Mn19",
+                200,
+                SEARCH_CHANNELS_FIXTURE,
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.search_channels("loserfruit", true, 3).await.unwrap();
+
+        assert_eq!(result.len(), 3);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn search_channels_empty_query_makes_no_request() {
+        let mock = MockHttpClient::new();
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.search_channels("   ", false, 10).await.unwrap();
+
+        assert!(result.is_empty());
+        assert!(mock.get_requests().is_empty());
+    }
+
+    #[tokio::test]
+    async fn search_channels_surfaces_api_errors() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/search/channels?query=x&first=10",
+            400,
+            r#"{"error":"Bad Request","status":400,"message":"Invalid query"}"#,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.search_channels("x", false, 10).await;
+
+        assert!(result.unwrap_err().to_string().contains("400"));
+    }
+
+    // === search_categories limit and paging tests ===
+
+    #[tokio::test]
+    async fn search_categories_no_results() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/search/categories?query=zzzz&first=10",
+            200,
+            r#"{"data":[],"pagination":{}}"#,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.search_categories("  zzzz ", 10).await.unwrap();
+
+        assert!(result.is_empty());
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    #[tokio::test]
+    async fn search_categories_follows_pages_up_to_limit() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/search/categories?query=mine&first=3",
+                &SearchCategoriesResponse {
+                    data: vec![game("1", "Minecraft"), game("2", "Minecraft Dungeons")],
+                    pagination: Some(Pagination {
+                        cursor: Some("page2".to_string()),
+                    }),
+                },
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/search/categories?query=mine&first=1&after=page2",
+                &SearchCategoriesResponse {
+                    data: vec![game("3", "Minesweeper")],
+                    pagination: Some(Pagination {
+                        cursor: Some("page3".to_string()),
+                    }),
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.search_categories("mine", 3).await.unwrap();
+
+        let names: Vec<_> = result.iter().map(|c| c.name.as_str()).collect();
+        assert_eq!(
+            names,
+            vec!["Minecraft", "Minecraft Dungeons", "Minesweeper"]
+        );
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn search_categories_caps_limit_at_100() {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/search/categories?query=a&first=100",
+            200,
+            r#"{"data":[]}"#,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        assert!(client.search_categories("a", 500).await.is_ok());
+    }
+
+    #[tokio::test]
+    async fn search_categories_exact_match_warms_games_cache() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/search/categories?query=minecraft&first=10",
+            &SearchCategoriesResponse {
+                data: vec![game("1", "Minecraft"), game("2", "Minecraft Dungeons")],
+                pagination: None,
+            },
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        client.search_categories("minecraft", 10).await.unwrap();
+        let games = client.get_games_by_ids(&["1"]).await.unwrap();
+
+        assert_eq!(games[0].name, "Minecraft");
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    // === get_top_games tests ===
+
+    #[tokio::test]
+    async fn get_top_games_paginates_without_counts() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/games/top?first=3",
+                &SearchCategoriesResponse {
+                    data: vec![game("1", "Just Chatting"), game("2", "Fortnite")],
+                    pagination: Some(Pagination {
+                        cursor: Some("next".to_string()),
+                    }),
+                },
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/games/top?first=1&after=next",
+                &SearchCategoriesResponse {
+                    data: vec![game("3", "Minecraft")],
+                    pagination: None,
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_top_games(3, 0).await.unwrap();
+
+        let names: Vec<_> = result.iter().map(|g| g.category.name.as_str()).collect();
+        assert_eq!(names, vec!["Just Chatting", "Fortnite", "Minecraft"]);
+        assert!(result.iter().all(|g| g.live_streams.is_none()));
+        assert_eq!(mock.get_requests().len(), 2);
+
+        client.get_games_by_ids(&["3"]).await.unwrap();
+        assert_eq!(mock.get_requests().len(), 2, "served from the cache");
+    }
+
+    #[tokio::test]
+    async fn get_top_games_counts_streams_for_first_games() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/games/top?first=2",
+                &SearchCategoriesResponse {
+                    data: vec![game("1", "Just Chatting"), game("2", "Fortnite")],
+                    pagination: None,
+                },
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/streams?game_id=1&first=100",
+                &make_streams_response(
+                    vec![make_stream("a", "StreamerA"), make_stream("b", "StreamerB")],
+                    None,
+                ),
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_top_games(2, 1).await.unwrap();
+
+        assert_eq!(result[0].live_streams, Some(2));
+        assert_eq!(result[1].live_streams, None);
+    }
+
+    // === get_clips tests ===
+
+    const CLIPS_FIXTURE: &str = r#"{
+        "data": [
+            {
+                "id": "AwkwardHelplessSalamanderSwiftRage",
+                "url": "https://clips.twitch.tv/AwkwardHelplessSalamanderSwiftRage",
+                "embed_url": "https://clips.twitch.tv/embed?clip=AwkwardHelplessSalamanderSwiftRage",
+                "broadcaster_id": "67955580",
+                "broadcaster_name": "ChewieMelodies",
+                "creator_id": "53834192",
+                "creator_name": "BlackNova03",
+                "video_id": "205586603",
+                "game_id": "488191",
+                "language": "en",
+                "title": "babymetal",
+                "view_count": 10,
+                "created_at": "2026-10-12T03:12:31Z",
+                "thumbnail_url": "https://clips-media-assets.twitch.tv/157589949-preview-480x272.jpg",
+                "duration": 28.3,
+                "vod_offset": 480,
+                "is_featured": false
+            },
+            {
+                "id": "FunnyClip",
+                "url": "https://clips.twitch.tv/FunnyClip",
+                "title": "the fall",
+                "view_count": 250,
+                "created_at": "2026-10-13T20:00:00Z",
+                "thumbnail_url": "",
+                "duration": 12.0
+            }
+        ],
+        "pagination": {}
+    }"#;
+
+    #[tokio::test]
+    async fn get_clips_sorted_by_views() {
+        let started_at = chrono::TimeZone::with_ymd_and_hms(&Utc, 2026, 10, 9, 0, 0, 0).unwrap();
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/clips?broadcaster_id=67955580&started_at=2026-10-09T00%3A00%3A00Z&first=5",
+            200,
+            CLIPS_FIXTURE,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let clips = client.get_clips("67955580", started_at, 5).await.unwrap();
+
+        assert_eq!(clips.len(), 2);
+        assert_eq!(clips[0].id, "FunnyClip");
+        assert_eq!(clips[0].view_count, 250);
+        assert_eq!(clips[1].title, "babymetal");
+        assert!((clips[1].duration - 28.3).abs() < f64::EPSILON);
+    }
+
+    #[tokio::test]
+    async fn get_clips_capped_at_limit() {
+        let started_at = chrono::TimeZone::with_ymd_and_hms(&Utc, 2026, 10, 9, 0, 0, 0).unwrap();
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/clips?broadcaster_id=67955580&started_at=2026-10-09T00%3A00%3A00Z&first=1",
+            200,
+            CLIPS_FIXTURE,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let clips = client.get_clips("67955580", started_at, 1).await.unwrap();
+
+        assert_eq!(clips.len(), 1);
+    }
+
+    #[tokio::test]
+    async fn get_clips_unauthorized() {
+        let started_at = chrono::TimeZone::with_ymd_and_hms(&Utc, 2026, 10, 9, 0, 0, 0).unwrap();
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/clips?broadcaster_id=1&started_at=2026-10-09T00%3A00%3A00Z&first=5",
+            401,
+            "Unauthorized",
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_clips("1", started_at, 5).await;
+
+        assert!(matches!(result, Err(ApiError::Unauthorized)));
+    }
+
+    // === get_channel_info tests ===
+
+    fn channel_info_url(ids: &[String]) -> String {
+        let params: Vec<String> = ids
+            .iter()
+            .map(|id| format!("broadcaster_id={id}"))
+            .collect();
+        format!("https://api.twitch.tv/helix/channels?{}", params.join("&"))
+    }
+
+    fn channel_info_body(ids: &[String]) -> String {
+        let data: Vec<_> = ids
+            .iter()
+            .map(|id| {
+                serde_json::json!({
+                    "broadcaster_id": id,
+                    "broadcaster_login": format!("streamer{id}"),
+                    "broadcaster_name": format!("Streamer{id}"),
+                    "broadcaster_language": "en",
+                    "game_id": "509658",
+                    "game_name": "Just Chatting",
+                    "title": format!("Title {id}"),
+                    "delay": 0,
+                    "tags": []
+                })
+            })
+            .collect();
+        serde_json::json!({ "data": data }).to_string()
+    }
+
+    #[tokio::test]
+    async fn get_channel_info_maps_fields() {
+        let ids = vec!["141981764".to_string()];
+        let mock =
+            MockHttpClient::new().on_get(&channel_info_url(&ids), 200, channel_info_body(&ids));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_channel_info(&["141981764"]).await.unwrap();
+
+        assert_eq!(result.len(), 1);
+        assert_eq!(result[0].broadcaster_id, "141981764");
+        assert_eq!(result[0].title, "Title 141981764");
+        assert_eq!(result[0].game_name, "Just Chatting");
+        assert_eq!(result[0].language, "en");
+    }
+
+    #[tokio::test]
+    async fn get_channel_info_batches_by_100() {
+        let ids: Vec<String> = (1..=150).map(|i| i.to_string()).collect();
+        let (first, second) = ids.split_at(100);
+        let mock = MockHttpClient::new()
+            .on_get(&channel_info_url(first), 200, channel_info_body(first))
+            .on_get(&channel_info_url(second), 200, channel_info_body(second));
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let id_refs: Vec<&str> = ids.iter().map(String::as_str).collect();
+        let result = client.get_channel_info(&id_refs).await.unwrap();
+
+        assert_eq!(result.len(), 150);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn get_channel_info_empty_makes_no_request() {
+        let mock = MockHttpClient::new();
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        assert!(client.get_channel_info(&[]).await.unwrap().is_empty());
+        assert!(mock.get_requests().is_empty());
+    }
+
+    #[tokio::test]
+    async fn get_channel_info_surfaces_api_errors() {
+        let ids = vec!["1".to_string()];
+        let mock = MockHttpClient::new().on_get(
+            &channel_info_url(&ids),
+            400,
+            r#"{"error":"Bad Request","status":400,"message":"Missing required parameter"}"#,
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client.get_channel_info(&["1"]).await;
+
+        assert!(result
+            .unwrap_err()
+            .to_string()
+            .contains("Missing required parameter"));
+    }
+
+    // === user cache tests ===
+
+    fn user(id: &str, login: &str) -> User {
+        User {
+            id: id.to_string(),
+            login: login.to_string(),
+            display_name: login.to_string(),
+            profile_image_url: format!("https://example.com/{id}.png"),
+        }
+    }
+
+    #[tokio::test]
+    async fn get_user_profiles_fetches_only_misses() {
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/users?id=1",
+                &UsersResponse {
+                    data: vec![user("1", "one")],
+                },
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/users?id=2",
+                &UsersResponse {
+                    data: vec![user("2", "two")],
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        client.get_user_profiles(&["1"]).await.unwrap();
+        let profiles = client.get_user_profiles(&["1", "2"]).await.unwrap();
+
+        assert_eq!(profiles["1"], "https://example.com/1.png");
+        assert_eq!(profiles["2"], "https://example.com/2.png");
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn get_users_by_ids_chunks_at_100() {
+        let ids: Vec<String> = (1..=120).map(|i| i.to_string()).collect();
+        let url = |ids: &[String]| {
+            let params: Vec<String> = ids.iter().map(|id| format!("id={id}")).collect();
+            format!("https://api.twitch.tv/helix/users?{}", params.join("&"))
+        };
+        let (first, second) = ids.split_at(100);
+        let mock = MockHttpClient::new()
+            .on_get_json(&url(first), &UsersResponse { data: vec![] })
+            .on_get_json(
+                &url(second),
+                &UsersResponse {
+                    data: vec![user("120", "last")],
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let id_refs: Vec<&str> = ids.iter().map(String::as_str).collect();
+        let users = client.get_users_by_ids(&id_refs).await.unwrap();
+
+        assert_eq!(users.len(), 1);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn users_looked_up_by_login() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/users?id=1",
+            &UsersResponse {
+                data: vec![user("1", "streamerone")],
+            },
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        client.get_users_by_ids(&["1"]).await.unwrap();
+
+        assert_eq!(client.cached_user_by_login("StreamerOne").unwrap().id, "1");
+        assert!(client.cached_user_by_login("nobody").is_none());
+    }
+
+    #[tokio::test]
+    async fn followed_channels_warm_user_cache_without_images() {
+        let mock = MockHttpClient::new()
+            .on_get(
+                "https://api.twitch.tv/helix/channels/followed?user_id=user123&first=100",
+                200,
+                r#"{"data":[{"broadcaster_id":"1","broadcaster_login":"streamerone","broadcaster_name":"StreamerOne","followed_at":"2026-01-01T00:00:00Z"}]}"#,
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/users?id=1",
+                &UsersResponse {
+                    data: vec![user("1", "streamerone")],
+                },
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+        client.set_user_id("user123".to_string()).await;
+
+        client.get_all_followed_channels().await.unwrap();
+        assert_eq!(client.cached_user_by_login("streamerone").unwrap().id, "1");
+
+        // The follow list has no profile images, so those still need a lookup
+        let profiles = client.get_user_profiles(&["1"]).await.unwrap();
+        assert_eq!(profiles["1"], "https://example.com/1.png");
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    // === cancellation tests ===
+
+    #[tokio::test]
+    async fn cancel_requests_aborts_hung_request() {
+        let mock = MockHttpClient::new()
+            .with_delay(std::time::Duration::from_secs(30))
+            .on_get_json(
+                "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+                &make_streams_response(vec![], None),
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let request_client = client.clone();
+        let request = tokio::spawn(async move {
+            request_client
+                .get_streams_by_category("509658", None, 10)
+                .await
+        });
+        tokio::time::sleep(std::time::Duration::from_millis(50)).await;
+
+        let started = std::time::Instant::now();
+        client.cancel_requests();
+        let result = request.await.unwrap();
+
+        assert!(started.elapsed() < std::time::Duration::from_secs(1));
+        assert!(result.unwrap_err().to_string().contains("cancelled"));
+    }
+
+    #[tokio::test]
+    async fn requests_after_cancel_still_work() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            &make_streams_response(vec![make_stream("1", "StreamerOne")], None),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+        client.cancel_requests();
+
+        let result = client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 1);
+    }
+
+    // === category stream pagination tests ===
+
+    #[tokio::test]
+    async fn get_streams_by_category_paginates_past_100() {
+        let page1: Vec<Stream> = (0..100)
+            .map(|i| make_stream(&i.to_string(), "Streamer"))
+            .collect();
+        let page2: Vec<Stream> = (100..200)
+            .map(|i| make_stream(&i.to_string(), "Streamer"))
+            .collect();
+        let mock = MockHttpClient::new()
+            .on_get_json(
+                "https://api.twitch.tv/helix/streams?game_id=509658&first=100",
+                &make_streams_response(page1, Some("page2")),
+            )
+            .on_get_json(
+                "https://api.twitch.tv/helix/streams?game_id=509658&first=50&after=page2",
+                &make_streams_response(page2, Some("page3")),
+            );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .get_streams_by_category("509658", None, 150)
+            .await
+            .unwrap();
+
+        assert_eq!(result.len(), 150);
+        assert_eq!(mock.get_requests().len(), 2);
+    }
+
+    #[tokio::test]
+    async fn get_streams_by_category_small_limit_is_one_request() {
+        let mock = MockHttpClient::new().on_get_json(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            &make_streams_response(vec![make_stream("1", "StreamerOne")], Some("more")),
+        );
+
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock.clone());
+        client.set_access_token("test_token".to_string()).await;
+
+        client
+            .get_streams_by_category("509658", None, 10)
+            .await
+            .unwrap();
+
+        assert_eq!(mock.get_requests().len(), 1);
+    }
+
+    // === typed error tests ===
+
+    async fn category_request_with_status(
+        status: u16,
+        body: &str,
+    ) -> Result<Vec<Stream>, ApiError> {
+        let mock = MockHttpClient::new().on_get(
+            "https://api.twitch.tv/helix/streams?game_id=509658&first=10",
+            status,
+            body,
+        );
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+        client.get_streams_by_category("509658", None, 10).await
+    }
+
+    #[tokio::test]
+    async fn unauthorized_is_typed() {
+        let result = category_request_with_status(401, "Unauthorized").await;
+        assert!(matches!(result, Err(ApiError::Unauthorized)));
+    }
+
+    #[tokio::test]
+    async fn not_found_is_typed() {
+        let result = category_request_with_status(404, "Not Found").await;
+        assert!(matches!(result, Err(ApiError::NotFound { .. })));
+    }
+
+    #[tokio::test]
+    async fn rate_limited_is_typed() {
+        let result = category_request_with_status(429, "Too Many Requests").await;
+        assert!(matches!(result, Err(ApiError::RateLimited { .. })));
+    }
+
+    #[tokio::test]
+    async fn other_status_carries_helix_message() {
+        let result = category_request_with_status(
+            400,
+            r#"{"error":"Bad Request","status":400,"message":"Invalid game_id"}"#,
+        )
+        .await;
+
+        match result {
+            Err(ApiError::Status {
+                status,
+                endpoint,
+                message,
+            }) => {
+                assert_eq!(status, 400);
+                assert_eq!(endpoint, "/streams?game_id=509658&first=10");
+                assert_eq!(message, "Invalid game_id");
+            }
+            other => panic!("expected Status error, got {other:?}"),
+        }
+    }
+
+    #[tokio::test]
+    async fn schedule_server_error_is_not_treated_as_missing() {
+        let mock = MockHttpClient::new().on_get(&schedule_url("1"), 403, "Forbidden");
+        let client = TwitchClient::with_http_client("test_client_id".to_string(), mock);
+        client.set_access_token("test_token".to_string()).await;
+
+        let result = client
+            .get_schedule("1", schedule_start(), schedule_until())
+            .await;
+
+        assert!(matches!(result, Err(ApiError::Status { status: 403, .. })));
+    }
 }
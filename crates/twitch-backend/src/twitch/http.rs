@@ -21,12 +21,24 @@ pub trait HttpClient: Send + Sync {
         url: &str,
         params: Vec<(String, String)>,
     ) -> Result<HttpResponse>;
+
+    /// Makes an unauthenticated POST request with a JSON body and returns the
+    /// raw response
+    async fn post_json_response(&self, url: &str, body: String) -> Result<HttpResponse>;
+
+    /// Makes an unauthenticated GET request for binary content (e.g. images),
+    /// returning the status and raw body bytes
+    async fn get_bytes(&self, url: &str) -> Result<(u16, Vec<u8>)> {
+        let response = self.get_response(url, &HeaderMap::new()).await?;
+        Ok((response.status, response.body.into_bytes()))
+    }
 }
 
 /// Response from an HTTP request
 #[derive(Debug)]
 pub struct HttpResponse {
     pub status: u16,
+    pub headers: HeaderMap,
     pub body: String,
 }
 
@@ -46,6 +58,11 @@ impl HttpResponse {
         self.status == 401
     }
 
+    /// Returns true if status is 429 Too Many Requests
+    pub fn is_rate_limited(&self) -> bool {
+        self.status == 429
+    }
+
     /// Deserializes the body as JSON
     pub fn json<T: DeserializeOwned>(&self) -> Result<T> {
         serde_json::from_str(&self.body).context("Failed to parse JSON response")
@@ -85,9 +102,14 @@ impl HttpClient for ReqwestClient {
             .context("Failed to send request")?;
 
         let status = response.status().as_u16();
+        let headers = response.headers().clone();
         let body = response.text().await.unwrap_or_default();
 
-        Ok(HttpResponse { status, body })
+        Ok(HttpResponse {
+            status,
+            headers,
+            body,
+        })
     }
 
     async fn post_form_response(
@@ -104,26 +126,72 @@ impl HttpClient for ReqwestClient {
             .context("Failed to send POST form request")?;
 
         let status = response.status().as_u16();
+        let headers = response.headers().clone();
+        let body = response.text().await.unwrap_or_default();
+
+        Ok(HttpResponse {
+            status,
+            headers,
+            body,
+        })
+    }
+
+    async fn post_json_response(&self, url: &str, body: String) -> Result<HttpResponse> {
+        let response = self
+            .inner
+            .post(url)
+            .header(reqwest::header::CONTENT_TYPE, "application/json")
+            .body(body)
+            .send()
+            .await
+            .context("Failed to send POST JSON request")?;
+
+        let status = response.status().as_u16();
+        let headers = response.headers().clone();
         let body = response.text().await.unwrap_or_default();
 
-        Ok(HttpResponse { status, body })
+        Ok(HttpResponse {
+            status,
+            headers,
+            body,
+        })
+    }
+
+    async fn get_bytes(&self, url: &str) -> Result<(u16, Vec<u8>)> {
+        let response = self
+            .inner
+            .get(url)
+            .send()
+            .await
+            .context("Failed to send request")?;
+
+        let status = response.status().as_u16();
+        let body = response
+            .bytes()
+            .await
+            .context("Failed to read response body")?;
+        Ok((status, body.to_vec()))
     }
 }
 
 #[cfg(test)]
 pub mod mock {
     use super::*;
-    use std::collections::HashMap;
+    use std::collections::{HashMap, VecDeque};
     use std::sync::{Arc, RwLock};
 
     /// Mock HTTP client for testing
     ///
-    /// Allows setting up canned responses for specific URLs.
+    /// Allows setting up canned responses for specific URLs. One-shot responses
+    /// queued with [`MockHttpClient::on_get_once`] are served first, in order,
+    /// before falling back to the standing response for the URL.
     #[derive(Debug, Clone, Default)]
     pub struct MockHttpClient {
         responses: Arc<RwLock<HashMap<String, MockResponse>>>,
+        queued: Arc<RwLock<HashMap<String, VecDeque<MockResponse>>>>,
         responses_post: Arc<RwLock<HashMap<String, MockResponse>>>,
         requests: Arc<RwLock<Vec<RecordedRequest>>>,
+        delay: Option<std::time::Duration>,
     }
 
     /// A recorded HTTP request
@@ -131,15 +199,28 @@ pub mod mock {
     pub struct RecordedRequest {
         pub url: String,
         pub headers: HeaderMap,
+        /// Body of a JSON POST; `None` for GETs
+        pub body: Option<String>,
     }
 
     /// A mock response configuration
     #[derive(Debug, Clone)]
     struct MockResponse {
         status: u16,
+        headers: HeaderMap,
         body: String,
     }
 
+    impl MockResponse {
+        fn to_response(&self) -> HttpResponse {
+            HttpResponse {
+                status: self.status,
+                headers: self.headers.clone(),
+                body: self.body.clone(),
+            }
+        }
+    }
+
     impl MockHttpClient {
         /// Creates a new mock client
         pub fn new() -> Self {
@@ -148,16 +229,49 @@ pub mod mock {
 
         /// Configures a response for a URL
         pub fn on_get(self, url: &str, status: u16, body: impl Into<String>) -> Self {
+            self.on_get_with_headers(url, status, HeaderMap::new(), body)
+        }
+
+        /// Configures a response with headers for a URL
+        pub fn on_get_with_headers(
+            self,
+            url: &str,
+            status: u16,
+            headers: HeaderMap,
+            body: impl Into<String>,
+        ) -> Self {
             self.responses.write().unwrap().insert(
                 url.to_string(),
                 MockResponse {
                     status,
+                    headers,
                     body: body.into(),
                 },
             );
             self
         }
 
+        /// Queues a response that is served once for a URL, ahead of the standing one
+        pub fn on_get_once(
+            self,
+            url: &str,
+            status: u16,
+            headers: HeaderMap,
+            body: impl Into<String>,
+        ) -> Self {
+            self.queued
+                .write()
+                .unwrap()
+                .entry(url.to_string())
+                .or_default()
+                .push_back(MockResponse {
+                    status,
+                    headers,
+                    body: body.into(),
+                });
+            self
+        }
+
         /// Configures a successful JSON response for a URL
         pub fn on_get_json<T: serde::Serialize>(self, url: &str, data: &T) -> Self {
             let body = serde_json::to_string(data).expect("Failed to serialize mock data");
@@ -175,6 +289,7 @@ pub mod mock {
                 url.to_string(),
                 MockResponse {
                     status,
+                    headers: HeaderMap::new(),
                     body: body.into(),
                 },
             );
@@ -187,6 +302,12 @@ pub mod mock {
             self.on_post(url, 200, body)
         }
 
+        /// Delays every GET response, simulating a slow server
+        pub fn with_delay(mut self, delay: std::time::Duration) -> Self {
+            self.delay = Some(delay);
+            self
+        }
+
         /// Returns all recorded requests
         pub fn get_requests(&self) -> Vec<RecordedRequest> {
             self.requests.read().unwrap().clone()
@@ -200,18 +321,31 @@ pub mod mock {
             self.requests.write().unwrap().push(RecordedRequest {
                 url: url.to_string(),
                 headers: headers.clone(),
+                body: None,
             });
 
+            if let Some(delay) = self.delay {
+                tokio::time::sleep(delay).await;
+            }
+
+            // Serve any queued one-shot response first
+            if let Some(queued) = self
+                .queued
+                .write()
+                .unwrap()
+                .get_mut(url)
+                .and_then(VecDeque::pop_front)
+            {
+                return Ok(queued.to_response());
+            }
+
             // Find matching response
             let responses = self.responses.read().unwrap();
             let mock_response = responses
                 .get(url)
                 .ok_or_else(|| anyhow::anyhow!("No mock response configured for URL: {}", url))?;
 
-            Ok(HttpResponse {
-                status: mock_response.status,
-                body: mock_response.body.clone(),
-            })
+            Ok(mock_response.to_response())
         }
 
         async fn post_form_response(
@@ -224,10 +358,22 @@ pub mod mock {
                 anyhow::anyhow!("No mock POST response configured for URL: {}", url)
             })?;
 
-            Ok(HttpResponse {
-                status: mock_response.status,
-                body: mock_response.body.clone(),
-            })
+            Ok(mock_response.to_response())
+        }
+
+        async fn post_json_response(&self, url: &str, body: String) -> Result<HttpResponse> {
+            self.requests.write().unwrap().push(RecordedRequest {
+                url: url.to_string(),
+                headers: HeaderMap::new(),
+                body: Some(body),
+            });
+
+            let responses = self.responses_post.read().unwrap();
+            let mock_response = responses.get(url).ok_or_else(|| {
+                anyhow::anyhow!("No mock POST response configured for URL: {}", url)
+            })?;
+
+            Ok(mock_response.to_response())
         }
     }
 }
@@ -308,28 +454,54 @@ mod tests {
         assert!(!response.is_success());
     }
 
+    #[tokio::test]
+    async fn mock_client_serves_queued_responses_first() {
+        let mut headers = HeaderMap::new();
+        headers.insert("Ratelimit-Remaining", "0".parse().unwrap());
+        let client = MockHttpClient::new()
+            .on_get("https://api.example.com/flaky", 200, "{}")
+            .on_get_once("https://api.example.com/flaky", 429, headers, "");
+
+        let first = client
+            .get_response("https://api.example.com/flaky", &HeaderMap::new())
+            .await
+            .unwrap();
+        assert!(first.is_rate_limited());
+        assert_eq!(first.headers.get("Ratelimit-Remaining").unwrap(), "0");
+
+        let second = client
+            .get_response("https://api.example.com/flaky", &HeaderMap::new())
+            .await
+            .unwrap();
+        assert!(second.is_success());
+    }
+
     #[test]
     fn http_response_is_success() {
         let response = HttpResponse {
             status: 200,
+            headers: HeaderMap::new(),
             body: "{}".to_string(),
         };
         assert!(response.is_success());
 
         let response = HttpResponse {
             status: 201,
+            headers: HeaderMap::new(),
             body: "{}".to_string(),
         };
         assert!(response.is_success());
 
         let response = HttpResponse {
             status: 404,
+            headers: HeaderMap::new(),
             body: "{}".to_string(),
         };
         assert!(!response.is_success());
 
         let response = HttpResponse {
             status: 500,
+            headers: HeaderMap::new(),
             body: "{}".to_string(),
         };
         assert!(!response.is_success());
@@ -339,6 +511,7 @@ mod tests {
     fn http_response_json_parsing() {
         let response = HttpResponse {
             status: 200,
+            headers: HeaderMap::new(),
             body: r#"{"name": "test", "value": 42}"#.to_string(),
         };
 
@@ -0,0 +1,191 @@
+//! Per-endpoint request counters for diagnosing API usage, and overall API
+//! health for the status display
+
+use chrono::{DateTime, Utc};
+use serde::Serialize;
+use std::collections::BTreeMap;
+use std::sync::Mutex;
+
+/// Request counters for one Helix endpoint
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct EndpointMetrics {
+    /// Requests made, including failed ones
+    pub requests: u64,
+    /// Requests that failed or got a non-2xx response
+    pub errors: u64,
+    /// Description of the most recent failure
+    pub last_error: Option<String>,
+    pub last_error_at: Option<DateTime<Utc>>,
+    pub last_success_at: Option<DateTime<Utc>>,
+}
+
+/// Point-in-time copy of the counters, keyed by endpoint path (e.g. `/streams`)
+pub type ApiMetrics = BTreeMap<String, EndpointMetrics>;
+
+/// The most recent failed request
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct ApiFailure {
+    /// Endpoint path the request went to
+    pub endpoint: String,
+    /// HTTP status, or `None` if no response came back
+    pub status: Option<u16>,
+    pub message: String,
+    pub at: DateTime<Utc>,
+}
+
+/// Whether requests to Twitch are currently getting through
+#[derive(Debug, Clone, Default, PartialEq, Eq, Serialize)]
+pub struct ApiHealth {
+    pub last_success_at: Option<DateTime<Utc>>,
+    pub last_error: Option<ApiFailure>,
+}
+
+impl ApiHealth {
+    /// Returns true if the latest request failed because Twitch couldn't be
+    /// reached or returned a server error
+    ///
+    /// Client errors such as 404 mean Twitch answered, so they don't count.
+    pub fn is_unreachable(&self) -> bool {
+        let Some(error) = &self.last_error else {
+            return false;
+        };
+        error.status.is_none_or(|status| status >= 500)
+            && self.last_success_at.is_none_or(|at| error.at >= at)
+    }
+}
+
+/// Counters shared by every request a client makes
+#[derive(Debug, Default)]
+pub struct MetricsRecorder {
+    endpoints: Mutex<ApiMetrics>,
+    health: Mutex<ApiHealth>,
+}
+
+impl MetricsRecorder {
+    /// Returns a copy of the current counters
+    pub fn snapshot(&self) -> ApiMetrics {
+        self.endpoints.lock().unwrap().clone()
+    }
+
+    /// Returns the latest success and failure across all endpoints
+    pub fn health(&self) -> ApiHealth {
+        self.health.lock().unwrap().clone()
+    }
+
+    /// Records a successful request to `endpoint`
+    pub fn record_success(&self, endpoint: &str, now: DateTime<Utc>) {
+        let mut endpoints = self.endpoints.lock().unwrap();
+        let metrics = endpoints.entry(path_of(endpoint)).or_default();
+        metrics.requests += 1;
+        metrics.last_success_at = Some(now);
+        self.health.lock().unwrap().last_success_at = Some(now);
+    }
+
+    /// Records a failed request to `endpoint`, with the HTTP status if a
+    /// response came back
+    pub fn record_error(
+        &self,
+        endpoint: &str,
+        status: Option<u16>,
+        error: String,
+        now: DateTime<Utc>,
+    ) {
+        let path = path_of(endpoint);
+        let mut endpoints = self.endpoints.lock().unwrap();
+        let metrics = endpoints.entry(path.clone()).or_default();
+        metrics.requests += 1;
+        metrics.errors += 1;
+        metrics.last_error = Some(error.clone());
+        metrics.last_error_at = Some(now);
+        self.health.lock().unwrap().last_error = Some(ApiFailure {
+            endpoint: path,
+            status,
+            message: error,
+            at: now,
+        });
+    }
+}
+
+/// Strips the query string so requests for different IDs share one entry
+fn path_of(endpoint: &str) -> String {
+    endpoint.split('?').next().unwrap_or(endpoint).to_string()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn counts_are_grouped_by_path() {
+        let recorder = MetricsRecorder::default();
+        let now = Utc::now();
+
+        recorder.record_success("/streams?game_id=1", now);
+        recorder.record_success("/streams?game_id=2", now);
+        recorder.record_error("/users?id=1", Some(500), "HTTP 500".to_string(), now);
+
+        let metrics = recorder.snapshot();
+        assert_eq!(metrics.len(), 2);
+        assert_eq!(metrics["/streams"].requests, 2);
+        assert_eq!(metrics["/streams"].errors, 0);
+        assert_eq!(metrics["/streams"].last_success_at, Some(now));
+        assert_eq!(metrics["/users"].requests, 1);
+        assert_eq!(metrics["/users"].errors, 1);
+        assert_eq!(metrics["/users"].last_error.as_deref(), Some("HTTP 500"));
+    }
+
+    #[test]
+    fn success_keeps_last_error() {
+        let recorder = MetricsRecorder::default();
+        let now = Utc::now();
+
+        recorder.record_error("/streams", Some(503), "HTTP 503".to_string(), now);
+        recorder.record_success("/streams", now);
+
+        let metrics = &recorder.snapshot()["/streams"];
+        assert_eq!(metrics.requests, 2);
+        assert_eq!(metrics.errors, 1);
+        assert_eq!(metrics.last_error.as_deref(), Some("HTTP 503"));
+    }
+
+    #[test]
+    fn health_tracks_latest_failure() {
+        let recorder = MetricsRecorder::default();
+        let now = Utc::now();
+
+        recorder.record_success("/streams", now);
+        recorder.record_error(
+            "/schedule?broadcaster_id=1",
+            None,
+            "connection refused".to_string(),
+            now + chrono::Duration::seconds(1),
+        );
+
+        let health = recorder.health();
+        assert_eq!(health.last_success_at, Some(now));
+        let error = health.last_error.as_ref().unwrap();
+        assert_eq!(error.endpoint, "/schedule");
+        assert_eq!(error.status, None);
+        assert!(health.is_unreachable());
+    }
+
+    #[test]
+    fn success_after_failure_is_reachable() {
+        let recorder = MetricsRecorder::default();
+        let now = Utc::now();
+
+        recorder.record_error("/streams", Some(503), "HTTP 503".to_string(), now);
+        assert!(recorder.health().is_unreachable());
+
+        recorder.record_success("/streams", now + chrono::Duration::seconds(1));
+        assert!(!recorder.health().is_unreachable());
+    }
+
+    #[test]
+    fn client_errors_are_not_unreachable() {
+        let recorder = MetricsRecorder::default();
+        recorder.record_error("/schedule", Some(404), "HTTP 404".to_string(), Utc::now());
+
+        assert!(!recorder.health().is_unreachable());
+    }
+}
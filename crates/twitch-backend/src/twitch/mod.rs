@@ -1,22 +1,63 @@
+mod cache;
+mod circuit;
 mod client;
 pub mod http;
+mod metrics;
+mod rate_limit;
 mod types;
 
-pub use client::TwitchClient;
+pub use circuit::CircuitState;
+pub use client::{
+    TwitchClient, DEFAULT_GAMES_CACHE_TTL, DEFAULT_NO_SCHEDULE_TTL, DEFAULT_USERS_CACHE_TTL,
+    MAX_CATEGORY_STREAMS, STREAM_BATCH_CONCURRENCY,
+};
+pub use metrics::{ApiFailure, ApiHealth, ApiMetrics, EndpointMetrics};
+pub use rate_limit::RateLimitStatus;
 // HttpClient, HttpResponse, ReqwestClient are used internally and in tests
 pub use types::*;
 
+use chrono::{DateTime, Utc};
+
 /// API error types for distinguishing recoverable errors
 #[derive(Debug, thiserror::Error)]
 pub enum ApiError {
     /// Token is expired or invalid - can be recovered by refreshing
     #[error("Unauthorized - token expired or invalid")]
     Unauthorized,
-    /// Other API or network errors
+    /// Rate limit still exhausted after waiting; `reset_at` is when it refills
+    #[error("Rate limited by Twitch")]
+    RateLimited { reset_at: Option<DateTime<Utc>> },
+    /// The requested resource doesn't exist (404)
+    #[error("Not found: {endpoint}")]
+    NotFound { endpoint: String },
+    /// Requests are paused after repeated failures; retry after `retry_at`
+    #[error("Twitch unreachable, retrying after {retry_at}")]
+    CircuitOpen { retry_at: DateTime<Utc> },
+    /// Any other non-success response from Helix
+    #[error("API error {status} from {endpoint}: {message}")]
+    Status {
+        status: u16,
+        endpoint: String,
+        message: String,
+    },
+    /// Network, parsing and other errors
     #[error("{0}")]
     Other(#[from] anyhow::Error),
 }
 
+impl ApiError {
+    /// Returns the HTTP status this error came from, if any
+    pub fn status(&self) -> Option<u16> {
+        match self {
+            Self::Unauthorized => Some(401),
+            Self::RateLimited { .. } => Some(429),
+            Self::NotFound { .. } => Some(404),
+            Self::Status { status, .. } => Some(*status),
+            Self::CircuitOpen { .. } | Self::Other(_) => None,
+        }
+    }
+}
+
 /// Returns the system locale as an ISO 639-1 two-letter language code (e.g. "en", "es").
 ///
 /// Uses the `sys-locale` crate to detect the OS locale, then extracts the language part.
@@ -61,6 +102,31 @@ mod tests {
         Arc,
     };
 
+    // =========================================================
+    // ApiError
+    // =========================================================
+
+    #[test]
+    fn api_error_status_codes() {
+        assert_eq!(ApiError::Unauthorized.status(), Some(401));
+        assert_eq!(ApiError::RateLimited { reset_at: None }.status(), Some(429));
+        assert_eq!(
+            ApiError::NotFound {
+                endpoint: "/users".to_string()
+            }
+            .status(),
+            Some(404)
+        );
+        assert_eq!(
+            ApiError::CircuitOpen {
+                retry_at: Utc::now()
+            }
+            .status(),
+            None
+        );
+        assert_eq!(ApiError::Other(anyhow::anyhow!("boom")).status(), None);
+    }
+
     // =========================================================
     // with_retry
     // =========================================================
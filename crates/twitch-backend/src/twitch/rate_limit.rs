@@ -0,0 +1,299 @@
+//! Client-side view of the Helix rate-limit bucket
+//!
+//! Helix reports the state of the per-token bucket in `Ratelimit-*` response
+//! headers. The limiter mirrors those values, spends a point locally for every
+//! request so concurrent bursts are throttled before their responses arrive,
+//! and delays requests while the bucket is nearly empty. After a 429 it also
+//! remembers when requests may resume (`Ratelimit-Reset` / `Retry-After`).
+
+use chrono::{DateTime, TimeZone, Utc};
+use reqwest::header::HeaderMap;
+use std::sync::Mutex;
+use std::time::Duration;
+
+/// Remaining points at or below which requests wait for the bucket to reset
+const LOW_REMAINING: u32 = 5;
+
+/// Longest single wait for a reset, guarding against bogus headers
+const MAX_WAIT_SECS: i64 = 60;
+
+/// Wait after a 429 when the response carries no reset time
+const DEFAULT_RATE_LIMITED_WAIT_SECS: i64 = 1;
+
+/// Longest wait after a 429 that requests block on; longer waits fail fast
+/// with the time requests may resume
+pub const MAX_BLOCKING_WAIT_SECS: i64 = 5;
+
+/// Current rate-limit values as last reported by Helix
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct RateLimitStatus {
+    /// Bucket size (`Ratelimit-Limit`)
+    pub limit: Option<u32>,
+    /// Points left in the bucket (`Ratelimit-Remaining`), less any spent since
+    pub remaining: Option<u32>,
+    /// When the bucket refills (`Ratelimit-Reset`)
+    pub reset_at: Option<DateTime<Utc>>,
+}
+
+/// Token bucket shared by every request a client makes
+#[derive(Debug, Default)]
+pub struct RateLimiter {
+    status: Mutex<RateLimitStatus>,
+    /// Set by a 429: no requests should go out before this time
+    resume_at: Mutex<Option<DateTime<Utc>>>,
+}
+
+impl RateLimiter {
+    /// Returns the current rate-limit values
+    pub fn status(&self) -> RateLimitStatus {
+        *self.status.lock().unwrap()
+    }
+
+    /// Refreshes the bucket from a response's `Ratelimit-*` headers
+    ///
+    /// Headers that are missing or unparseable leave the existing value alone.
+    pub fn update(&self, headers: &HeaderMap) {
+        let mut status = self.status.lock().unwrap();
+        if let Some(limit) = header_u32(headers, "ratelimit-limit") {
+            status.limit = Some(limit);
+        }
+        if let Some(remaining) = header_u32(headers, "ratelimit-remaining") {
+            status.remaining = Some(remaining);
+        }
+        if let Some(reset) = header_reset(headers) {
+            status.reset_at = Some(reset);
+        }
+    }
+
+    /// Waits until a request may be sent, then spends a point from the bucket
+    pub async fn acquire(&self) {
+        if let Some(wait) = self.take(Utc::now()) {
+            tracing::debug!("Rate limit nearly exhausted, waiting {:?}", wait);
+            tokio::time::sleep(wait).await;
+        }
+    }
+
+    /// Records a 429 response, returning when requests may resume
+    ///
+    /// Uses the later of `Ratelimit-Reset` and `Retry-After`, falling back to
+    /// a short default when the response carries neither.
+    pub fn record_rate_limited(&self, headers: &HeaderMap, now: DateTime<Utc>) -> DateTime<Utc> {
+        let reset = header_reset(headers).filter(|reset| *reset > now);
+        let retry_after = header_u32(headers, "retry-after")
+            .map(|secs| now + chrono::Duration::seconds(i64::from(secs)));
+        let resume = reset
+            .max(retry_after)
+            .unwrap_or(now + chrono::Duration::seconds(DEFAULT_RATE_LIMITED_WAIT_SECS));
+        *self.resume_at.lock().unwrap() = Some(resume);
+        resume
+    }
+
+    /// Returns when requests may resume after a 429, if that's still ahead
+    pub fn next_allowed_at(&self, now: DateTime<Utc>) -> Option<DateTime<Utc>> {
+        self.resume_at
+            .lock()
+            .unwrap()
+            .filter(|resume| *resume > now)
+    }
+
+    /// Returns how long to wait before the next request because of a 429
+    ///
+    /// Waits up to [`MAX_BLOCKING_WAIT_SECS`] are returned as `Ok`; longer
+    /// ones as `Err` with the resume time, so callers can give up instead.
+    pub fn penalty_wait(&self, now: DateTime<Utc>) -> Result<Option<Duration>, DateTime<Utc>> {
+        let Some(resume) = self.next_allowed_at(now) else {
+            return Ok(None);
+        };
+        if resume - now > chrono::Duration::seconds(MAX_BLOCKING_WAIT_SECS) {
+            return Err(resume);
+        }
+        Ok(Some(clamp_wait(resume - now)))
+    }
+
+    /// Spends a point, returning how long the caller must wait first (if at all)
+    fn take(&self, now: DateTime<Utc>) -> Option<Duration> {
+        let mut status = self.status.lock().unwrap();
+
+        // Once the reset time passes the bucket is full again
+        if let (Some(reset), Some(limit)) = (status.reset_at, status.limit) {
+            if reset <= now {
+                status.remaining = Some(limit);
+                status.reset_at = None;
+            }
+        }
+
+        let remaining = status.remaining?;
+        status.remaining = Some(remaining.saturating_sub(1));
+
+        if remaining > LOW_REMAINING {
+            return None;
+        }
+        let wait = clamp_wait(status.reset_at? - now);
+        (!wait.is_zero()).then_some(wait)
+    }
+}
+
+fn clamp_wait(wait: chrono::Duration) -> Duration {
+    wait.clamp(
+        chrono::Duration::zero(),
+        chrono::Duration::seconds(MAX_WAIT_SECS),
+    )
+    .to_std()
+    .unwrap_or_default()
+}
+
+fn header_u32(headers: &HeaderMap, name: &str) -> Option<u32> {
+    headers.get(name)?.to_str().ok()?.trim().parse().ok()
+}
+
+fn header_reset(headers: &HeaderMap) -> Option<DateTime<Utc>> {
+    let secs: i64 = headers
+        .get("ratelimit-reset")?
+        .to_str()
+        .ok()?
+        .trim()
+        .parse()
+        .ok()?;
+    Utc.timestamp_opt(secs, 0).single()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn headers(limit: u32, remaining: u32, reset: DateTime<Utc>) -> HeaderMap {
+        let mut headers = HeaderMap::new();
+        headers.insert("Ratelimit-Limit", limit.into());
+        headers.insert("Ratelimit-Remaining", remaining.into());
+        headers.insert("Ratelimit-Reset", reset.timestamp().into());
+        headers
+    }
+
+    fn at_second(dt: DateTime<Utc>) -> DateTime<Utc> {
+        Utc.timestamp_opt(dt.timestamp(), 0).unwrap()
+    }
+
+    #[test]
+    fn update_reads_headers() {
+        let limiter = RateLimiter::default();
+        let reset = at_second(Utc::now() + chrono::Duration::seconds(30));
+
+        limiter.update(&headers(800, 650, reset));
+
+        let status = limiter.status();
+        assert_eq!(status.limit, Some(800));
+        assert_eq!(status.remaining, Some(650));
+        assert_eq!(status.reset_at, Some(reset));
+    }
+
+    #[test]
+    fn update_ignores_missing_headers() {
+        let limiter = RateLimiter::default();
+        let reset = at_second(Utc::now());
+        limiter.update(&headers(800, 650, reset));
+
+        limiter.update(&HeaderMap::new());
+
+        assert_eq!(limiter.status().remaining, Some(650));
+    }
+
+    #[test]
+    fn take_without_headers_never_waits() {
+        let limiter = RateLimiter::default();
+        assert_eq!(limiter.take(Utc::now()), None);
+    }
+
+    #[test]
+    fn take_spends_a_point() {
+        let limiter = RateLimiter::default();
+        let now = Utc::now();
+        limiter.update(&headers(800, 100, now + chrono::Duration::seconds(30)));
+
+        assert_eq!(limiter.take(now), None);
+        assert_eq!(limiter.status().remaining, Some(99));
+    }
+
+    #[test]
+    fn take_waits_for_reset_when_low() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        limiter.update(&headers(
+            800,
+            LOW_REMAINING,
+            now + chrono::Duration::seconds(10),
+        ));
+
+        assert_eq!(limiter.take(now), Some(Duration::from_secs(10)));
+    }
+
+    #[test]
+    fn take_refills_after_reset() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        limiter.update(&headers(800, 0, now - chrono::Duration::seconds(1)));
+
+        assert_eq!(limiter.take(now), None);
+        assert_eq!(limiter.status().remaining, Some(799));
+    }
+
+    #[test]
+    fn waits_are_capped() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        limiter.update(&headers(800, 0, now + chrono::Duration::hours(1)));
+
+        assert_eq!(
+            limiter.take(now),
+            Some(Duration::from_secs(MAX_WAIT_SECS as u64))
+        );
+    }
+
+    #[test]
+    fn rate_limited_uses_reset_or_default() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        assert_eq!(
+            limiter.record_rate_limited(&HeaderMap::new(), now),
+            now + chrono::Duration::seconds(DEFAULT_RATE_LIMITED_WAIT_SECS)
+        );
+
+        let reset = now + chrono::Duration::seconds(3);
+        assert_eq!(
+            limiter.record_rate_limited(&headers(800, 0, reset), now),
+            reset
+        );
+    }
+
+    #[test]
+    fn rate_limited_prefers_later_retry_after() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        let mut headers = headers(800, 0, now + chrono::Duration::seconds(3));
+        headers.insert("Retry-After", 30.into());
+
+        assert_eq!(
+            limiter.record_rate_limited(&headers, now),
+            now + chrono::Duration::seconds(30)
+        );
+    }
+
+    #[test]
+    fn short_penalty_blocks_and_long_penalty_fails_fast() {
+        let limiter = RateLimiter::default();
+        let now = at_second(Utc::now());
+        assert_eq!(limiter.penalty_wait(now), Ok(None));
+
+        let short = now + chrono::Duration::seconds(2);
+        limiter.record_rate_limited(&headers(800, 0, short), now);
+        assert_eq!(limiter.penalty_wait(now), Ok(Some(Duration::from_secs(2))));
+        assert_eq!(limiter.next_allowed_at(now), Some(short));
+
+        let long = now + chrono::Duration::seconds(MAX_BLOCKING_WAIT_SECS + 1);
+        limiter.record_rate_limited(&headers(800, 0, long), now);
+        assert_eq!(limiter.penalty_wait(now), Err(long));
+
+        // Once the penalty passes, requests flow again
+        assert_eq!(limiter.next_allowed_at(long), None);
+        assert_eq!(limiter.penalty_wait(long), Ok(None));
+    }
+}
@@ -16,11 +16,55 @@ pub struct Stream {
     pub thumbnail_url: String,
     #[serde(default)]
     pub tags: Vec<String>,
+    /// "live" for a live broadcast; Helix has also used "rerun"
+    #[serde(rename = "type", default = "default_stream_type")]
+    pub stream_type: String,
+    #[serde(default)]
+    pub is_mature: bool,
     /// Profile image URL (not returned by /streams — populated separately via /users)
     #[serde(default)]
     pub profile_image_url: String,
 }
 
+/// Returns the Twitch channel URL for a login name
+pub fn channel_url(user_login: &str) -> String {
+    format!("https://twitch.tv/{user_login}")
+}
+
+/// Returns the URL of a channel's chat in a popout window
+pub fn chat_url(user_login: &str) -> String {
+    format!("https://twitch.tv/popout/{user_login}/chat?popout=")
+}
+
+/// Returns the URL of a channel's past broadcasts and clips
+pub fn videos_url(user_login: &str) -> String {
+    format!("https://twitch.tv/{user_login}/videos")
+}
+
+/// Returns the URL of a category's directory page
+pub fn category_url(category_name: &str) -> String {
+    format!(
+        "https://twitch.tv/directory/game/{}",
+        urlencoding::encode(category_name)
+    )
+}
+
+fn default_stream_type() -> String {
+    "live".to_string()
+}
+
+/// Formats a duration as hours and minutes, e.g. "4h 12m" or "45m"
+pub fn format_duration(duration: chrono::Duration) -> String {
+    let hours = duration.num_hours();
+    let minutes = duration.num_minutes() % 60;
+
+    if hours > 0 {
+        format!("{hours}h {minutes}m")
+    } else {
+        format!("{minutes}m")
+    }
+}
+
 /// Formats a viewer count with k suffix for thousands
 pub fn format_viewer_count(count: u32) -> String {
     if count >= 1000 {
@@ -38,7 +82,7 @@ pub fn format_viewer_count(count: u32) -> String {
 impl Stream {
     /// Returns the Twitch channel URL
     pub fn channel_url(&self) -> String {
-        format!("https://twitch.tv/{}", self.user_login)
+        channel_url(&self.user_login)
     }
 
     /// Returns the duration since the stream started
@@ -48,21 +92,30 @@ impl Stream {
 
     /// Returns a human-readable duration string
     pub fn format_duration(&self) -> String {
-        let duration = self.duration();
-        let hours = duration.num_hours();
-        let minutes = duration.num_minutes() % 60;
-
-        if hours > 0 {
-            format!("{hours}h {minutes}m")
-        } else {
-            format!("{minutes}m")
-        }
+        format_duration(self.duration())
     }
 
     /// Returns a formatted viewer count
     pub fn format_viewer_count(&self) -> String {
         format_viewer_count(self.viewer_count)
     }
+
+    /// Returns true if this is a rerun of an earlier broadcast
+    pub fn is_rerun(&self) -> bool {
+        self.stream_type == "rerun"
+    }
+
+    /// Returns true if the channel is broadcasting live (not a rerun)
+    pub fn is_live_broadcast(&self) -> bool {
+        self.stream_type == "live"
+    }
+
+    /// Returns true if any of the stream's tags contains `tag`, ignoring case
+    /// (so "drops" matches "DropsEnabled")
+    pub fn has_tag(&self, tag: &str) -> bool {
+        let tag = tag.to_lowercase();
+        self.tags.iter().any(|t| t.to_lowercase().contains(&tag))
+    }
 }
 
 /// Represents a scheduled broadcast
@@ -85,25 +138,50 @@ pub struct ScheduledStream {
     pub is_inferred: bool,
 }
 
+/// Formats a schedule time in local time, e.g. "Today 8:00 PM" or "Sat 2:30 PM"
+pub fn format_schedule_time(time: DateTime<Utc>) -> String {
+    let now = Local::now();
+    let start_local = time.with_timezone(&Local);
+
+    // Check if it's today
+    if start_local.date_naive() == now.date_naive() {
+        return format!("Today {}", start_local.format("%-I:%M %p"));
+    }
+
+    // Check if it's tomorrow
+    let tomorrow = now.date_naive() + chrono::Duration::days(1);
+    if start_local.date_naive() == tomorrow {
+        return format!("Tomorrow {}", start_local.format("%-I:%M %p"));
+    }
+
+    // Otherwise show day and time
+    start_local.format("%a %-I:%M %p").to_string()
+}
+
 impl ScheduledStream {
     /// Returns a human-readable start time
     pub fn format_start_time(&self) -> String {
-        let now = Local::now();
-        let start_local = self.start_time.with_timezone(&Local);
+        format_schedule_time(self.start_time)
+    }
 
-        // Check if it's today
-        if start_local.date_naive() == now.date_naive() {
-            return format!("Today {}", start_local.format("%-I:%M %p"));
+    /// Returns how long until the stream starts, e.g. "in 2h 15m", rounded
+    /// up to the minute so it never says "in 0m". Once the start time has
+    /// passed the stream is "live soon...".
+    pub fn format_time_until(&self, now: DateTime<Utc>) -> String {
+        let seconds = (self.start_time - now).num_seconds();
+        if seconds <= 0 {
+            return "live soon...".to_string();
         }
 
-        // Check if it's tomorrow
-        let tomorrow = now.date_naive() + chrono::Duration::days(1);
-        if start_local.date_naive() == tomorrow {
-            return format!("Tomorrow {}", start_local.format("%-I:%M %p"));
+        let minutes = (seconds + 59) / 60;
+        let (days, hours, minutes) = (minutes / (24 * 60), minutes / 60 % 24, minutes % 60);
+        match (days, hours, minutes) {
+            (0, 0, m) => format!("in {m}m"),
+            (0, h, 0) => format!("in {h}h"),
+            (0, h, m) => format!("in {h}h {m}m"),
+            (d, 0, _) => format!("in {d}d"),
+            (d, h, _) => format!("in {d}d {h}h"),
         }
-
-        // Otherwise show day and time
-        start_local.format("%a %-I:%M %p").to_string()
     }
 }
 
@@ -184,6 +262,8 @@ pub struct ScheduleData {
 #[derive(Debug, Clone, Deserialize)]
 pub struct ScheduleResponse {
     pub data: ScheduleData,
+    #[serde(default)]
+    pub pagination: Option<Pagination>,
 }
 
 /// Represents a Twitch user (from /users endpoint)
@@ -215,6 +295,69 @@ pub struct Category {
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct SearchCategoriesResponse {
     pub data: Vec<Category>,
+    #[serde(default)]
+    pub pagination: Option<Pagination>,
+}
+
+/// A channel returned by the search channels endpoint
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct ChannelSearchResult {
+    /// Broadcaster user ID
+    pub id: String,
+    pub broadcaster_login: String,
+    pub display_name: String,
+    #[serde(default)]
+    pub game_id: String,
+    #[serde(default)]
+    pub game_name: String,
+    pub is_live: bool,
+    #[serde(default)]
+    pub title: String,
+}
+
+/// A category from the top games endpoint
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct TopGame {
+    pub category: Category,
+    /// Live streams in the category (capped at 100), if it was counted
+    pub live_streams: Option<usize>,
+}
+
+/// Channel information from the channels endpoint
+///
+/// Available whether or not the channel is live, so it describes what an
+/// offline channel last streamed.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct ChannelInfo {
+    pub broadcaster_id: String,
+    #[serde(default)]
+    pub title: String,
+    #[serde(default)]
+    pub game_id: String,
+    #[serde(default)]
+    pub game_name: String,
+    #[serde(default, rename = "broadcaster_language")]
+    pub language: String,
+}
+
+/// Response from get channel information endpoint
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct ChannelInfoResponse {
+    pub data: Vec<ChannelInfo>,
+}
+
+/// A clip from the clips endpoint
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct Clip {
+    pub id: String,
+    pub url: String,
+    pub title: String,
+    pub view_count: u32,
+    pub created_at: DateTime<Utc>,
+    /// Length in seconds
+    pub duration: f64,
+    #[serde(default)]
+    pub thumbnail_url: String,
 }
 
 /// Response from get games endpoint
@@ -242,10 +385,40 @@ mod tests {
             started_at: Utc::now() - Duration::hours(1),
             thumbnail_url: "https://example.com/thumb.jpg".to_string(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
 
+    #[test]
+    fn stream_channel_url_matches_login_url() {
+        let stream = stream_with_viewers(1);
+        assert_eq!(stream.channel_url(), "https://twitch.tv/testuser");
+        assert_eq!(stream.channel_url(), channel_url(&stream.user_login));
+    }
+
+    #[test]
+    fn chat_and_videos_urls() {
+        assert_eq!(
+            chat_url("testuser"),
+            "https://twitch.tv/popout/testuser/chat?popout="
+        );
+        assert_eq!(videos_url("testuser"), "https://twitch.tv/testuser/videos");
+    }
+
+    #[test]
+    fn category_url_encodes_name() {
+        assert_eq!(
+            category_url("Just Chatting"),
+            "https://twitch.tv/directory/game/Just%20Chatting"
+        );
+        assert_eq!(
+            category_url("Tom & Jerry"),
+            "https://twitch.tv/directory/game/Tom%20%26%20Jerry"
+        );
+    }
+
     /// Helper to create a test stream started at a specific time
     fn stream_started_at(started_at: DateTime<Utc>) -> Stream {
         Stream {
@@ -260,6 +433,8 @@ mod tests {
             started_at,
             thumbnail_url: "https://example.com/thumb.jpg".to_string(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
@@ -339,6 +514,165 @@ mod tests {
         assert_eq!(stream.format_viewer_count(), "999");
     }
 
+    // === Helix fixture tests ===
+
+    /// Trimmed response recorded from the Helix get streams endpoint
+    const STREAMS_FIXTURE: &str = r#"{
+        "data": [
+            {
+                "id": "40952121085",
+                "user_id": "101051819",
+                "user_login": "afro",
+                "user_name": "Afro",
+                "game_id": "32982",
+                "game_name": "Grand Theft Auto V",
+                "type": "live",
+                "title": "Jacob: Digital Den Laptops & Routers | NoPixel",
+                "tags": ["English"],
+                "viewer_count": 1490,
+                "started_at": "2021-03-31T20:57:26Z",
+                "language": "en",
+                "thumbnail_url": "https://static-cdn.jtvnw.net/previews-ttv/live_user_afro-{width}x{height}.jpg",
+                "tag_ids": [],
+                "is_mature": false
+            }
+        ],
+        "pagination": {
+            "cursor": "eyJiIjp7IkN1cnNvciI6ImV5SnpJam94TkRBNE1EVTNNamMwTmpnd05Dd2laQ0k2Wm1Gc2MyVXNJblFpT25SeWRXVjkifX0"
+        }
+    }"#;
+
+    /// Trimmed response recorded from the Helix get channel stream schedule endpoint
+    const SCHEDULE_FIXTURE: &str = r#"{
+        "data": {
+            "segments": [
+                {
+                    "id": "eyJzZWdtZW50SUQiOiJlNGFjYzcyNC0zNzFmLTQwMmMtODFjYS0yM2FkYTc5NzU5ZDQiLCJpc29ZZWFyIjoyMDIxLCJpc29XZWVrIjoyNn0=",
+                    "start_time": "2021-07-01T18:00:00Z",
+                    "end_time": "2021-07-01T19:00:00Z",
+                    "title": "TwitchDev Monthly Update // July 1, 2021",
+                    "canceled_until": null,
+                    "category": {
+                        "id": "509670",
+                        "name": "Science & Technology"
+                    },
+                    "is_recurring": false
+                }
+            ],
+            "broadcaster_id": "141981764",
+            "broadcaster_name": "TwitchDev",
+            "broadcaster_login": "twitchdev",
+            "vacation": null
+        },
+        "pagination": {}
+    }"#;
+
+    #[test]
+    fn streams_fixture_maps_every_field() {
+        let response: StreamsResponse = serde_json::from_str(STREAMS_FIXTURE).unwrap();
+        let stream = &response.data[0];
+
+        assert_eq!(stream.id, "40952121085");
+        assert_eq!(stream.user_id, "101051819");
+        assert_eq!(stream.user_login, "afro");
+        assert_eq!(stream.user_name, "Afro");
+        assert_eq!(stream.game_id, "32982");
+        assert_eq!(stream.game_name, "Grand Theft Auto V");
+        assert!(stream.is_live_broadcast());
+        assert_eq!(
+            stream.title,
+            "Jacob: Digital Den Laptops & Routers | NoPixel"
+        );
+        assert_eq!(stream.tags, vec!["English"]);
+        assert_eq!(stream.viewer_count, 1490);
+        assert_eq!(
+            stream.started_at,
+            Utc.with_ymd_and_hms(2021, 3, 31, 20, 57, 26).unwrap()
+        );
+        assert!(stream.thumbnail_url.contains("{width}x{height}"));
+        assert!(!stream.is_mature);
+        assert!(stream.profile_image_url.is_empty());
+        assert!(response.pagination.and_then(|p| p.cursor).is_some());
+    }
+
+    #[test]
+    fn schedule_fixture_maps_every_field() {
+        let response: ScheduleResponse = serde_json::from_str(SCHEDULE_FIXTURE).unwrap();
+        let data = response.data;
+        let segments = data.segments.unwrap();
+        let segment = &segments[0];
+
+        assert_eq!(data.broadcaster_id, "141981764");
+        assert_eq!(data.broadcaster_name, "TwitchDev");
+        assert_eq!(data.broadcaster_login, "twitchdev");
+        assert!(data.vacation.is_none());
+        assert!(data.broadcaster_timezone.is_none());
+        assert_eq!(
+            segment.start_time,
+            Utc.with_ymd_and_hms(2021, 7, 1, 18, 0, 0).unwrap()
+        );
+        assert_eq!(
+            segment.end_time,
+            Some(Utc.with_ymd_and_hms(2021, 7, 1, 19, 0, 0).unwrap())
+        );
+        assert_eq!(segment.title, "TwitchDev Monthly Update // July 1, 2021");
+        assert!(segment.canceled_until.is_none());
+        let category = segment.category.as_ref().unwrap();
+        assert_eq!(category.id, "509670");
+        assert_eq!(category.name, "Science & Technology");
+        assert!(!segment.is_recurring);
+        assert!(response.pagination.and_then(|p| p.cursor).is_none());
+    }
+
+    // === stream type tests ===
+
+    #[test]
+    fn stream_type_and_mature_flag_deserialize() {
+        let json = r#"{
+            "id": "1", "user_id": "2", "user_login": "a", "user_name": "A",
+            "game_id": "3", "game_name": "G", "type": "rerun", "title": "T",
+            "viewer_count": 5, "started_at": "2026-10-16T12:00:00Z",
+            "thumbnail_url": "", "is_mature": true
+        }"#;
+        let stream: Stream = serde_json::from_str(json).unwrap();
+
+        assert!(stream.is_rerun());
+        assert!(!stream.is_live_broadcast());
+        assert!(stream.is_mature);
+    }
+
+    #[test]
+    fn stream_type_defaults_to_live() {
+        let json = r#"{
+            "id": "1", "user_id": "2", "user_login": "a", "user_name": "A",
+            "game_id": "3", "game_name": "G", "title": "T",
+            "viewer_count": 5, "started_at": "2026-10-16T12:00:00Z",
+            "thumbnail_url": ""
+        }"#;
+        let stream: Stream = serde_json::from_str(json).unwrap();
+
+        assert!(stream.is_live_broadcast());
+        assert!(!stream.is_rerun());
+        assert!(!stream.is_mature);
+    }
+
+    // === has_tag tests ===
+
+    #[test]
+    fn has_tag_is_case_insensitive_substring() {
+        let mut stream = stream_with_viewers(0);
+        stream.tags = vec!["English".to_string(), "DropsEnabled".to_string()];
+
+        assert!(stream.has_tag("english"));
+        assert!(stream.has_tag("drops"));
+        assert!(!stream.has_tag("Speedrun"));
+    }
+
+    #[test]
+    fn has_tag_without_tags() {
+        assert!(!stream_with_viewers(0).has_tag("English"));
+    }
+
     // === format_duration tests ===
 
     #[test]
@@ -371,6 +705,44 @@ mod tests {
         assert_eq!(stream.format_duration(), "0m");
     }
 
+    // === format_time_until tests ===
+
+    #[test]
+    fn format_time_until_rounds_up_to_the_minute() {
+        let now = Utc::now();
+        let until = |offset| scheduled_at(now + offset).format_time_until(now);
+
+        assert_eq!(until(Duration::seconds(1)), "in 1m");
+        assert_eq!(until(Duration::seconds(60)), "in 1m");
+        assert_eq!(until(Duration::seconds(61)), "in 2m");
+        assert_eq!(until(Duration::minutes(59)), "in 59m");
+    }
+
+    #[test]
+    fn format_time_until_hours_and_days() {
+        let now = Utc::now();
+        let until = |offset| scheduled_at(now + offset).format_time_until(now);
+
+        assert_eq!(until(Duration::minutes(60)), "in 1h");
+        assert_eq!(until(Duration::minutes(135)), "in 2h 15m");
+        assert_eq!(until(Duration::hours(24)), "in 1d");
+        assert_eq!(
+            until(Duration::hours(27) + Duration::minutes(30)),
+            "in 1d 3h"
+        );
+    }
+
+    #[test]
+    fn format_time_until_after_start_is_live_soon() {
+        let now = Utc::now();
+
+        assert_eq!(scheduled_at(now).format_time_until(now), "live soon...");
+        assert_eq!(
+            scheduled_at(now - Duration::minutes(5)).format_time_until(now),
+            "live soon..."
+        );
+    }
+
     // === format_start_time tests ===
     // Note: These tests are timezone-dependent, so we test the general format
 
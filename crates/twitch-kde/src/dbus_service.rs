@@ -6,6 +6,7 @@ use zbus::{interface, object_server::SignalContext};
 
 use twitch_backend::{
     handle::{LoginProgress, RawDisplayData},
+    twitch::channel_url,
     AuthCommand,
 };
 
@@ -87,7 +88,7 @@ impl DbusService {
     }
 
     pub fn do_open_stream(&self, user_login: &str) {
-        (self.open_url)(&format!("https://twitch.tv/{user_login}"));
+        (self.open_url)(&channel_url(user_login));
     }
 
     pub async fn do_open_settings(&self) {
@@ -8,6 +8,7 @@ use tauri::Manager;
 use tokio::sync::mpsc;
 use tracing_subscriber::{layer::SubscriberExt, util::SubscriberInitExt, EnvFilter};
 
+use twitch_backend::app_services::AppServices;
 use twitch_backend::{handle::RawDisplayData, AuthCommand, BackendEvent};
 use twitch_kde::{
     dbus_service::{spawn_state_watcher, DbusService, WindowRequest, OBJECT_PATH},
@@ -21,6 +22,16 @@ fn main() {
         .with(tracing_subscriber::fmt::layer())
         .init();
 
+    // `--test-notification` sends one notification and exits, so users can
+    // check notifications work without waiting for someone to go live
+    if std::env::args().any(|arg| arg == "--test-notification") {
+        if let Err(e) = twitch_backend::send_test_notification() {
+            eprintln!("Test notification failed: {e:#}");
+            std::process::exit(1);
+        }
+        return;
+    }
+
     tracing::info!("Starting Twitch KDE daemon");
 
     tauri::Builder::default()
@@ -33,6 +44,7 @@ fn main() {
             twitch_settings_tauri::commands::is_debug_build,
             twitch_settings_tauri::commands::get_debug_schedule_data,
             twitch_settings_tauri::commands::get_debug_hotness_data,
+            twitch_settings_tauri::commands::save_state_snapshot,
         ])
         .setup(|app| {
             // Enter the Tauri-managed tokio runtime so tokio::spawn works
@@ -146,11 +158,13 @@ fn main() {
         })
         .build(tauri::generate_context!())
         .expect("Failed to build Tauri application")
-        .run(|_app, event| {
+        .run(|app, event| {
             // Prevent exit when all windows close (daemon stays alive until killed)
             if let tauri::RunEvent::ExitRequested { ref api, code, .. } = event {
                 if code.is_none() {
                     api.prevent_exit();
+                } else if let Some(services) = app.try_state::<Arc<dyn AppServices>>() {
+                    services.shutdown();
                 }
             }
         });
@@ -255,7 +255,7 @@ mod tests {
             DEFAULT_LIVE_MENU_LIMIT, DEFAULT_SCHEDULE_MENU_LIMIT,
         },
         handle::RawDisplayData,
-        twitch::{ScheduledStream, Stream},
+        twitch::{ApiHealth, ScheduledStream, Stream},
     };
 
     use super::*;
@@ -278,6 +278,8 @@ mod tests {
             started_at: Utc::now() - Duration::hours(1),
             thumbnail_url: "https://example.com/thumb.jpg".to_string(),
             tags: vec![],
+            stream_type: "live".to_string(),
+            is_mature: false,
             profile_image_url: String::new(),
         }
     }
@@ -301,16 +303,23 @@ mod tests {
     fn raw(streams: Vec<Stream>, scheduled: Vec<ScheduledStream>) -> RawDisplayData {
         RawDisplayData {
             is_authenticated: true,
+            user_login: String::new(),
             live_streams: streams,
             scheduled_streams: scheduled,
             schedules_loaded: true,
             followed_channels: vec![],
             followed_categories: vec![],
+            played_categories: vec![],
             category_streams: HashMap::new(),
             config: Config::default(),
             profile_image_urls: HashMap::new(),
             box_art_urls: HashMap::new(),
             hot_stream_ids: HashSet::new(),
+            viewer_trends: HashMap::new(),
+            first_seen_at: HashMap::new(),
+            api_health: ApiHealth::default(),
+            recent_notifications: vec![],
+            notification_backend: None,
         }
     }
 
@@ -327,20 +336,29 @@ mod tests {
                 display_name: user_login.to_string(),
                 importance,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
             },
         );
         RawDisplayData {
             is_authenticated: true,
+            user_login: String::new(),
             live_streams: streams,
             scheduled_streams: scheduled,
             schedules_loaded: true,
             followed_channels: vec![],
             followed_categories: vec![],
+            played_categories: vec![],
             category_streams: HashMap::new(),
             config,
             profile_image_urls: HashMap::new(),
             box_art_urls: HashMap::new(),
             hot_stream_ids: HashSet::new(),
+            viewer_trends: HashMap::new(),
+            first_seen_at: HashMap::new(),
+            api_health: ApiHealth::default(),
+            recent_notifications: vec![],
+            notification_backend: None,
         }
     }
 
@@ -608,6 +626,8 @@ mod tests {
         raw.followed_categories = vec![FollowedCategory {
             id: cat_id.clone(),
             name: "Minecraft".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         raw.category_streams = HashMap::from([(cat_id, cat_streams)]);
 
@@ -628,6 +648,8 @@ mod tests {
         raw.followed_categories = vec![FollowedCategory {
             id: cat_id.clone(),
             name: "Minecraft".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         raw.category_streams = HashMap::from([(cat_id.clone(), cat_streams)]);
         raw.box_art_urls =
@@ -650,6 +672,8 @@ mod tests {
         raw.followed_categories = vec![FollowedCategory {
             id: cat_id.clone(),
             name: "Minecraft".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         raw.category_streams = HashMap::from([(cat_id, cat_streams)]);
 
@@ -667,6 +691,8 @@ mod tests {
         raw.followed_categories = vec![FollowedCategory {
             id: cat_id.clone(),
             name: "Gaming".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         raw.category_streams = HashMap::from([(cat_id, vec![stream])]);
         raw.config.streamer_settings.insert(
@@ -675,6 +701,8 @@ mod tests {
                 display_name: "favuser".to_string(),
                 importance: StreamerImportance::Favourite,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
             },
         );
 
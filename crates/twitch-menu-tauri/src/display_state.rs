@@ -1,26 +1,118 @@
 use std::collections::{HashMap, HashSet};
 
-use chrono::{DateTime, Duration, Utc};
+use chrono::{DateTime, Duration, Local, Utc};
 
-use twitch_backend::config::{FollowedCategory, StreamerImportance, StreamerSettings};
-use twitch_backend::notify::truncate;
-use twitch_backend::twitch::{format_viewer_count, ScheduledStream, Stream};
+use twitch_backend::config::{
+    FollowedCategory, MenuToggle, ScheduleTimeFormat, StreamerImportance, StreamerSettings,
+};
+use twitch_backend::notification_history::HistoryEntry;
+use twitch_backend::state::ViewerTrend;
+use twitch_backend::text::{truncate, truncate_utf16};
+use twitch_backend::twitch::{format_duration, format_viewer_count, ScheduledStream, Stream};
 
 /// Scheduled stream within this many minutes of a live broadcast is "covered" by the live stream
 /// and hidden from the schedule section.
 const LIVE_COVERS_SCHEDULE_WINDOW_MIN: i64 = 60;
 
+/// Streams first noticed live within this many minutes are marked as new.
+/// Checked when the display state is built, so the mark drops off on the
+/// next refresh without waiting for a state change.
+const RECENTLY_LIVE_WINDOW_MIN: i64 = 10;
+
+/// Tray icon tooltip before any state is known.
+pub const TOOLTIP_TITLE: &str = "Twitch Tray";
+
+/// Group for live streams that have no game set.
+const OTHER_GAME: &str = "Other";
+
+/// Longest tray tooltip in UTF-16 code units; Windows cuts tooltips off at
+/// 127, so an emoji outside the BMP counts twice.
+const TOOLTIP_MAX_LEN: usize = 127;
+
+/// What the tray icon shows: greyed out when logged out, otherwise a badge
+/// with the live count when anyone is live.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum IconState {
+    LoggedOut,
+    Idle,
+    Live {
+        count: usize,
+    },
+    /// At least one of the live streams is a favourite.
+    FavouriteLive {
+        count: usize,
+    },
+}
+
 /// A live stream entry ready to be rendered.
 pub struct StreamEntry {
     pub stream: Stream,
     pub label: String,
     pub is_hot: bool,
+    /// `true` if the stream was first noticed live within the last few minutes.
+    pub is_new: bool,
+}
+
+/// Live streams playing the same game, shown together when grouping by game.
+pub struct GameGroup {
+    /// e.g. `"Factorio (3)"`
+    pub header: String,
+    pub entries: Vec<StreamEntry>,
+}
+
+/// A setting checkbox in the menu.
+pub struct ToggleEntry {
+    pub toggle: MenuToggle,
+    pub label: &'static str,
+    pub checked: bool,
+}
+
+/// Who is logged in and whether Twitch can be reached.
+pub struct AccountSection {
+    /// e.g. `"Logged in as somebody"`
+    pub header: String,
+    /// e.g. `"Connected"` or `"Twitch unreachable (last update 5m ago)"`
+    pub status: String,
+}
+
+/// A favourite streamer who isn't live.
+pub struct OfflineFavourite {
+    pub user_login: String,
+    /// e.g. `"Name — offline"`
+    pub label: String,
+}
+
+/// Favourite streamers, pinned above the live list.
+pub struct FavouritesSection {
+    /// Live favourites, in the same order as the live list.
+    pub live: Vec<StreamEntry>,
+    /// Favourites that aren't live, by name, listed so they can still be
+    /// removed from the menu.
+    pub offline: Vec<OfflineFavourite>,
+}
+
+impl FavouritesSection {
+    pub fn is_empty(&self) -> bool {
+        self.live.is_empty() && self.offline.is_empty()
+    }
 }
 
 /// The live-streams portion of the display.
 pub struct LiveSection {
     pub visible: Vec<StreamEntry>,
     pub overflow: Vec<StreamEntry>,
+    /// Streams grouped by game, biggest group first, when grouping is on.
+    /// `visible` and `overflow` are empty in that case.
+    pub groups: Vec<GameGroup>,
+}
+
+impl LiveSection {
+    /// Number of live streams, however they are arranged.
+    pub fn total(&self) -> usize {
+        self.visible.len()
+            + self.overflow.len()
+            + self.groups.iter().map(|g| g.entries.len()).sum::<usize>()
+    }
 }
 
 /// A scheduled stream entry ready to be rendered.
@@ -46,10 +138,24 @@ pub struct CategoryStreamEntry {
 
 /// A followed category and its top streams.
 pub struct CategorySection {
+    pub name: String,
     pub header: String,
     pub entries: Vec<CategoryStreamEntry>,
 }
 
+/// A previously sent notification in the "Recent notifications" submenu.
+pub struct RecentNotificationEntry {
+    pub label: String,
+    /// Channel to open when clicked; `None` unless the channel is still live.
+    pub open_login: Option<String>,
+}
+
+/// A streamer muted with "Mute today", listed so the mute can be lifted early.
+pub struct MutedEntry {
+    pub label: String,
+    pub user_login: String,
+}
+
 /// The full computed display state for the tray menu.
 ///
 /// This is a pure data type — no Tauri or GTK types. The render layer
@@ -59,9 +165,23 @@ pub struct CategorySection {
 /// and the other fields are ignored.
 pub struct DisplayState {
     pub authenticated: bool,
+    /// `None` until the signed-in user's login is known.
+    pub account: Option<AccountSection>,
+    pub favourites: FavouritesSection,
+    /// Live streams other than favourites.
     pub live_section: LiveSection,
     pub schedule_section: ScheduleSection,
     pub category_sections: Vec<CategorySection>,
+    pub recent_notifications: Vec<RecentNotificationEntry>,
+    pub muted_today: Vec<MutedEntry>,
+    /// `true` when an external player is configured, so live streams can
+    /// offer opening in it.
+    pub has_external_player: bool,
+    /// Settings that can be switched from the menu.
+    pub toggles: Vec<ToggleEntry>,
+    /// Summary shown when hovering over the tray icon.
+    pub tooltip: String,
+    pub icon: IconState,
 }
 
 impl DisplayState {
@@ -69,9 +189,15 @@ impl DisplayState {
     pub fn unauthenticated() -> Self {
         Self {
             authenticated: false,
+            account: None,
+            favourites: FavouritesSection {
+                live: Vec::new(),
+                offline: Vec::new(),
+            },
             live_section: LiveSection {
                 visible: Vec::new(),
                 overflow: Vec::new(),
+                groups: Vec::new(),
             },
             schedule_section: ScheduleSection {
                 header: String::new(),
@@ -80,6 +206,12 @@ impl DisplayState {
                 schedules_loaded: false,
             },
             category_sections: Vec::new(),
+            recent_notifications: Vec::new(),
+            muted_today: Vec::new(),
+            has_external_player: false,
+            toggles: Vec::new(),
+            tooltip: format!("{TOOLTIP_TITLE} \u{2014} not logged in"),
+            icon: IconState::LoggedOut,
         }
     }
 }
@@ -92,8 +224,28 @@ pub struct DisplayConfig {
     pub live_limit: usize,
     /// Maximum scheduled streams shown in the main menu before the overflow submenu.
     pub schedule_limit: usize,
+    /// How scheduled streams show their start time.
+    pub schedule_time_format: ScheduleTimeFormat,
     /// User IDs of streams currently detected as "hot" (significantly above normal viewers).
     pub hot_stream_ids: HashSet<String>,
+    /// Viewer-count trend per live stream, keyed by user ID.
+    pub viewer_trends: HashMap<String, ViewerTrend>,
+    /// When each live stream was first noticed live, keyed by user ID.
+    pub first_seen_at: HashMap<String, DateTime<Utc>>,
+    /// Login of the signed-in user, for the account header.
+    pub user_login: String,
+    /// `true` while Twitch can't be reached, so the tooltip can say the data may be stale.
+    pub api_unreachable: bool,
+    /// When a Twitch request last succeeded.
+    pub api_last_success_at: Option<DateTime<Utc>>,
+    /// Group live streams by game instead of listing them flat.
+    pub group_by_game: bool,
+    /// `true` when an external player is configured.
+    pub has_external_player: bool,
+    /// Leave reruns out of the live section.
+    pub hide_reruns: bool,
+    /// Settings switchable from the menu, with whether each is on.
+    pub toggles: Vec<(MenuToggle, bool)>,
 }
 
 fn get_importance(
@@ -106,36 +258,55 @@ fn get_importance(
         .unwrap_or_default()
 }
 
-/// Formats a stream label for the Following Live menu with optional star/fire prefix.
+/// Formats a stream label for the Following Live menu with optional star/fire prefix
+/// and a viewer trend arrow.
 ///
-/// Format: `"[🔥 ][★ ]StreamerName - GameName (1.2k, 2h 15m)"`
-pub(crate) fn format_stream_label_with_star(s: &Stream, star: bool, hot: bool) -> String {
+/// Format: `"[🔥 ][★ ]StreamerName - GameName (1.2k[ ↑], 2h 15m)"`
+pub(crate) fn format_stream_label_with_star(
+    s: &Stream,
+    star: bool,
+    hot: bool,
+    trend: Option<ViewerTrend>,
+) -> String {
     let fire = if hot { "\u{1F525} " } else { "" };
     let star_str = if star { "\u{2605} " } else { "" };
+    let arrow = match trend {
+        Some(ViewerTrend::Rising) => " \u{2191}",
+        Some(ViewerTrend::Falling) => " \u{2193}",
+        Some(ViewerTrend::Steady) | None => "",
+    };
     format!(
-        "{}{}{} - {} ({}, {})",
+        "{}{}{} - {} ({}{}, {})",
         fire,
         star_str,
         s.user_name,
         truncate(&s.game_name, 20),
         s.format_viewer_count(),
+        arrow,
         s.format_duration()
     )
 }
 
 /// Formats a scheduled stream label with optional sparkle/star prefix.
 ///
-/// Format: `"[✨ ][★ ]StreamerName - Tomorrow 3:00 PM"`
-pub(crate) fn format_scheduled_label_with_star(s: &ScheduledStream, star: bool) -> String {
+/// Format: `"[✨ ][★ ]StreamerName - Tomorrow 3:00 PM"`, with the time as
+/// `time_format` asks.
+pub(crate) fn format_scheduled_label_with_star(
+    s: &ScheduledStream,
+    star: bool,
+    time_format: ScheduleTimeFormat,
+    now: DateTime<Utc>,
+) -> String {
     let sparkle = if s.is_inferred { "\u{2728} " } else { "" };
     let star_str = if star { "\u{2605} " } else { "" };
-    format!(
-        "{}{}{} - {}",
-        sparkle,
-        star_str,
-        s.broadcaster_name,
-        s.format_start_time()
-    )
+    let time = match time_format {
+        ScheduleTimeFormat::Absolute => s.format_start_time(),
+        ScheduleTimeFormat::Relative => s.format_time_until(now),
+        ScheduleTimeFormat::Both => {
+            format!("{} ({})", s.format_start_time(), s.format_time_until(now))
+        }
+    };
+    format!("{}{}{} - {}", sparkle, star_str, s.broadcaster_name, time)
 }
 
 /// Formats a stream for a category submenu (no game name since it's implied).
@@ -151,6 +322,9 @@ pub(crate) fn format_category_stream_label(s: &Stream) -> String {
 /// business decisions (sorting, filtering, overflow splitting, label formatting)
 /// are made here so the render layer is a thin data → menu-item mapper.
 ///
+/// `streams` are listed in the order given, which the backend sorts with
+/// `AppState::get_followed_streams_sorted`.
+///
 /// `now` is passed in rather than calling `Utc::now()` directly so the function
 /// is deterministically testable.
 pub fn compute_display_state(
@@ -168,53 +342,67 @@ pub fn compute_display_state(
 
     // Filter out Ignore streamers
     streams.retain(|s| get_importance(&s.user_login, settings) != StreamerImportance::Ignore);
+    if config.hide_reruns {
+        streams.retain(|s| !s.is_rerun());
+    }
 
     // Remember which broadcasters are live (used for schedule filtering below)
     let live_logins: HashSet<String> = streams.iter().map(|s| s.user_login.clone()).collect();
 
-    // Sort: Favourites first, then by viewer count descending
-    streams.sort_by(|a, b| {
-        let a_fav = get_importance(&a.user_login, settings) == StreamerImportance::Favourite;
-        let b_fav = get_importance(&b.user_login, settings) == StreamerImportance::Favourite;
-        b_fav.cmp(&a_fav).then(b.viewer_count.cmp(&a.viewer_count))
-    });
+    // Pin favourites in their own section, which keeps the same order
+    let favourites: HashSet<String> = settings
+        .iter()
+        .filter(|(_, s)| s.importance == StreamerImportance::Favourite)
+        .map(|(login, _)| login.clone())
+        .collect();
+    let (favourite_streams, streams): (Vec<Stream>, Vec<Stream>) = streams
+        .into_iter()
+        .partition(|s| favourites.contains(&s.user_login));
+
+    let to_entry = |s: Stream| {
+        let is_fav = get_importance(&s.user_login, settings) == StreamerImportance::Favourite;
+        let is_hot = config.hot_stream_ids.contains(&s.user_id);
+        let is_new = config
+            .first_seen_at
+            .get(&s.user_id)
+            .is_some_and(|seen| now - *seen < Duration::minutes(RECENTLY_LIVE_WINDOW_MIN));
+        let trend = config.viewer_trends.get(&s.user_id).copied();
+        let mut label = format_stream_label_with_star(&s, is_fav, is_hot, trend);
+        if is_new {
+            label.push_str(" \u{2022} new");
+        }
+        StreamEntry {
+            stream: s,
+            label,
+            is_hot,
+            is_new,
+        }
+    };
 
-    let (live_visible_raw, live_overflow_raw) = if streams.len() > config.live_limit {
-        let (main, over) = streams.split_at(config.live_limit);
-        (main.to_vec(), over.to_vec())
-    } else {
-        (streams, Vec::new())
+    let favourites_section = FavouritesSection {
+        offline: compute_offline_favourites(settings, &favourite_streams),
+        live: favourite_streams.into_iter().map(to_entry).collect(),
     };
 
-    let live_section = LiveSection {
-        visible: live_visible_raw
-            .into_iter()
-            .map(|s| {
-                let is_fav =
-                    get_importance(&s.user_login, settings) == StreamerImportance::Favourite;
-                let is_hot = config.hot_stream_ids.contains(&s.user_id);
-                let label = format_stream_label_with_star(&s, is_fav, is_hot);
-                StreamEntry {
-                    stream: s,
-                    label,
-                    is_hot,
-                }
-            })
-            .collect(),
-        overflow: live_overflow_raw
-            .into_iter()
-            .map(|s| {
-                let is_fav =
-                    get_importance(&s.user_login, settings) == StreamerImportance::Favourite;
-                let is_hot = config.hot_stream_ids.contains(&s.user_id);
-                let label = format_stream_label_with_star(&s, is_fav, is_hot);
-                StreamEntry {
-                    stream: s,
-                    label,
-                    is_hot,
-                }
-            })
-            .collect(),
+    // Grouped streams all sit in submenus, so the limit only applies to the flat list
+    let live_section = if config.group_by_game {
+        LiveSection {
+            visible: Vec::new(),
+            overflow: Vec::new(),
+            groups: group_by_game(streams.into_iter().map(to_entry).collect()),
+        }
+    } else {
+        let (live_visible_raw, live_overflow_raw) = if streams.len() > config.live_limit {
+            let (main, over) = streams.split_at(config.live_limit);
+            (main.to_vec(), over.to_vec())
+        } else {
+            (streams, Vec::new())
+        };
+        LiveSection {
+            visible: live_visible_raw.into_iter().map(to_entry).collect(),
+            overflow: live_overflow_raw.into_iter().map(to_entry).collect(),
+            groups: Vec::new(),
+        }
     };
 
     // --- Category sections ---
@@ -238,7 +426,11 @@ pub fn compute_display_state(
                     })
                     .collect();
 
-                category_sections.push(CategorySection { header, entries });
+                category_sections.push(CategorySection {
+                    name: category.name.clone(),
+                    header,
+                    entries,
+                });
             }
         }
     }
@@ -271,7 +463,8 @@ pub fn compute_display_state(
             .map(|s| {
                 let is_fav =
                     get_importance(&s.broadcaster_login, settings) == StreamerImportance::Favourite;
-                let label = format_scheduled_label_with_star(&s, is_fav);
+                let label =
+                    format_scheduled_label_with_star(&s, is_fav, config.schedule_time_format, now);
                 ScheduledEntry {
                     scheduled: s,
                     label,
@@ -283,7 +476,8 @@ pub fn compute_display_state(
             .map(|s| {
                 let is_fav =
                     get_importance(&s.broadcaster_login, settings) == StreamerImportance::Favourite;
-                let label = format_scheduled_label_with_star(&s, is_fav);
+                let label =
+                    format_scheduled_label_with_star(&s, is_fav, config.schedule_time_format, now);
                 ScheduledEntry {
                     scheduled: s,
                     label,
@@ -293,12 +487,206 @@ pub fn compute_display_state(
         schedules_loaded,
     };
 
+    let live_count = favourites_section.live.len() + live_section.total();
+    let tooltip = format_tooltip(live_count, &schedule_section, config.api_unreachable, now);
+    let icon = compute_icon(live_count, &favourites_section);
+
     DisplayState {
         authenticated: true,
+        account: compute_account(config, now),
+        favourites: favourites_section,
         live_section,
         schedule_section,
         category_sections,
+        recent_notifications: Vec::new(),
+        muted_today: compute_muted_today(&config.streamer_settings, now),
+        has_external_player: config.has_external_player,
+        toggles: config
+            .toggles
+            .iter()
+            .map(|&(toggle, checked)| ToggleEntry {
+                toggle,
+                label: toggle_label(toggle),
+                checked,
+            })
+            .collect(),
+        tooltip,
+        icon,
+    }
+}
+
+/// Groups live stream entries by game, keeping their order within each group.
+///
+/// Groups with the most streams come first, with streams that have no game
+/// last under "Other".
+fn group_by_game(entries: Vec<StreamEntry>) -> Vec<GameGroup> {
+    let mut groups: Vec<(String, Vec<StreamEntry>)> = Vec::new();
+    for entry in entries {
+        let game = if entry.stream.game_name.is_empty() {
+            OTHER_GAME
+        } else {
+            entry.stream.game_name.as_str()
+        };
+        match groups.iter_mut().find(|(g, _)| g == game) {
+            Some((_, group)) => group.push(entry),
+            None => groups.push((game.to_string(), vec![entry])),
+        }
+    }
+
+    groups.sort_by(|(a_game, a), (b_game, b)| {
+        (a_game == OTHER_GAME)
+            .cmp(&(b_game == OTHER_GAME))
+            .then(b.len().cmp(&a.len()))
+            .then_with(|| a_game.cmp(b_game))
+    });
+    groups
+        .into_iter()
+        .map(|(game, entries)| GameGroup {
+            header: format!("{} ({})", game, entries.len()),
+            entries,
+        })
+        .collect()
+}
+
+/// Menu label for a setting checkbox.
+fn toggle_label(toggle: MenuToggle) -> &'static str {
+    match toggle {
+        MenuToggle::NotifyOnLive => "Notify on live",
+        MenuToggle::NotifyOnCategory => "Notify on category change",
+        MenuToggle::HideReruns => "Hide reruns",
+        MenuToggle::GroupLiveByGame => "Group by game",
+        MenuToggle::NotificationsPaused => "Pause notifications",
+    }
+}
+
+/// Picks the tray icon from the number of live streams shown in the menu, so
+/// ignored streamers don't count.
+fn compute_icon(count: usize, favourites: &FavouritesSection) -> IconState {
+    if count == 0 {
+        IconState::Idle
+    } else if !favourites.live.is_empty() {
+        IconState::FavouriteLive { count }
+    } else {
+        IconState::Live { count }
+    }
+}
+
+/// Summarises the menu for the tray icon tooltip, e.g.
+/// `"Twitch Tray — 6 live · next: Streamer at 7:00 PM"`.
+///
+/// Kept short since some platforms cut tooltips off.
+fn format_tooltip(
+    live_count: usize,
+    schedule: &ScheduleSection,
+    api_unreachable: bool,
+    now: DateTime<Utc>,
+) -> String {
+    let mut tooltip = format!("{TOOLTIP_TITLE} \u{2014} {live_count} live");
+
+    if let Some(next) = schedule
+        .visible
+        .iter()
+        .map(|e| &e.scheduled)
+        .find(|s| s.start_time >= now)
+    {
+        tooltip.push_str(&format!(
+            " \u{b7} next: {} at {}",
+            truncate(&next.broadcaster_name, 25),
+            next.start_time.with_timezone(&Local).format("%-I:%M %p")
+        ));
+    }
+    if api_unreachable {
+        tooltip.push_str(" \u{b7} \u{26a0} Twitch unreachable");
+    }
+
+    truncate_utf16(&tooltip, TOOLTIP_MAX_LEN)
+}
+
+/// Lists favourites that aren't among the live streams, by name.
+fn compute_offline_favourites(
+    streamer_settings: &HashMap<String, StreamerSettings>,
+    live: &[Stream],
+) -> Vec<OfflineFavourite> {
+    let mut offline: Vec<(&String, &StreamerSettings)> = streamer_settings
+        .iter()
+        .filter(|(_, s)| s.importance == StreamerImportance::Favourite)
+        .filter(|(login, _)| !live.iter().any(|s| &s.user_login == *login))
+        .collect();
+    offline.sort_by_key(|(_, s)| s.display_name.to_lowercase());
+
+    offline
+        .into_iter()
+        .map(|(login, s)| OfflineFavourite {
+            user_login: login.clone(),
+            label: format!("{} \u{2014} offline", s.display_name),
+        })
+        .collect()
+}
+
+/// Describes the signed-in account and the connection to Twitch.
+fn compute_account(config: &DisplayConfig, now: DateTime<Utc>) -> Option<AccountSection> {
+    if config.user_login.is_empty() {
+        return None;
     }
+    let status = match (config.api_unreachable, config.api_last_success_at) {
+        (true, Some(at)) => format!(
+            "Twitch unreachable (last update {} ago)",
+            format_duration(now - at)
+        ),
+        (true, None) => "Twitch unreachable".to_string(),
+        (false, Some(_)) => "Connected".to_string(),
+        (false, None) => "Connecting...".to_string(),
+    };
+    Some(AccountSection {
+        header: format!("Logged in as {}", config.user_login),
+        status,
+    })
+}
+
+/// Lists streamers whose "Mute today" mute hasn't expired, by name.
+fn compute_muted_today(
+    streamer_settings: &HashMap<String, StreamerSettings>,
+    now: DateTime<Utc>,
+) -> Vec<MutedEntry> {
+    let mut muted: Vec<(&String, &StreamerSettings)> = streamer_settings
+        .iter()
+        .filter(|(_, s)| s.muted_until.is_some_and(|until| now < until))
+        .collect();
+    muted.sort_by_key(|(_, s)| s.display_name.to_lowercase());
+
+    muted
+        .into_iter()
+        .map(|(login, s)| MutedEntry {
+            label: format!("Unmute {}", s.display_name),
+            user_login: login.clone(),
+        })
+        .collect()
+}
+
+/// Labels recent notifications, newest first, with how long ago each was sent.
+///
+/// Entries about a channel that is still live can be clicked to open it.
+pub fn compute_recent_notifications(
+    history: &[HistoryEntry],
+    live_streams: &[Stream],
+    now: DateTime<Utc>,
+) -> Vec<RecentNotificationEntry> {
+    let live_logins: HashSet<&str> = live_streams.iter().map(|s| s.user_login.as_str()).collect();
+
+    history
+        .iter()
+        .map(|entry| RecentNotificationEntry {
+            label: format!(
+                "{} ({} ago)",
+                truncate(&entry.message, 50),
+                format_duration(now - entry.at)
+            ),
+            open_login: entry
+                .user_login
+                .clone()
+                .filter(|login| live_logins.contains(login.as_str())),
+        })
+        .collect()
 }
 
 #[cfg(test)]
@@ -306,6 +694,7 @@ mod tests {
     use super::*;
     use crate::test_helpers::{make_scheduled, make_stream};
     use chrono::Duration;
+    use twitch_backend::notification_history::HistoryKind;
 
     // =========================================================
     // Helpers
@@ -333,7 +722,17 @@ mod tests {
             schedule_lookahead_hours: 6,
             live_limit: 10,
             schedule_limit: 5,
+            schedule_time_format: ScheduleTimeFormat::Absolute,
             hot_stream_ids: HashSet::new(),
+            viewer_trends: HashMap::new(),
+            first_seen_at: HashMap::new(),
+            user_login: String::new(),
+            api_unreachable: false,
+            api_last_success_at: None,
+            group_by_game: false,
+            has_external_player: false,
+            hide_reruns: false,
+            toggles: Vec::new(),
         }
     }
 
@@ -346,6 +745,8 @@ mod tests {
                 display_name: user_login.to_string(),
                 importance,
                 hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
             },
         );
         DisplayConfig {
@@ -353,7 +754,17 @@ mod tests {
             schedule_lookahead_hours: 6,
             live_limit: 10,
             schedule_limit: 5,
+            schedule_time_format: ScheduleTimeFormat::Absolute,
             hot_stream_ids: HashSet::new(),
+            viewer_trends: HashMap::new(),
+            first_seen_at: HashMap::new(),
+            user_login: String::new(),
+            api_unreachable: false,
+            api_last_success_at: None,
+            group_by_game: false,
+            has_external_player: false,
+            hide_reruns: false,
+            toggles: Vec::new(),
         }
     }
 
@@ -367,7 +778,7 @@ mod tests {
         s.game_name = "Fortnite".to_string();
         s.viewer_count = 5000;
         s.started_at = Utc::now() - Duration::hours(2);
-        let label = format_stream_label_with_star(&s, false, false);
+        let label = format_stream_label_with_star(&s, false, false, None);
 
         assert!(label.contains("Ninja"), "should contain streamer name");
         assert!(label.contains("Fortnite"), "should contain game name");
@@ -380,7 +791,7 @@ mod tests {
         let mut s = make_stream("streamer", "Streamer");
         s.game_name = "This Is A Very Long Game Name That Should Be Truncated".to_string();
         s.viewer_count = 1000;
-        let label = format_stream_label_with_star(&s, false, false);
+        let label = format_stream_label_with_star(&s, false, false, None);
 
         assert!(label.contains("..."), "long game name should be truncated");
     }
@@ -389,7 +800,7 @@ mod tests {
     fn format_stream_label_small_viewers_exact() {
         let mut s = make_stream("smallstreamer", "SmallStreamer");
         s.viewer_count = 42;
-        let label = format_stream_label_with_star(&s, false, false);
+        let label = format_stream_label_with_star(&s, false, false, None);
 
         assert!(
             label.contains("42"),
@@ -401,8 +812,8 @@ mod tests {
     #[test]
     fn format_stream_label_star_prefix() {
         let s = make_stream("fav", "Fav");
-        let with_star = format_stream_label_with_star(&s, true, false);
-        let without_star = format_stream_label_with_star(&s, false, false);
+        let with_star = format_stream_label_with_star(&s, true, false, None);
+        let without_star = format_stream_label_with_star(&s, false, false, None);
 
         assert!(
             with_star.starts_with('\u{2605}'),
@@ -414,6 +825,22 @@ mod tests {
         );
     }
 
+    #[test]
+    fn format_stream_label_trend_arrow() {
+        let s = make_stream("trendy", "Trendy");
+        let rising = format_stream_label_with_star(&s, false, false, Some(ViewerTrend::Rising));
+        let falling = format_stream_label_with_star(&s, false, false, Some(ViewerTrend::Falling));
+        let steady = format_stream_label_with_star(&s, false, false, Some(ViewerTrend::Steady));
+
+        assert!(rising.contains("\u{2191},"), "rising shows ↑: {rising}");
+        assert!(falling.contains("\u{2193},"), "falling shows ↓: {falling}");
+        assert_eq!(
+            steady,
+            format_stream_label_with_star(&s, false, false, None),
+            "steady shows no arrow"
+        );
+    }
+
     // =========================================================
     // format_scheduled_label_with_star
     // =========================================================
@@ -421,7 +848,12 @@ mod tests {
     #[test]
     fn format_scheduled_label_basic() {
         let sched = make_scheduled("StreamerName", 5);
-        let label = format_scheduled_label_with_star(&sched, false);
+        let label = format_scheduled_label_with_star(
+            &sched,
+            false,
+            ScheduleTimeFormat::Absolute,
+            Utc::now(),
+        );
 
         assert!(
             label.starts_with("StreamerName - "),
@@ -433,7 +865,12 @@ mod tests {
     #[test]
     fn format_scheduled_label_contains_time() {
         let sched = make_scheduled("TestStreamer", 2);
-        let label = format_scheduled_label_with_star(&sched, false);
+        let label = format_scheduled_label_with_star(
+            &sched,
+            false,
+            ScheduleTimeFormat::Absolute,
+            Utc::now(),
+        );
 
         let has_time = label.contains("Today")
             || label.contains("Tomorrow")
@@ -444,11 +881,45 @@ mod tests {
         assert!(has_time, "label should contain time info: {}", label);
     }
 
+    #[test]
+    fn format_scheduled_label_relative_and_both() {
+        let now = Utc::now();
+        let mut sched = make_scheduled("Streamer", 0);
+        sched.start_time = now + Duration::minutes(135);
+
+        let relative =
+            format_scheduled_label_with_star(&sched, false, ScheduleTimeFormat::Relative, now);
+        let both = format_scheduled_label_with_star(&sched, false, ScheduleTimeFormat::Both, now);
+
+        assert_eq!(relative, "Streamer - in 2h 15m");
+        assert_eq!(
+            both,
+            format!("Streamer - {} (in 2h 15m)", sched.format_start_time())
+        );
+    }
+
+    #[test]
+    fn format_scheduled_label_after_start_is_live_soon() {
+        let now = Utc::now();
+        let mut sched = make_scheduled("Streamer", 0);
+        sched.start_time = now - Duration::minutes(5);
+
+        let label =
+            format_scheduled_label_with_star(&sched, false, ScheduleTimeFormat::Relative, now);
+
+        assert_eq!(label, "Streamer - live soon...");
+    }
+
     #[test]
     fn format_scheduled_label_sparkle_for_inferred() {
         let mut sched = make_scheduled("Streamer", 3);
         sched.is_inferred = true;
-        let label = format_scheduled_label_with_star(&sched, false);
+        let label = format_scheduled_label_with_star(
+            &sched,
+            false,
+            ScheduleTimeFormat::Absolute,
+            Utc::now(),
+        );
 
         assert!(
             label.starts_with('\u{2728}'),
@@ -459,7 +930,12 @@ mod tests {
     #[test]
     fn format_scheduled_label_star_for_favourite() {
         let sched = make_scheduled("Streamer", 3);
-        let label = format_scheduled_label_with_star(&sched, true);
+        let label = format_scheduled_label_with_star(
+            &sched,
+            true,
+            ScheduleTimeFormat::Absolute,
+            Utc::now(),
+        );
 
         assert!(label.contains('\u{2605}'), "favourite should contain ★");
     }
@@ -468,7 +944,12 @@ mod tests {
     fn format_scheduled_label_sparkle_and_star() {
         let mut sched = make_scheduled("Streamer", 3);
         sched.is_inferred = true;
-        let label = format_scheduled_label_with_star(&sched, true);
+        let label = format_scheduled_label_with_star(
+            &sched,
+            true,
+            ScheduleTimeFormat::Absolute,
+            Utc::now(),
+        );
 
         assert!(label.starts_with('\u{2728}'), "should start with ✨");
         assert!(label.contains('\u{2605}'), "should also contain ★");
@@ -510,8 +991,8 @@ mod tests {
     }
 
     #[test]
-    fn live_streams_sorted_favourites_first() {
-        // normal_high has more viewers, fav_low is a favourite — fav should appear first
+    fn live_favourites_pinned_in_their_own_section() {
+        // normal_high has more viewers, fav_low is a favourite — fav is pinned anyway
         let mut normal_high = stream_with_viewers("normal_high", 50_000);
         normal_high.user_login = "normal_high".to_string();
         let mut fav_low = stream_with_viewers("fav_low", 100);
@@ -529,18 +1010,96 @@ mod tests {
             Utc::now(),
         );
 
+        assert_eq!(state.favourites.live.len(), 1);
+        assert_eq!(state.favourites.live[0].stream.user_login, "fav_low");
+        assert!(state.favourites.offline.is_empty());
+        assert_eq!(state.live_section.total(), 1);
         assert_eq!(
-            state.live_section.visible[0].stream.user_login, "fav_low",
-            "favourite should appear first despite lower viewer count"
+            state.live_section.visible[0].stream.user_login,
+            "normal_high"
+        );
+    }
+
+    #[test]
+    fn favourites_keep_backend_order() {
+        let mut config = config_with_importance("low", StreamerImportance::Favourite);
+        config.streamer_settings.insert(
+            "high".to_string(),
+            StreamerSettings {
+                display_name: "High".to_string(),
+                importance: StreamerImportance::Favourite,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
+            },
+        );
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![
+                stream_with_viewers("low", 10),
+                stream_with_viewers("high", 1_000),
+            ],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config,
+            Utc::now(),
+        );
+
+        let logins: Vec<&str> = state
+            .favourites
+            .live
+            .iter()
+            .map(|e| e.stream.user_login.as_str())
+            .collect();
+        assert_eq!(logins, vec!["low", "high"], "not re-sorted by the menu");
+    }
+
+    #[test]
+    fn offline_favourites_listed_by_name() {
+        let mut config = config_with_importance("zed", StreamerImportance::Favourite);
+        config.streamer_settings.insert(
+            "amy".to_string(),
+            StreamerSettings {
+                display_name: "Amy".to_string(),
+                importance: StreamerImportance::Favourite,
+                hotness_z_threshold_override: None,
+                notify_on_offline: false,
+                muted_until: None,
+            },
+        );
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![make_stream("1", "Other")],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config,
+            Utc::now(),
         );
+
+        let offline: Vec<(&str, &str)> = state
+            .favourites
+            .offline
+            .iter()
+            .map(|f| (f.user_login.as_str(), f.label.as_str()))
+            .collect();
         assert_eq!(
-            state.live_section.visible[1].stream.user_login,
-            "normal_high"
+            offline,
+            vec![
+                ("amy", "Amy \u{2014} offline"),
+                ("zed", "zed \u{2014} offline")
+            ]
         );
+        assert!(state.favourites.live.is_empty());
     }
 
     #[test]
-    fn live_streams_sorted_by_viewers_within_group() {
+    fn live_streams_keep_backend_order() {
         let mut s1 = stream_with_viewers("low", 1_000);
         s1.user_login = "low".to_string();
         let mut s2 = stream_with_viewers("high", 10_000);
@@ -557,8 +1116,8 @@ mod tests {
             Utc::now(),
         );
 
-        assert_eq!(state.live_section.visible[0].stream.user_login, "high");
-        assert_eq!(state.live_section.visible[1].stream.user_login, "low");
+        assert_eq!(state.live_section.visible[0].stream.user_login, "low");
+        assert_eq!(state.live_section.visible[1].stream.user_login, "high");
     }
 
     #[test]
@@ -633,11 +1192,71 @@ mod tests {
         );
 
         assert!(
-            state.live_section.visible[0].label.contains('\u{2605}'),
+            state.favourites.live[0].label.contains('\u{2605}'),
             "favourite label should contain ★"
         );
     }
 
+    // =========================================================
+    // compute_display_state — recently live
+    // =========================================================
+
+    #[test]
+    fn recently_seen_stream_marked_new() {
+        let now = Utc::now();
+        let mut fresh = make_stream("fresh", "Fresh");
+        fresh.user_id = "uid_fresh".to_string();
+        let mut old = make_stream("old", "Old");
+        old.user_id = "uid_old".to_string();
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![fresh, old],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &DisplayConfig {
+                first_seen_at: HashMap::from([
+                    ("uid_fresh".to_string(), now - Duration::minutes(3)),
+                    ("uid_old".to_string(), now - Duration::minutes(30)),
+                ]),
+                ..default_config()
+            },
+            now,
+        );
+
+        let entries = &state.live_section.visible;
+        assert!(entries[0].is_new);
+        assert!(entries[0].label.ends_with("\u{2022} new"));
+        assert!(!entries[1].is_new);
+        assert!(!entries[1].label.contains("new"));
+    }
+
+    #[test]
+    fn new_marker_clears_as_time_passes() {
+        let seen = Utc::now();
+        let mut s = make_stream("fresh", "Fresh");
+        s.user_id = "uid_fresh".to_string();
+        let (cats, cat_streams) = no_categories();
+        let config = DisplayConfig {
+            first_seen_at: HashMap::from([("uid_fresh".to_string(), seen)]),
+            ..default_config()
+        };
+
+        let later = compute_display_state(
+            vec![s],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config,
+            seen + Duration::minutes(RECENTLY_LIVE_WINDOW_MIN),
+        );
+
+        assert!(!later.live_section.visible[0].is_new);
+    }
+
     // =========================================================
     // compute_display_state — hotness
     // =========================================================
@@ -903,6 +1522,8 @@ mod tests {
         let cats = vec![FollowedCategory {
             id: "cat1".to_string(),
             name: "Minecraft".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         let mut cat_streams = HashMap::new();
         cat_streams.insert(
@@ -930,6 +1551,8 @@ mod tests {
         let cats = vec![FollowedCategory {
             id: "cat1".to_string(),
             name: "Minecraft".to_string(),
+            tags: vec![],
+            viewer_alert: None,
         }];
         let cat_streams = HashMap::new(); // no streams for cat1
 
@@ -948,4 +1571,471 @@ mod tests {
             "no section created when category has no streams"
         );
     }
+
+    // =========================================================
+    // compute_display_state — tooltip
+    // =========================================================
+
+    #[test]
+    fn tooltip_counts_live_and_names_next_scheduled() {
+        let now = Utc::now();
+        let (cats, cat_streams) = no_categories();
+        let streams = (0..12)
+            .map(|i| stream_with_viewers(&format!("S{i}"), 100))
+            .collect();
+        let next = make_scheduled("Later", 2);
+        let expected_time = next.start_time.with_timezone(&Local).format("%-I:%M %p");
+
+        let state = compute_display_state(
+            streams,
+            vec![make_scheduled("Started", -1), next.clone()],
+            true,
+            &cats,
+            &cat_streams,
+            &default_config(),
+            now,
+        );
+
+        assert_eq!(
+            state.tooltip,
+            format!("Twitch Tray \u{2014} 12 live \u{b7} next: Later at {expected_time}")
+        );
+    }
+
+    #[test]
+    fn tooltip_warns_when_twitch_unreachable() {
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &DisplayConfig {
+                api_unreachable: true,
+                ..default_config()
+            },
+            Utc::now(),
+        );
+
+        assert_eq!(
+            state.tooltip,
+            "Twitch Tray \u{2014} 0 live \u{b7} \u{26a0} Twitch unreachable"
+        );
+    }
+
+    #[test]
+    fn tooltip_fits_platform_limit() {
+        let (cats, cat_streams) = no_categories();
+        let mut next = make_scheduled("Someone", 1);
+        next.broadcaster_name = "x".repeat(200);
+
+        let state = compute_display_state(
+            vec![],
+            vec![next],
+            true,
+            &cats,
+            &cat_streams,
+            &DisplayConfig {
+                api_unreachable: true,
+                ..default_config()
+            },
+            Utc::now(),
+        );
+
+        assert!(state.tooltip.encode_utf16().count() <= TOOLTIP_MAX_LEN);
+        assert!(state.tooltip.ends_with("Twitch unreachable"));
+    }
+
+    // =========================================================
+    // compute_display_state — account
+    // =========================================================
+
+    fn account_for(config: DisplayConfig, now: DateTime<Utc>) -> Option<AccountSection> {
+        let (cats, cat_streams) = no_categories();
+        compute_display_state(
+            vec![],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config,
+            now,
+        )
+        .account
+    }
+
+    #[test]
+    fn account_shows_login_and_connection() {
+        let now = Utc::now();
+
+        let account = account_for(
+            DisplayConfig {
+                user_login: "somebody".to_string(),
+                api_last_success_at: Some(now - Duration::seconds(20)),
+                ..default_config()
+            },
+            now,
+        )
+        .unwrap();
+
+        assert_eq!(account.header, "Logged in as somebody");
+        assert_eq!(account.status, "Connected");
+    }
+
+    #[test]
+    fn account_status_says_when_twitch_was_last_reached() {
+        let now = Utc::now();
+        let config = |last_success_at| DisplayConfig {
+            user_login: "somebody".to_string(),
+            api_unreachable: true,
+            api_last_success_at: last_success_at,
+            ..default_config()
+        };
+
+        let stale = account_for(config(Some(now - Duration::minutes(5))), now).unwrap();
+        let never = account_for(config(None), now).unwrap();
+
+        assert_eq!(stale.status, "Twitch unreachable (last update 5m ago)");
+        assert_eq!(never.status, "Twitch unreachable");
+    }
+
+    #[test]
+    fn account_is_connecting_before_first_request() {
+        let account = account_for(
+            DisplayConfig {
+                user_login: "somebody".to_string(),
+                ..default_config()
+            },
+            Utc::now(),
+        )
+        .unwrap();
+
+        assert_eq!(account.status, "Connecting...");
+    }
+
+    #[test]
+    fn no_account_without_login() {
+        assert!(account_for(default_config(), Utc::now()).is_none());
+    }
+
+    // =========================================================
+    // compute_display_state — grouping by game
+    // =========================================================
+
+    fn grouped_config() -> DisplayConfig {
+        DisplayConfig {
+            group_by_game: true,
+            ..default_config()
+        }
+    }
+
+    fn stream_playing(name: &str, game: &str, viewer_count: u32) -> Stream {
+        let mut s = stream_with_viewers(name, viewer_count);
+        s.game_name = game.to_string();
+        s
+    }
+
+    #[test]
+    fn grouped_live_streams_are_grouped_by_game() {
+        let (cats, cat_streams) = no_categories();
+        let streams = vec![
+            stream_playing("Fact2", "Factorio", 300),
+            stream_playing("Fact1", "Factorio", 100),
+            stream_playing("Chess1", "Chess", 50),
+            stream_playing("Nothing", "", 10),
+        ];
+
+        let state = compute_display_state(
+            streams,
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &grouped_config(),
+            Utc::now(),
+        );
+
+        let live = &state.live_section;
+        assert!(live.visible.is_empty() && live.overflow.is_empty());
+        let headers: Vec<&str> = live.groups.iter().map(|g| g.header.as_str()).collect();
+        assert_eq!(headers, vec!["Factorio (2)", "Chess (1)", "Other (1)"]);
+        let factorio: Vec<&str> = live.groups[0]
+            .entries
+            .iter()
+            .map(|e| e.stream.user_name.as_str())
+            .collect();
+        assert_eq!(factorio, vec!["Fact2", "Fact1"], "in the order given");
+        assert_eq!(live.total(), 4);
+    }
+
+    #[test]
+    fn grouped_live_streams_ignore_live_limit() {
+        let (cats, cat_streams) = no_categories();
+        let streams = (0..15)
+            .map(|i| stream_playing(&format!("S{i}"), "Factorio", 100))
+            .collect();
+
+        let state = compute_display_state(
+            streams,
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &grouped_config(),
+            Utc::now(),
+        );
+
+        assert_eq!(state.live_section.groups.len(), 1);
+        assert_eq!(state.live_section.groups[0].entries.len(), 15);
+        assert_eq!(state.icon, IconState::Live { count: 15 });
+    }
+
+    #[test]
+    fn live_streams_are_flat_by_default() {
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![stream_playing("Fact1", "Factorio", 100)],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &default_config(),
+            Utc::now(),
+        );
+
+        assert_eq!(state.live_section.visible.len(), 1);
+        assert!(state.live_section.groups.is_empty());
+    }
+
+    // =========================================================
+    // compute_display_state — reruns and toggles
+    // =========================================================
+
+    #[test]
+    fn reruns_are_hidden_when_configured() {
+        let (cats, cat_streams) = no_categories();
+        let mut rerun = make_stream("2", "Rerunner");
+        rerun.stream_type = "rerun".to_string();
+        let streams = || vec![make_stream("1", "Live"), rerun.clone()];
+
+        let shown = compute_display_state(
+            streams(),
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &default_config(),
+            Utc::now(),
+        );
+        let hidden = compute_display_state(
+            streams(),
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &DisplayConfig {
+                hide_reruns: true,
+                ..default_config()
+            },
+            Utc::now(),
+        );
+
+        assert_eq!(shown.live_section.total(), 2);
+        assert_eq!(hidden.live_section.total(), 1);
+        assert_eq!(hidden.live_section.visible[0].stream.user_name, "Live");
+    }
+
+    #[test]
+    fn toggles_are_labelled_and_keep_their_state() {
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &DisplayConfig {
+                toggles: vec![
+                    (MenuToggle::NotifyOnLive, true),
+                    (MenuToggle::NotificationsPaused, false),
+                ],
+                ..default_config()
+            },
+            Utc::now(),
+        );
+
+        let toggles: Vec<(&str, bool)> =
+            state.toggles.iter().map(|t| (t.label, t.checked)).collect();
+        assert_eq!(
+            toggles,
+            vec![("Notify on live", true), ("Pause notifications", false)]
+        );
+    }
+
+    // =========================================================
+    // compute_display_state — icon
+    // =========================================================
+
+    #[test]
+    fn icon_is_idle_when_nobody_is_live() {
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &default_config(),
+            Utc::now(),
+        );
+
+        assert_eq!(state.icon, IconState::Idle);
+        assert_eq!(DisplayState::unauthenticated().icon, IconState::LoggedOut);
+    }
+
+    #[test]
+    fn icon_counts_live_including_overflow() {
+        let (cats, cat_streams) = no_categories();
+        let streams = (0..12)
+            .map(|i| stream_with_viewers(&format!("S{i}"), 100))
+            .collect();
+
+        let state = compute_display_state(
+            streams,
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &default_config(),
+            Utc::now(),
+        );
+
+        assert_eq!(state.icon, IconState::Live { count: 12 });
+    }
+
+    #[test]
+    fn icon_marks_live_favourite() {
+        let (cats, cat_streams) = no_categories();
+        let streams = vec![
+            make_stream("other", "Other"),
+            make_stream("favuser", "FavUser"),
+        ];
+
+        let state = compute_display_state(
+            streams,
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config_with_importance("favuser", StreamerImportance::Favourite),
+            Utc::now(),
+        );
+
+        assert_eq!(state.icon, IconState::FavouriteLive { count: 2 });
+    }
+
+    #[test]
+    fn icon_ignores_ignored_streamers() {
+        let (cats, cat_streams) = no_categories();
+
+        let state = compute_display_state(
+            vec![make_stream("ignored", "Ignored")],
+            no_scheduled(),
+            true,
+            &cats,
+            &cat_streams,
+            &config_with_importance("ignored", StreamerImportance::Ignore),
+            Utc::now(),
+        );
+
+        assert_eq!(state.icon, IconState::Idle);
+    }
+
+    // =========================================================
+    // Muted today
+    // =========================================================
+
+    fn muted_until(display_name: &str, until: DateTime<Utc>) -> StreamerSettings {
+        StreamerSettings {
+            display_name: display_name.to_string(),
+            importance: StreamerImportance::Normal,
+            hotness_z_threshold_override: None,
+            notify_on_offline: false,
+            muted_until: Some(until),
+        }
+    }
+
+    #[test]
+    fn muted_today_lists_unexpired_mutes_by_name() {
+        let now = Utc::now();
+        let mut settings = HashMap::new();
+        settings.insert(
+            "zed".to_string(),
+            muted_until("Zed", now + Duration::hours(2)),
+        );
+        settings.insert(
+            "alice".to_string(),
+            muted_until("alice", now + Duration::hours(2)),
+        );
+        settings.insert(
+            "bob".to_string(),
+            muted_until("Bob", now - Duration::minutes(1)),
+        );
+
+        let muted = compute_muted_today(&settings, now);
+
+        let labels: Vec<&str> = muted.iter().map(|m| m.label.as_str()).collect();
+        assert_eq!(labels, vec!["Unmute alice", "Unmute Zed"]);
+        assert_eq!(muted[1].user_login, "zed");
+    }
+
+    // =========================================================
+    // Recent notifications
+    // =========================================================
+
+    fn history_entry(user_login: Option<&str>, message: &str, at: DateTime<Utc>) -> HistoryEntry {
+        HistoryEntry {
+            kind: HistoryKind::Live,
+            user_login: user_login.map(str::to_string),
+            message: message.to_string(),
+            at,
+        }
+    }
+
+    #[test]
+    fn recent_notifications_show_age() {
+        let now = Utc::now();
+        let history = vec![history_entry(
+            Some("alice"),
+            "Alice went live",
+            now - Duration::minutes(75),
+        )];
+
+        let recent = compute_recent_notifications(&history, &[], now);
+
+        assert_eq!(recent.len(), 1);
+        assert_eq!(recent[0].label, "Alice went live (1h 15m ago)");
+    }
+
+    #[test]
+    fn recent_notifications_open_only_live_channels() {
+        let now = Utc::now();
+        let history = vec![
+            history_entry(Some("alice"), "Alice went live", now),
+            history_entry(Some("bob"), "Bob went live", now),
+            history_entry(None, "Twitch is unreachable", now),
+        ];
+        let live = vec![make_stream("1", "Alice")];
+
+        let recent = compute_recent_notifications(&history, &live, now);
+
+        assert_eq!(recent[0].open_login.as_deref(), Some("alice"));
+        assert_eq!(recent[1].open_login, None, "Bob is no longer live");
+        assert_eq!(recent[2].open_login, None);
+    }
 }
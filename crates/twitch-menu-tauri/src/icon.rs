@@ -0,0 +1,228 @@
+//! Tray icon rendering
+//!
+//! The icon shows at a glance whether anyone is live: a badge in the corner,
+//! amber when a favourite is live and red otherwise, with the number of live
+//! channels drawn on it for counts 1–9. Pure pixel work, so it is testable
+//! without a tray.
+
+use crate::display_state::IconState;
+
+const ICON_BYTES: &[u8] = include_bytes!(concat!(
+    env!("CARGO_MANIFEST_DIR"),
+    "/../twitch-app-tauri/icons/icon.png"
+));
+const ICON_GREY_BYTES: &[u8] = include_bytes!(concat!(
+    env!("CARGO_MANIFEST_DIR"),
+    "/../twitch-app-tauri/icons/icon_grey.png"
+));
+
+/// Badge colour while followed channels are live
+const LIVE_BADGE: [u8; 4] = [0xe9, 0x19, 0x16, 0xff];
+
+/// Badge colour while a favourite is live
+const FAVOURITE_BADGE: [u8; 4] = [0xff, 0xb3, 0x00, 0xff];
+
+/// Colour of the count drawn on the badge
+const DIGIT_COLOUR: [u8; 4] = [0xff, 0xff, 0xff, 0xff];
+
+/// 3×5 bitmaps of the digits 1–9, one row per entry, leftmost pixel in the
+/// high bit
+const DIGITS: [[u8; 5]; 9] = [
+    [0b010, 0b110, 0b010, 0b010, 0b111],
+    [0b111, 0b001, 0b111, 0b100, 0b111],
+    [0b111, 0b001, 0b111, 0b001, 0b111],
+    [0b101, 0b101, 0b111, 0b001, 0b001],
+    [0b111, 0b100, 0b111, 0b001, 0b111],
+    [0b111, 0b100, 0b111, 0b101, 0b111],
+    [0b111, 0b001, 0b001, 0b001, 0b001],
+    [0b111, 0b101, 0b111, 0b101, 0b111],
+    [0b111, 0b101, 0b111, 0b001, 0b111],
+];
+
+/// An RGBA image, four bytes per pixel, row by row
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Rgba {
+    pub width: u32,
+    pub height: u32,
+    pub pixels: Vec<u8>,
+}
+
+impl Rgba {
+    fn pixel(&self, x: u32, y: u32) -> [u8; 4] {
+        let i = ((y * self.width + x) * 4) as usize;
+        [
+            self.pixels[i],
+            self.pixels[i + 1],
+            self.pixels[i + 2],
+            self.pixels[i + 3],
+        ]
+    }
+
+    fn set_pixel(&mut self, x: u32, y: u32, colour: [u8; 4]) {
+        let i = ((y * self.width + x) * 4) as usize;
+        self.pixels[i..i + 4].copy_from_slice(&colour);
+    }
+}
+
+/// Renders the tray icon for `state`
+pub fn render(state: IconState) -> anyhow::Result<Rgba> {
+    let (colour, count) = match state {
+        IconState::LoggedOut => return decode_png(ICON_GREY_BYTES),
+        IconState::Idle => return decode_png(ICON_BYTES),
+        IconState::Live { count } => (LIVE_BADGE, count),
+        IconState::FavouriteLive { count } => (FAVOURITE_BADGE, count),
+    };
+    let mut icon = decode_png(ICON_BYTES)?;
+    draw_badge(&mut icon, colour, count);
+    Ok(icon)
+}
+
+/// Decodes an embedded RGBA PNG
+fn decode_png(bytes: &[u8]) -> anyhow::Result<Rgba> {
+    let decoder = png::Decoder::new(bytes);
+    let mut reader = decoder.read_info()?;
+    let mut pixels = vec![0; reader.output_buffer_size()];
+    let info = reader.next_frame(&mut pixels)?;
+    anyhow::ensure!(
+        info.color_type == png::ColorType::Rgba && info.bit_depth == png::BitDepth::Eight,
+        "icon is not 8-bit RGBA"
+    );
+    pixels.truncate(info.buffer_size());
+
+    Ok(Rgba {
+        width: info.width,
+        height: info.height,
+        pixels,
+    })
+}
+
+/// Draws a filled circle in the bottom-right corner with `count` on it.
+/// Counts above 9 don't fit, so only the circle is drawn for those, as it is
+/// on icons too small for a digit.
+fn draw_badge(icon: &mut Rgba, colour: [u8; 4], count: usize) {
+    let diameter = icon.width.min(icon.height) * 9 / 16;
+    let left = icon.width - diameter;
+    let top = icon.height - diameter;
+    let radius = f64::from(diameter) / 2.0;
+    let (cx, cy) = (f64::from(left) + radius, f64::from(top) + radius);
+
+    for y in top..icon.height {
+        for x in left..icon.width {
+            let dx = f64::from(x) + 0.5 - cx;
+            let dy = f64::from(y) + 0.5 - cy;
+            if dx * dx + dy * dy <= radius * radius {
+                icon.set_pixel(x, y, colour);
+            }
+        }
+    }
+
+    let Some(glyph) = count.checked_sub(1).and_then(|i| DIGITS.get(i)) else {
+        return;
+    };
+    let scale = diameter / 8;
+    if scale == 0 {
+        return;
+    }
+    let glyph_left = left + (diameter - 3 * scale) / 2;
+    let glyph_top = top + (diameter - 5 * scale) / 2;
+    for (row, bits) in (0..).zip(glyph) {
+        for col in 0..3 {
+            if (bits >> (2 - col)) & 1 == 0 {
+                continue;
+            }
+            for y in 0..scale {
+                for x in 0..scale {
+                    icon.set_pixel(
+                        glyph_left + col * scale + x,
+                        glyph_top + row * scale + y,
+                        DIGIT_COLOUR,
+                    );
+                }
+            }
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const CLEAR: [u8; 4] = [0, 0, 0, 0];
+
+    fn blank(size: u32) -> Rgba {
+        Rgba {
+            width: size,
+            height: size,
+            pixels: vec![0; (size * size * 4) as usize],
+        }
+    }
+
+    fn count_colour(icon: &Rgba, colour: [u8; 4]) -> usize {
+        icon.pixels.chunks(4).filter(|p| *p == colour).count()
+    }
+
+    #[test]
+    fn embedded_icons_decode() {
+        let icon = render(IconState::Idle).unwrap();
+        assert_eq!((icon.width, icon.height), (64, 64));
+        assert_eq!(icon.pixels.len(), 64 * 64 * 4);
+        assert_ne!(render(IconState::LoggedOut).unwrap(), icon);
+    }
+
+    #[test]
+    fn live_icon_is_base_icon_with_badge() {
+        let base = render(IconState::Idle).unwrap();
+        let live = render(IconState::Live { count: 3 }).unwrap();
+
+        assert_eq!(live.pixel(0, 0), base.pixel(0, 0), "top left untouched");
+        assert_eq!(live.pixel(32, 46), LIVE_BADGE);
+        assert!(count_colour(&live, DIGIT_COLOUR) > 0);
+    }
+
+    #[test]
+    fn favourite_badge_has_its_own_colour() {
+        let icon = render(IconState::FavouriteLive { count: 12 }).unwrap();
+
+        let centre = 64 - 18;
+        assert_eq!(icon.pixel(centre, centre), FAVOURITE_BADGE);
+    }
+
+    #[test]
+    fn badge_stays_in_bottom_right_corner() {
+        let mut icon = blank(64);
+        draw_badge(&mut icon, LIVE_BADGE, 0);
+
+        assert_eq!(icon.pixel(27, 63), CLEAR);
+        assert_eq!(icon.pixel(63, 27), CLEAR);
+        assert_eq!(icon.pixel(45, 45), LIVE_BADGE);
+        assert_eq!(
+            icon.pixel(63, 63),
+            CLEAR,
+            "corners of the circle stay clear"
+        );
+    }
+
+    #[test]
+    fn count_is_drawn_for_one_to_nine() {
+        let mut one = blank(64);
+        draw_badge(&mut one, LIVE_BADGE, 1);
+        let mut eight = blank(64);
+        draw_badge(&mut eight, LIVE_BADGE, 8);
+        let mut ten = blank(64);
+        draw_badge(&mut ten, LIVE_BADGE, 10);
+
+        // At 64px each glyph pixel is a 4×4 block
+        assert_eq!(count_colour(&one, DIGIT_COLOUR), 8 * 16);
+        assert_eq!(count_colour(&eight, DIGIT_COLOUR), 13 * 16);
+        assert_eq!(count_colour(&ten, DIGIT_COLOUR), 0);
+    }
+
+    #[test]
+    fn tiny_icon_gets_badge_without_count() {
+        let mut icon = blank(8);
+        draw_badge(&mut icon, LIVE_BADGE, 9);
+
+        assert!(count_colour(&icon, LIVE_BADGE) > 0);
+        assert_eq!(count_colour(&icon, DIGIT_COLOUR), 0);
+    }
+}
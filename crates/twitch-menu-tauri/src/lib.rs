@@ -3,6 +3,7 @@
 
 pub mod display;
 pub mod display_state;
+pub mod icon;
 pub mod tray;
 
 #[cfg(test)]
@@ -11,47 +12,104 @@ mod test_helpers;
 use chrono::Utc;
 use std::sync::Arc;
 use tokio::sync::watch;
+use twitch_backend::config::MenuToggle;
 use twitch_backend::handle::RawDisplayData;
 
 use crate::display::DisplayBackend;
-use crate::display_state::{compute_display_state, DisplayConfig, DisplayState};
+use crate::display_state::{
+    compute_display_state, compute_recent_notifications, DisplayConfig, DisplayState,
+};
 use crate::tray::TrayBackend;
 
+/// How often the menu is recomputed without new data, so relative times
+/// such as scheduled countdowns stay fresh.
+const REFRESH_INTERVAL: std::time::Duration = std::time::Duration::from_secs(60);
+
 /// Starts the display listener task.
 ///
 /// Subscribes to `display_rx` (a watch channel of `RawDisplayData`), converts
 /// each snapshot into a `DisplayState`, and calls `tray_backend.update()`.
+/// The latest snapshot is also re-rendered every `REFRESH_INTERVAL`; unchanged
+/// structure means the tray only relabels the affected items.
 /// Returns a `JoinHandle` so the caller can manage the task lifetime.
 pub fn start_listener(
     mut display_rx: watch::Receiver<RawDisplayData>,
     tray_backend: Arc<TrayBackend>,
 ) -> tokio::task::JoinHandle<()> {
     tokio::spawn(async move {
-        while display_rx.changed().await.is_ok() {
+        let mut refresh = tokio::time::interval(REFRESH_INTERVAL);
+        refresh.set_missed_tick_behavior(tokio::time::MissedTickBehavior::Delay);
+        // Nothing to refresh until the backend has pushed its first snapshot.
+        let mut received = false;
+
+        loop {
+            tokio::select! {
+                changed = display_rx.changed() => {
+                    if changed.is_err() {
+                        break;
+                    }
+                    received = true;
+                    refresh.reset();
+                }
+                _ = refresh.tick() => {
+                    if !received {
+                        continue;
+                    }
+                }
+            }
+
             let raw = display_rx.borrow().clone();
-            let display_config = DisplayConfig {
-                streamer_settings: raw.config.streamer_settings.clone(),
-                schedule_lookahead_hours: raw.config.schedule_lookahead_hours,
-                live_limit: raw.config.live_menu_limit,
-                schedule_limit: raw.config.schedule_menu_limit,
-                hot_stream_ids: raw.hot_stream_ids.clone(),
-            };
-            let state = if raw.is_authenticated {
-                compute_display_state(
-                    raw.live_streams,
-                    raw.scheduled_streams,
-                    raw.schedules_loaded,
-                    &raw.followed_categories,
-                    &raw.category_streams,
-                    &display_config,
-                    Utc::now(),
-                )
-            } else {
-                DisplayState::unauthenticated()
-            };
+            let state = display_state_for(raw);
             if let Err(e) = tray_backend.update(state) {
                 tracing::error!("Failed to update tray: {}", e);
             }
         }
     })
 }
+
+/// Converts a backend snapshot into the menu's `DisplayState` as of now.
+fn display_state_for(raw: RawDisplayData) -> DisplayState {
+    let display_config = DisplayConfig {
+        streamer_settings: raw.config.streamer_settings.clone(),
+        schedule_lookahead_hours: raw.config.schedule_lookahead_hours,
+        live_limit: raw.config.live_menu_limit,
+        schedule_limit: raw.config.schedule_menu_limit,
+        schedule_time_format: raw.config.schedule_time_format,
+        hot_stream_ids: raw.hot_stream_ids.clone(),
+        viewer_trends: raw.viewer_trends.clone(),
+        first_seen_at: raw.first_seen_at.clone(),
+        user_login: raw.user_login.clone(),
+        api_unreachable: raw.api_health.is_unreachable(),
+        api_last_success_at: raw.api_health.last_success_at,
+        group_by_game: raw.config.group_live_by_game,
+        has_external_player: raw
+            .config
+            .external_player
+            .as_deref()
+            .is_some_and(|t| !t.trim().is_empty()),
+        hide_reruns: raw.config.hide_reruns,
+        toggles: MenuToggle::ALL
+            .into_iter()
+            .map(|toggle| (toggle, toggle.is_on(&raw.config)))
+            .collect(),
+    };
+    let categories = [raw.followed_categories, raw.played_categories].concat();
+    let now = Utc::now();
+    if raw.is_authenticated {
+        let recent_notifications =
+            compute_recent_notifications(&raw.recent_notifications, &raw.live_streams, now);
+        let mut state = compute_display_state(
+            raw.live_streams,
+            raw.scheduled_streams,
+            raw.schedules_loaded,
+            &categories,
+            &raw.category_streams,
+            &display_config,
+            now,
+        );
+        state.recent_notifications = recent_notifications;
+        state
+    } else {
+        DisplayState::unauthenticated()
+    }
+}
@@ -0,0 +1,772 @@
+//! Menu layout
+//!
+//! Describes the tray menu as plain data so that each update can be compared
+//! with what is already shown. Most polls only change labels (viewer counts,
+//! uptimes), and those are updated in place; the menu is only rebuilt when
+//! items are added or removed. Rebuilding closes the menu if it is open and
+//! makes it flicker on some platforms.
+
+use crate::display_state::{DisplayState, ScheduledEntry, StreamEntry};
+
+use super::ids;
+
+/// One entry in the tray menu
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum MenuNode {
+    /// A clickable item when it has an id, otherwise a disabled label
+    Item {
+        id: Option<String>,
+        label: String,
+    },
+    /// A checkbox item
+    Check {
+        id: String,
+        label: String,
+        checked: bool,
+    },
+    Submenu {
+        label: String,
+        children: Vec<MenuNode>,
+    },
+    Separator,
+}
+
+impl MenuNode {
+    fn item(id: String, label: &str) -> Self {
+        Self::Item {
+            id: Some(id),
+            label: label.to_string(),
+        }
+    }
+
+    fn label(label: &str) -> Self {
+        Self::Item {
+            id: None,
+            label: label.to_string(),
+        }
+    }
+
+    fn submenu(label: &str, children: Vec<MenuNode>) -> Self {
+        Self::Submenu {
+            label: label.to_string(),
+            children,
+        }
+    }
+}
+
+/// How to bring the shown menu up to date with a new layout
+#[derive(Debug, PartialEq, Eq)]
+pub enum MenuChange {
+    Unchanged,
+    /// Same items, some with new labels: `(index, label)` pairs, counting
+    /// depth first with each submenu before its children
+    Relabel(Vec<(usize, String)>),
+    /// Items were added, removed or moved
+    Rebuild,
+}
+
+/// Compares the shown layout with a new one
+pub fn diff(old: &[MenuNode], new: &[MenuNode]) -> MenuChange {
+    let old = flatten(old);
+    let new = flatten(new);
+    if old.len() != new.len() || old.iter().zip(&new).any(|(o, n)| o.0 != n.0) {
+        return MenuChange::Rebuild;
+    }
+
+    let relabel: Vec<(usize, String)> = old
+        .iter()
+        .zip(new)
+        .enumerate()
+        .filter(|(_, (o, n))| o.1 != n.1)
+        .map(|(i, (_, n))| (i, n.1.to_string()))
+        .collect();
+    if relabel.is_empty() {
+        MenuChange::Unchanged
+    } else {
+        MenuChange::Relabel(relabel)
+    }
+}
+
+/// What identifies a node regardless of its label
+#[derive(Debug, PartialEq, Eq)]
+enum Shape<'a> {
+    Item(Option<&'a str>),
+    /// Checking a box rebuilds the menu, so the state always comes from config
+    Check(&'a str, bool),
+    Submenu(usize),
+    Separator,
+}
+
+/// Lists every node depth first, each submenu before its children, with its
+/// shape and label
+fn flatten(nodes: &[MenuNode]) -> Vec<(Shape<'_>, &str)> {
+    let mut flat = Vec::new();
+    for node in nodes {
+        match node {
+            MenuNode::Item { id, label } => flat.push((Shape::Item(id.as_deref()), label.as_str())),
+            MenuNode::Check { id, label, checked } => {
+                flat.push((Shape::Check(id, *checked), label.as_str()))
+            }
+            MenuNode::Submenu { label, children } => {
+                flat.push((Shape::Submenu(children.len()), label.as_str()));
+                flat.extend(flatten(children));
+            }
+            MenuNode::Separator => flat.push((Shape::Separator, "")),
+        }
+    }
+    flat
+}
+
+/// The menu shown before logging in
+pub fn unauthenticated_layout() -> Vec<MenuNode> {
+    vec![
+        MenuNode::item(ids::LOGIN.to_string(), "Login to Twitch"),
+        MenuNode::item(ids::QUIT.to_string(), "Quit"),
+    ]
+}
+
+/// Adds a streamer to favourites, or removes them if they are one
+fn favourite_node(user_login: &str, is_favourite: bool) -> MenuNode {
+    if is_favourite {
+        MenuNode::item(
+            format!("{}{}", ids::UNFAVOURITE_PREFIX, user_login),
+            "Remove from favourites",
+        )
+    } else {
+        MenuNode::item(
+            format!("{}{}", ids::FAVOURITE_PREFIX, user_login),
+            "Add to favourites",
+        )
+    }
+}
+
+/// A live stream that opens in the browser, or with an external player set,
+/// a submenu offering both
+fn stream_node(
+    state: &DisplayState,
+    browser_prefix: &str,
+    player_prefix: &str,
+    user_login: &str,
+    label: &str,
+) -> MenuNode {
+    let browser_id = format!("{browser_prefix}{user_login}");
+    if !state.has_external_player {
+        return MenuNode::item(browser_id, label);
+    }
+    MenuNode::submenu(
+        label,
+        vec![
+            MenuNode::item(browser_id, "Open in browser"),
+            MenuNode::item(format!("{player_prefix}{user_login}"), "Open in player"),
+        ],
+    )
+}
+
+/// A followed channel's chat and videos, muting and the favourites toggle
+fn channel_actions(user_login: &str, is_favourite: bool) -> Vec<MenuNode> {
+    vec![
+        MenuNode::item(format!("{}{}", ids::CHAT_PREFIX, user_login), "Open chat"),
+        MenuNode::item(
+            format!("{}{}", ids::VIDEOS_PREFIX, user_login),
+            "Open videos",
+        ),
+        MenuNode::Separator,
+        MenuNode::item(format!("{}{}", ids::MUTE_PREFIX, user_login), "Mute today"),
+        favourite_node(user_login, is_favourite),
+    ]
+}
+
+/// A "Channel actions" submenu with a submenu of actions per stream, kept
+/// apart from the streams so clicking one still opens it
+fn channel_actions_node<'a>(
+    entries: impl Iterator<Item = &'a StreamEntry>,
+    is_favourite: bool,
+) -> MenuNode {
+    MenuNode::submenu(
+        "Channel actions",
+        entries
+            .map(|entry| {
+                MenuNode::submenu(
+                    &entry.stream.user_name,
+                    channel_actions(&entry.stream.user_login, is_favourite),
+                )
+            })
+            .collect(),
+    )
+}
+
+/// Lays out the menu for `state`.
+///
+/// All business logic (sorting, filtering, labelling) lives in
+/// `display_state.rs`; this only arranges the entries.
+pub fn layout(state: &DisplayState) -> Vec<MenuNode> {
+    if !state.authenticated {
+        return unauthenticated_layout();
+    }
+
+    let mut nodes = Vec::new();
+
+    // === Account ===
+    if let Some(account) = &state.account {
+        nodes.push(MenuNode::label(&account.header));
+        nodes.push(MenuNode::label(&account.status));
+        nodes.push(MenuNode::Separator);
+    }
+
+    // === Favourites section ===
+    // Offline favourites are listed too, so they can be removed from here;
+    // with nothing to open, they are submenus holding the toggle
+    if !state.favourites.is_empty() {
+        nodes.push(MenuNode::label("Favourites"));
+        nodes.extend(state.favourites.live.iter().map(|entry| {
+            stream_node(
+                state,
+                ids::STREAM_PREFIX,
+                ids::PLAYER_PREFIX,
+                &entry.stream.user_login,
+                &entry.label,
+            )
+        }));
+        nodes.extend(state.favourites.offline.iter().map(|entry| {
+            MenuNode::submenu(&entry.label, vec![favourite_node(&entry.user_login, true)])
+        }));
+        if !state.favourites.live.is_empty() {
+            nodes.push(channel_actions_node(state.favourites.live.iter(), true));
+        }
+    }
+
+    // === Following Live section ===
+    let total_live = state.live_section.total();
+    if total_live == 0 {
+        nodes.push(MenuNode::label("Following Live"));
+        nodes.push(MenuNode::label(if state.favourites.live.is_empty() {
+            "  No streams live"
+        } else {
+            "  No other streams live"
+        }));
+    } else {
+        nodes.push(MenuNode::label(&format!("Following Live ({total_live})")));
+        let stream_item = |entry: &StreamEntry| {
+            stream_node(
+                state,
+                ids::STREAM_PREFIX,
+                ids::PLAYER_PREFIX,
+                &entry.stream.user_login,
+                &entry.label,
+            )
+        };
+        nodes.extend(state.live_section.visible.iter().map(stream_item));
+
+        // A game with one stream gets no submenu of its own
+        for group in &state.live_section.groups {
+            match group.entries.as_slice() {
+                [entry] => nodes.push(stream_item(entry)),
+                entries => nodes.push(MenuNode::submenu(
+                    &group.header,
+                    entries.iter().map(stream_item).collect(),
+                )),
+            }
+        }
+
+        if !state.live_section.overflow.is_empty() {
+            nodes.push(MenuNode::submenu(
+                &format!("More ({})...", state.live_section.overflow.len()),
+                state
+                    .live_section
+                    .overflow
+                    .iter()
+                    .map(stream_item)
+                    .collect(),
+            ));
+        }
+
+        let live_entries = state
+            .live_section
+            .visible
+            .iter()
+            .chain(state.live_section.groups.iter().flat_map(|g| &g.entries))
+            .chain(&state.live_section.overflow);
+        nodes.push(channel_actions_node(live_entries, false));
+    }
+
+    // === Category sections ===
+    if !state.category_sections.is_empty() {
+        nodes.push(MenuNode::label("Categories"));
+        for section in &state.category_sections {
+            let mut entries: Vec<MenuNode> = section
+                .entries
+                .iter()
+                .map(|entry| {
+                    stream_node(
+                        state,
+                        ids::CATEGORY_STREAM_PREFIX,
+                        ids::CATEGORY_PLAYER_PREFIX,
+                        &entry.stream.user_login,
+                        &entry.label,
+                    )
+                })
+                .collect();
+            entries.push(MenuNode::Separator);
+            entries.push(MenuNode::item(
+                format!("{}{}", ids::CATEGORY_PREFIX, section.name),
+                "Open category on Twitch",
+            ));
+            nodes.push(MenuNode::submenu(&section.header, entries));
+        }
+    }
+
+    // === Scheduled section ===
+    nodes.push(MenuNode::label(&state.schedule_section.header));
+    let total_sched = state.schedule_section.visible.len() + state.schedule_section.overflow.len();
+    if total_sched == 0 {
+        nodes.push(MenuNode::label(
+            if state.schedule_section.schedules_loaded {
+                "  No scheduled streams"
+            } else {
+                "  Loading..."
+            },
+        ));
+    } else {
+        let scheduled_item = |entry: &ScheduledEntry| {
+            MenuNode::submenu(
+                &entry.label,
+                vec![
+                    MenuNode::item(
+                        format!(
+                            "{}{}",
+                            ids::SCHEDULED_PREFIX,
+                            entry.scheduled.broadcaster_login
+                        ),
+                        "Open in browser",
+                    ),
+                    MenuNode::item(
+                        format!("{}{}", ids::CALENDAR_PREFIX, entry.scheduled.id),
+                        "Add to calendar",
+                    ),
+                ],
+            )
+        };
+        nodes.extend(state.schedule_section.visible.iter().map(scheduled_item));
+
+        if !state.schedule_section.overflow.is_empty() {
+            nodes.push(MenuNode::submenu(
+                &format!("More ({})...", state.schedule_section.overflow.len()),
+                state
+                    .schedule_section
+                    .overflow
+                    .iter()
+                    .map(scheduled_item)
+                    .collect(),
+            ));
+        }
+    }
+
+    // === Recent notifications ===
+    if !state.recent_notifications.is_empty() {
+        // Ids must be unique, so clickable entries are numbered as well as
+        // carrying the channel to open
+        let entries = state
+            .recent_notifications
+            .iter()
+            .enumerate()
+            .map(|(i, entry)| match &entry.open_login {
+                Some(login) => MenuNode::item(
+                    format!("{}{}_{}", ids::RECENT_NOTIFICATION_PREFIX, i, login),
+                    &entry.label,
+                ),
+                None => MenuNode::label(&entry.label),
+            })
+            .collect();
+        nodes.push(MenuNode::submenu("Recent notifications", entries));
+    }
+
+    // === Muted today ===
+    if !state.muted_today.is_empty() {
+        let entries = state
+            .muted_today
+            .iter()
+            .map(|entry| {
+                MenuNode::item(
+                    format!("{}{}", ids::UNMUTE_PREFIX, entry.user_login),
+                    &entry.label,
+                )
+            })
+            .collect();
+        nodes.push(MenuNode::submenu("Muted today", entries));
+    }
+
+    // === Settings, Logout and Quit ===
+    nodes.push(MenuNode::Separator);
+    let mut settings: Vec<MenuNode> = state
+        .toggles
+        .iter()
+        .map(|t| MenuNode::Check {
+            id: format!("{}{}", ids::TOGGLE_PREFIX, t.toggle.key()),
+            label: t.label.to_string(),
+            checked: t.checked,
+        })
+        .collect();
+    if settings.is_empty() {
+        nodes.push(MenuNode::item(ids::SETTINGS.to_string(), "Settings"));
+    } else {
+        settings.push(MenuNode::Separator);
+        settings.push(MenuNode::item(ids::SETTINGS.to_string(), "All settings..."));
+        nodes.push(MenuNode::submenu("Settings", settings));
+    }
+    nodes.push(MenuNode::item(
+        ids::TEST_NOTIFICATION.to_string(),
+        "Send Test Notification",
+    ));
+    nodes.push(MenuNode::item(ids::LOGOUT.to_string(), "Logout"));
+    nodes.push(MenuNode::item(ids::QUIT.to_string(), "Quit"));
+
+    nodes
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::display_state::{
+        AccountSection, CategorySection, CategoryStreamEntry, FavouritesSection, GameGroup,
+        LiveSection, OfflineFavourite, ScheduleSection, ToggleEntry,
+    };
+    use crate::test_helpers::{make_scheduled, make_stream};
+    use twitch_backend::config::MenuToggle;
+
+    fn live(entries: &[(&str, u32)]) -> DisplayState {
+        let mut state = DisplayState::unauthenticated();
+        state.authenticated = true;
+        state.live_section = LiveSection {
+            visible: entries
+                .iter()
+                .map(|(name, viewers)| StreamEntry {
+                    stream: make_stream(name, name),
+                    label: format!("{name} ({viewers})"),
+                    is_hot: false,
+                    is_new: false,
+                })
+                .collect(),
+            overflow: Vec::new(),
+            groups: Vec::new(),
+        };
+        state.schedule_section = ScheduleSection {
+            header: "Scheduled (next 6h)".to_string(),
+            visible: Vec::new(),
+            overflow: Vec::new(),
+            schedules_loaded: true,
+        };
+        state
+    }
+
+    /// A followed stream, which opens on click
+    fn followed_stream(login: &str, label: &str) -> MenuNode {
+        MenuNode::item(format!("stream_{login}"), label)
+    }
+
+    /// The "Channel actions" submenu for followed streams that aren't
+    /// favourites, given as (login, name) pairs
+    fn channel_actions_for(channels: &[(&str, &str)]) -> MenuNode {
+        MenuNode::submenu(
+            "Channel actions",
+            channels
+                .iter()
+                .map(|(login, name)| {
+                    MenuNode::submenu(
+                        name,
+                        vec![
+                            MenuNode::item(format!("chat_{login}"), "Open chat"),
+                            MenuNode::item(format!("videos_{login}"), "Open videos"),
+                            MenuNode::Separator,
+                            MenuNode::item(format!("mute_{login}"), "Mute today"),
+                            MenuNode::item(format!("favourite_{login}"), "Add to favourites"),
+                        ],
+                    )
+                })
+                .collect(),
+        )
+    }
+
+    #[test]
+    fn same_state_is_unchanged() {
+        let state = live(&[("A", 10), ("B", 20)]);
+
+        assert_eq!(
+            diff(&layout(&state), &layout(&state)),
+            MenuChange::Unchanged
+        );
+    }
+
+    #[test]
+    fn new_viewer_counts_only_relabel() {
+        let old = layout(&live(&[("A", 10), ("B", 20)]));
+        let new = layout(&live(&[("A", 10), ("B", 25)]));
+
+        assert_eq!(
+            diff(&old, &new),
+            MenuChange::Relabel(vec![(2, "B (25)".to_string())])
+        );
+    }
+
+    #[test]
+    fn stream_going_live_or_offline_rebuilds() {
+        let two = layout(&live(&[("A", 10), ("B", 20)]));
+        let three = layout(&live(&[("A", 10), ("B", 20), ("C", 30)]));
+        let swapped = layout(&live(&[("B", 20), ("A", 10)]));
+
+        assert_eq!(diff(&two, &three), MenuChange::Rebuild);
+        assert_eq!(diff(&three, &two), MenuChange::Rebuild);
+        assert_eq!(diff(&two, &swapped), MenuChange::Rebuild, "order changed");
+    }
+
+    #[test]
+    fn logging_in_rebuilds() {
+        let old = layout(&DisplayState::unauthenticated());
+        let new = layout(&live(&[]));
+
+        assert_eq!(old, unauthenticated_layout());
+        assert_eq!(diff(&old, &new), MenuChange::Rebuild);
+    }
+
+    #[test]
+    fn repeated_polls_with_same_streams_never_rebuild() {
+        let mut shown = layout(&live(&[("A", 0), ("B", 0)]));
+
+        for viewers in 1..=100 {
+            let next = layout(&live(&[("A", viewers), ("B", viewers * 2)]));
+            assert!(matches!(diff(&shown, &next), MenuChange::Relabel(_)));
+            shown = next;
+        }
+    }
+
+    #[test]
+    fn account_heads_the_menu_and_status_changes_relabel() {
+        let with_status = |status: &str| {
+            let mut state = live(&[("A", 10)]);
+            state.account = Some(AccountSection {
+                header: "Logged in as somebody".to_string(),
+                status: status.to_string(),
+            });
+            layout(&state)
+        };
+
+        let connected = with_status("Connected");
+        assert_eq!(connected[0], MenuNode::label("Logged in as somebody"));
+        assert_eq!(connected[1], MenuNode::label("Connected"));
+        assert_eq!(connected[2], MenuNode::Separator);
+        assert_eq!(connected[3], MenuNode::label("Following Live (1)"));
+
+        assert_eq!(
+            diff(&connected, &with_status("Twitch unreachable")),
+            MenuChange::Relabel(vec![(1, "Twitch unreachable".to_string())])
+        );
+    }
+
+    #[test]
+    fn game_groups_become_submenus_unless_single() {
+        let mut state = live(&[]);
+        let entry = |name: &str| StreamEntry {
+            stream: make_stream(name, name),
+            label: name.to_string(),
+            is_hot: false,
+            is_new: false,
+        };
+        state.live_section.groups = vec![
+            GameGroup {
+                header: "Factorio (2)".to_string(),
+                entries: vec![entry("A"), entry("B")],
+            },
+            GameGroup {
+                header: "Chess (1)".to_string(),
+                entries: vec![entry("C")],
+            },
+        ];
+
+        let nodes = layout(&state);
+
+        assert_eq!(nodes[0], MenuNode::label("Following Live (3)"));
+        assert_eq!(
+            nodes[1],
+            MenuNode::submenu(
+                "Factorio (2)",
+                vec![followed_stream("a", "A"), followed_stream("b", "B")]
+            )
+        );
+        assert_eq!(nodes[2], followed_stream("c", "C"));
+        assert_eq!(
+            nodes[3],
+            channel_actions_for(&[("a", "A"), ("b", "B"), ("c", "C")])
+        );
+    }
+
+    #[test]
+    fn external_player_adds_submenu_per_stream() {
+        let mut state = live(&[("A", 10)]);
+        state.has_external_player = true;
+
+        let nodes = layout(&state);
+
+        assert_eq!(
+            nodes[1],
+            MenuNode::submenu(
+                "A (10)",
+                vec![
+                    MenuNode::item("stream_a".to_string(), "Open in browser"),
+                    MenuNode::item("player_a".to_string(), "Open in player"),
+                ]
+            )
+        );
+        assert_eq!(nodes[2], channel_actions_for(&[("a", "A")]));
+    }
+
+    #[test]
+    fn followed_streams_offer_channel_actions_but_category_streams_dont() {
+        let mut state = live(&[("A", 10)]);
+        state.category_sections = vec![CategorySection {
+            name: "Chess".to_string(),
+            header: "Chess (1)".to_string(),
+            entries: vec![CategoryStreamEntry {
+                stream: make_stream("C", "C"),
+                label: "C (5)".to_string(),
+            }],
+        }];
+
+        let nodes = layout(&state);
+
+        assert_eq!(nodes[1], followed_stream("a", "A (10)"));
+        assert_eq!(nodes[2], channel_actions_for(&[("a", "A")]));
+        assert_eq!(nodes[3], MenuNode::label("Categories"));
+        assert_eq!(
+            nodes[4],
+            MenuNode::submenu(
+                "Chess (1)",
+                vec![
+                    MenuNode::item("cat_stream_c".to_string(), "C (5)"),
+                    MenuNode::Separator,
+                    MenuNode::item("category_Chess".to_string(), "Open category on Twitch"),
+                ]
+            )
+        );
+    }
+
+    #[test]
+    fn scheduled_streams_offer_add_to_calendar() {
+        let mut state = live(&[]);
+        state.schedule_section.visible = vec![ScheduledEntry {
+            scheduled: make_scheduled("D", 3),
+            label: "D - in 3h".to_string(),
+        }];
+
+        let nodes = layout(&state);
+
+        assert_eq!(
+            nodes[3],
+            MenuNode::submenu(
+                "D - in 3h",
+                vec![
+                    MenuNode::item("scheduled_d".to_string(), "Open in browser"),
+                    MenuNode::item("calendar_sched_D".to_string(), "Add to calendar"),
+                ]
+            )
+        );
+    }
+
+    #[test]
+    fn favourites_are_pinned_above_following_live() {
+        let mut state = live(&[]);
+        state.favourites = FavouritesSection {
+            live: vec![StreamEntry {
+                stream: make_stream("A", "A"),
+                label: "A (10)".to_string(),
+                is_hot: false,
+                is_new: false,
+            }],
+            offline: vec![OfflineFavourite {
+                user_login: "b".to_string(),
+                label: "B \u{2014} offline".to_string(),
+            }],
+        };
+
+        let nodes = layout(&state);
+
+        assert_eq!(nodes[0], MenuNode::label("Favourites"));
+        assert_eq!(nodes[1], followed_stream("a", "A (10)"));
+        assert_eq!(
+            nodes[2],
+            MenuNode::submenu(
+                "B \u{2014} offline",
+                vec![MenuNode::item(
+                    "unfavourite_b".to_string(),
+                    "Remove from favourites"
+                )]
+            )
+        );
+        assert_eq!(
+            nodes[3],
+            MenuNode::submenu(
+                "Channel actions",
+                vec![MenuNode::submenu(
+                    "A",
+                    vec![
+                        MenuNode::item("chat_a".to_string(), "Open chat"),
+                        MenuNode::item("videos_a".to_string(), "Open videos"),
+                        MenuNode::Separator,
+                        MenuNode::item("mute_a".to_string(), "Mute today"),
+                        MenuNode::item("unfavourite_a".to_string(), "Remove from favourites"),
+                    ]
+                )]
+            )
+        );
+        assert_eq!(nodes[4], MenuNode::label("Following Live"));
+        assert_eq!(nodes[5], MenuNode::label("  No other streams live"));
+    }
+
+    #[test]
+    fn toggles_go_in_settings_submenu_and_checking_rebuilds() {
+        let with_toggle = |checked| {
+            let mut state = live(&[]);
+            state.toggles = vec![ToggleEntry {
+                toggle: MenuToggle::HideReruns,
+                label: "Hide reruns",
+                checked,
+            }];
+            layout(&state)
+        };
+
+        let nodes = with_toggle(false);
+        let settings = nodes
+            .iter()
+            .find(|n| matches!(n, MenuNode::Submenu { label, .. } if label == "Settings"))
+            .unwrap();
+        let MenuNode::Submenu { children, .. } = settings else {
+            unreachable!()
+        };
+        assert_eq!(
+            children[0],
+            MenuNode::Check {
+                id: "toggle_hide_reruns".to_string(),
+                label: "Hide reruns".to_string(),
+                checked: false,
+            }
+        );
+        assert_eq!(
+            children.last(),
+            Some(&MenuNode::item(
+                ids::SETTINGS.to_string(),
+                "All settings..."
+            ))
+        );
+        assert_eq!(diff(&nodes, &with_toggle(true)), MenuChange::Rebuild);
+    }
+
+    #[test]
+    fn submenu_children_are_indexed_after_their_parent() {
+        let nodes = vec![
+            MenuNode::label("Header"),
+            MenuNode::submenu("More (1)...", vec![MenuNode::label("child")]),
+            MenuNode::Separator,
+        ];
+
+        let labels: Vec<&str> = flatten(&nodes).into_iter().map(|(_, l)| l).collect();
+        assert_eq!(labels, vec!["Header", "More (1)...", "child", ""]);
+    }
+}
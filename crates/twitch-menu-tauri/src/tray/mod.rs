@@ -2,22 +2,24 @@ use std::sync::{Arc, Mutex};
 
 use tauri::{
     image::Image,
-    menu::{Menu, MenuBuilder, MenuItemBuilder, SubmenuBuilder},
+    menu::{
+        CheckMenuItem, CheckMenuItemBuilder, IsMenuItem, Menu, MenuBuilder, MenuItem,
+        MenuItemBuilder, PredefinedMenuItem, Submenu, SubmenuBuilder,
+    },
     tray::{TrayIcon, TrayIconBuilder},
     AppHandle, Emitter,
 };
 
+use twitch_backend::notify::{open_in_browser, open_stream};
+use twitch_backend::twitch::{category_url, chat_url, videos_url};
+
 use crate::display::DisplayBackend;
-use crate::display_state::DisplayState;
+use crate::display_state::{DisplayState, IconState, TOOLTIP_TITLE};
+use crate::icon;
+
+mod layout;
 
-const ICON_BYTES: &[u8] = include_bytes!(concat!(
-    env!("CARGO_MANIFEST_DIR"),
-    "/../twitch-app-tauri/icons/icon.png"
-));
-const ICON_GREY_BYTES: &[u8] = include_bytes!(concat!(
-    env!("CARGO_MANIFEST_DIR"),
-    "/../twitch-app-tauri/icons/icon_grey.png"
-));
+use layout::{MenuChange, MenuNode};
 
 /// Menu item IDs
 mod ids {
@@ -25,24 +27,54 @@ mod ids {
     pub const LOGOUT: &str = "logout";
     pub const QUIT: &str = "quit";
     pub const SETTINGS: &str = "settings";
+    pub const TEST_NOTIFICATION: &str = "test_notification";
     pub const STREAM_PREFIX: &str = "stream_";
     pub const SCHEDULED_PREFIX: &str = "scheduled_";
     pub const CATEGORY_STREAM_PREFIX: &str = "cat_stream_";
+    pub const RECENT_NOTIFICATION_PREFIX: &str = "recent_";
+    pub const UNMUTE_PREFIX: &str = "unmute_";
+    pub const PLAYER_PREFIX: &str = "player_";
+    pub const CATEGORY_PLAYER_PREFIX: &str = "cat_player_";
+    pub const TOGGLE_PREFIX: &str = "toggle_";
+    pub const FAVOURITE_PREFIX: &str = "favourite_";
+    pub const UNFAVOURITE_PREFIX: &str = "unfavourite_";
+    pub const CHAT_PREFIX: &str = "chat_";
+    pub const VIDEOS_PREFIX: &str = "videos_";
+    pub const MUTE_PREFIX: &str = "mute_";
+    pub const CATEGORY_PREFIX: &str = "category_";
+    pub const CALENDAR_PREFIX: &str = "calendar_";
 }
 
-/// Loads an image from embedded PNG bytes
-fn load_icon(bytes: &[u8]) -> tauri::Result<Image<'static>> {
-    let decoder = png::Decoder::new(bytes);
-    let mut reader = decoder
-        .read_info()
-        .map_err(|e| tauri::Error::Anyhow(e.into()))?;
-    let mut buf = vec![0; reader.output_buffer_size()];
-    let info = reader
-        .next_frame(&mut buf)
-        .map_err(|e| tauri::Error::Anyhow(e.into()))?;
-    buf.truncate(info.buffer_size());
+/// A menu entry that can be relabelled in place
+enum MenuHandle {
+    Item(MenuItem<tauri::Wry>),
+    Check(CheckMenuItem<tauri::Wry>),
+    Submenu(Submenu<tauri::Wry>),
+    Separator,
+}
+
+impl MenuHandle {
+    fn set_text(&self, text: &str) -> tauri::Result<()> {
+        match self {
+            Self::Item(item) => item.set_text(text),
+            Self::Check(item) => item.set_text(text),
+            Self::Submenu(submenu) => submenu.set_text(text),
+            Self::Separator => Ok(()),
+        }
+    }
+}
+
+/// The menu currently set on the tray, with a handle for every entry in the
+/// same depth-first order as the layout's relabel indices
+struct ShownMenu {
+    layout: Vec<MenuNode>,
+    handles: Vec<MenuHandle>,
+}
 
-    Ok(Image::new_owned(buf, info.width, info.height))
+/// Renders the tray icon for `state`
+fn tray_icon(state: IconState) -> tauri::Result<Image<'static>> {
+    let icon = icon::render(state).map_err(tauri::Error::Anyhow)?;
+    Ok(Image::new_owned(icon.pixels, icon.width, icon.height))
 }
 
 /// System tray adapter that implements [`DisplayBackend`].
@@ -56,6 +88,9 @@ pub struct TrayBackend {
     /// Serialises menu rebuilds to prevent concurrent GTK operations which
     /// can crash libayatana-appindicator on Linux.
     rebuild_lock: Arc<Mutex<()>>,
+    /// The menu on the tray, so updates that only change labels can be
+    /// applied in place instead of rebuilding it
+    shown: Arc<Mutex<Option<ShownMenu>>>,
 }
 
 impl TrayBackend {
@@ -64,16 +99,17 @@ impl TrayBackend {
         Self {
             app_handle,
             rebuild_lock: Arc::new(Mutex::new(())),
+            shown: Arc::new(Mutex::new(None)),
         }
     }
 
     /// Creates the initial tray icon.
     pub fn create_tray(&self) -> tauri::Result<TrayIcon> {
-        let icon = load_icon(ICON_GREY_BYTES)?;
+        let icon = tray_icon(IconState::LoggedOut)?;
 
         let tray = TrayIconBuilder::with_id("main")
             .icon(icon)
-            .tooltip("Twitch Tray")
+            .tooltip(TOOLTIP_TITLE)
             .show_menu_on_left_click(true)
             .build(&self.app_handle)?;
 
@@ -95,7 +131,7 @@ impl DisplayBackend for TrayBackend {
             .unwrap_or_else(std::sync::PoisonError::into_inner);
 
         let app_handle = self.app_handle.clone();
-        let authenticated = state.authenticated;
+        let shown = Arc::clone(&self.shown);
 
         // Build and set menu on the main thread to avoid GTK threading issues.
         // Clone the handle so the closure can own it while we call the method on the original.
@@ -103,173 +139,81 @@ impl DisplayBackend for TrayBackend {
         app_handle
             .run_on_main_thread(move || {
                 let app_handle = app_handle_closure;
-                let menu_result = if authenticated {
-                    render_display_state(&app_handle, &state)
-                } else {
-                    build_unauthenticated_menu(&app_handle)
+                let Some(tray) = app_handle.tray_by_id("main") else {
+                    return;
                 };
 
-                let menu = match menu_result {
-                    Ok(m) => m,
-                    Err(e) => {
-                        tracing::error!("Failed to build menu: {}", e);
-                        return;
-                    }
-                };
-
-                if let Some(tray) = app_handle.tray_by_id("main") {
-                    if let Err(e) = tray.set_menu(Some(menu)) {
-                        tracing::error!("Failed to set tray menu: {}", e);
-                        return;
-                    }
+                let mut shown = shown
+                    .lock()
+                    .unwrap_or_else(std::sync::PoisonError::into_inner);
+                if let Err(e) = apply_layout(&app_handle, &tray, &mut shown, layout::layout(&state))
+                {
+                    tracing::error!("Failed to update tray menu: {}", e);
+                    return;
+                }
 
-                    let icon_result = if authenticated {
-                        load_icon(ICON_BYTES)
-                    } else {
-                        load_icon(ICON_GREY_BYTES)
-                    };
+                if let Err(e) = tray.set_tooltip(Some(&state.tooltip)) {
+                    tracing::error!("Failed to set tray tooltip: {}", e);
+                }
 
-                    match icon_result {
-                        Ok(icon) => {
-                            if let Err(e) = tray.set_icon(Some(icon)) {
-                                tracing::error!("Failed to set tray icon: {}", e);
-                            }
-                        }
-                        Err(e) => {
-                            tracing::error!("Failed to load icon: {}", e);
+                match tray_icon(state.icon) {
+                    Ok(icon) => {
+                        if let Err(e) = tray.set_icon(Some(icon)) {
+                            tracing::error!("Failed to set tray icon: {}", e);
                         }
                     }
+                    Err(e) => {
+                        tracing::error!("Failed to load icon: {}", e);
+                    }
                 }
             })
             .map_err(anyhow::Error::from)
     }
 }
 
-fn build_unauthenticated_menu(app: &AppHandle) -> tauri::Result<Menu<tauri::Wry>> {
-    let login = MenuItemBuilder::with_id(ids::LOGIN, "Login to Twitch").build(app)?;
-    let quit = MenuItemBuilder::with_id(ids::QUIT, "Quit").build(app)?;
-
-    MenuBuilder::new(app).items(&[&login, &quit]).build()
-}
-
-/// Maps a `DisplayState` into Tauri menu items.
-///
-/// This is the only function in `tray/mod.rs` that knows about Tauri types.
-/// All business logic (sorting, filtering, labelling) lives in `display_state.rs`.
-fn render_display_state(app: &AppHandle, state: &DisplayState) -> tauri::Result<Menu<tauri::Wry>> {
-    let mut items: Vec<Box<dyn tauri::menu::IsMenuItem<tauri::Wry>>> = Vec::new();
-
-    // === Following Live section ===
-    let total_live = state.live_section.visible.len() + state.live_section.overflow.len();
-    let live_title = if total_live == 0 {
-        "Following Live".to_string()
-    } else {
-        format!("Following Live ({total_live})")
-    };
-    items.push(Box::new(
-        MenuItemBuilder::new(live_title).enabled(false).build(app)?,
-    ));
-
-    if total_live == 0 {
-        items.push(Box::new(
-            MenuItemBuilder::new("  No streams live")
-                .enabled(false)
-                .build(app)?,
-        ));
-    } else {
-        for entry in &state.live_section.visible {
-            let id = format!("{}{}", ids::STREAM_PREFIX, entry.stream.user_login);
-            items.push(Box::new(
-                MenuItemBuilder::with_id(id, &entry.label).build(app)?,
-            ));
-        }
-
-        if !state.live_section.overflow.is_empty() {
-            let more_label = format!("More ({})...", state.live_section.overflow.len());
-            let mut more_submenu = SubmenuBuilder::new(app, more_label);
-
-            for entry in &state.live_section.overflow {
-                let id = format!("{}{}", ids::STREAM_PREFIX, entry.stream.user_login);
-                let item = MenuItemBuilder::with_id(id, &entry.label).build(app)?;
-                more_submenu = more_submenu.item(&item);
-            }
-
-            items.push(Box::new(more_submenu.build()?));
-        }
-    }
-
-    // === Category sections ===
-    if !state.category_sections.is_empty() {
-        items.push(Box::new(
-            MenuItemBuilder::new("Categories")
-                .enabled(false)
-                .build(app)?,
-        ));
-
-        for cat_section in &state.category_sections {
-            let mut cat_submenu = SubmenuBuilder::new(app, &cat_section.header);
-
-            for entry in &cat_section.entries {
-                let id = format!("{}{}", ids::CATEGORY_STREAM_PREFIX, entry.stream.user_login);
-                let item = MenuItemBuilder::with_id(id, &entry.label).build(app)?;
-                cat_submenu = cat_submenu.item(&item);
+/// Brings the tray menu up to date with `layout`, relabelling the shown
+/// entries when only labels changed and rebuilding the menu otherwise.
+/// Rebuilding closes the menu if it is open, so it is avoided where possible.
+fn apply_layout(
+    app: &AppHandle,
+    tray: &TrayIcon,
+    shown: &mut Option<ShownMenu>,
+    layout: Vec<MenuNode>,
+) -> tauri::Result<()> {
+    if let Some(current) = shown.as_mut() {
+        match layout::diff(&current.layout, &layout) {
+            MenuChange::Unchanged => return Ok(()),
+            MenuChange::Relabel(labels) => {
+                for (index, label) in labels {
+                    current.handles[index].set_text(&label)?;
+                }
+                current.layout = layout;
+                return Ok(());
             }
-
-            items.push(Box::new(cat_submenu.build()?));
+            MenuChange::Rebuild => {}
         }
     }
 
-    // === Scheduled section ===
-    items.push(Box::new(
-        MenuItemBuilder::new(&state.schedule_section.header)
-            .enabled(false)
-            .build(app)?,
-    ));
-
-    let total_sched = state.schedule_section.visible.len() + state.schedule_section.overflow.len();
-    if total_sched == 0 {
-        let label = if state.schedule_section.schedules_loaded {
-            "  No scheduled streams"
-        } else {
-            "  Loading..."
-        };
-        items.push(Box::new(
-            MenuItemBuilder::new(label).enabled(false).build(app)?,
-        ));
-    } else {
-        for entry in &state.schedule_section.visible {
-            let id = format!(
-                "{}{}",
-                ids::SCHEDULED_PREFIX,
-                entry.scheduled.broadcaster_login
-            );
-            items.push(Box::new(
-                MenuItemBuilder::with_id(id, &entry.label).build(app)?,
-            ));
-        }
-
-        if !state.schedule_section.overflow.is_empty() {
-            let more_label = format!("More ({})...", state.schedule_section.overflow.len());
-            let mut more_submenu = SubmenuBuilder::new(app, more_label);
-
-            for entry in &state.schedule_section.overflow {
-                let id = format!(
-                    "{}{}",
-                    ids::SCHEDULED_PREFIX,
-                    entry.scheduled.broadcaster_login
-                );
-                let item = MenuItemBuilder::with_id(id, &entry.label).build(app)?;
-                more_submenu = more_submenu.item(&item);
-            }
-
-            items.push(Box::new(more_submenu.build()?));
-        }
-    }
+    let mut handles = Vec::new();
+    let menu = build_menu(app, &layout, &mut handles)?;
+    tray.set_menu(Some(menu))?;
+    *shown = Some(ShownMenu { layout, handles });
+    Ok(())
+}
 
-    // === Settings, Logout and Quit ===
-    let settings = MenuItemBuilder::with_id(ids::SETTINGS, "Settings").build(app)?;
-    let logout = MenuItemBuilder::with_id(ids::LOGOUT, "Logout").build(app)?;
-    let quit = MenuItemBuilder::with_id(ids::QUIT, "Quit").build(app)?;
+/// Maps a menu layout into Tauri menu items, collecting a handle for each.
+///
+/// All business logic (sorting, filtering, labelling) lives in
+/// `display_state.rs` and the arrangement in `layout.rs`.
+fn build_menu(
+    app: &AppHandle,
+    layout: &[MenuNode],
+    handles: &mut Vec<MenuHandle>,
+) -> tauri::Result<Menu<tauri::Wry>> {
+    let items = layout
+        .iter()
+        .map(|node| build_node(app, node, handles))
+        .collect::<tauri::Result<Vec<_>>>()?;
 
     MenuBuilder::new(app)
         .items(
@@ -278,11 +222,45 @@ fn render_display_state(app: &AppHandle, state: &DisplayState) -> tauri::Result<
                 .map(std::convert::AsRef::as_ref)
                 .collect::<Vec<_>>(),
         )
-        .separator()
-        .items(&[&settings, &logout, &quit])
         .build()
 }
 
+fn build_node(
+    app: &AppHandle,
+    node: &MenuNode,
+    handles: &mut Vec<MenuHandle>,
+) -> tauri::Result<Box<dyn IsMenuItem<tauri::Wry>>> {
+    match node {
+        MenuNode::Item { id, label } => {
+            let item = match id {
+                Some(id) => MenuItemBuilder::with_id(id.as_str(), label).build(app)?,
+                None => MenuItemBuilder::new(label).enabled(false).build(app)?,
+            };
+            handles.push(MenuHandle::Item(item.clone()));
+            Ok(Box::new(item))
+        }
+        MenuNode::Check { id, label, checked } => {
+            let item = CheckMenuItemBuilder::with_id(id.as_str(), label)
+                .checked(*checked)
+                .build(app)?;
+            handles.push(MenuHandle::Check(item.clone()));
+            Ok(Box::new(item))
+        }
+        MenuNode::Submenu { label, children } => {
+            let submenu = SubmenuBuilder::new(app, label).build()?;
+            handles.push(MenuHandle::Submenu(submenu.clone()));
+            for child in children {
+                submenu.append(build_node(app, child, handles)?.as_ref())?;
+            }
+            Ok(Box::new(submenu))
+        }
+        MenuNode::Separator => {
+            handles.push(MenuHandle::Separator);
+            Ok(Box::new(PredefinedMenuItem::separator(app)?))
+        }
+    }
+}
+
 /// Handles menu item clicks
 pub fn handle_menu_event(app: &AppHandle, id: &str) {
     match id {
@@ -295,6 +273,9 @@ pub fn handle_menu_event(app: &AppHandle, id: &str) {
         ids::SETTINGS => {
             twitch_settings_tauri::window::open_settings_window(app);
         }
+        ids::TEST_NOTIFICATION => {
+            app.emit("test-notification-requested", ()).ok();
+        }
         ids::QUIT => {
             app.exit(0);
         }
@@ -310,14 +291,56 @@ pub fn handle_menu_event(app: &AppHandle, id: &str) {
             let user_login = &id[ids::CATEGORY_STREAM_PREFIX.len()..];
             open_stream(user_login);
         }
+        _ if id.starts_with(ids::PLAYER_PREFIX) => {
+            let user_login = &id[ids::PLAYER_PREFIX.len()..];
+            app.emit("open-in-player-requested", user_login).ok();
+        }
+        _ if id.starts_with(ids::CATEGORY_PLAYER_PREFIX) => {
+            let user_login = &id[ids::CATEGORY_PLAYER_PREFIX.len()..];
+            app.emit("open-in-player-requested", user_login).ok();
+        }
+        _ if id.starts_with(ids::TOGGLE_PREFIX) => {
+            let key = &id[ids::TOGGLE_PREFIX.len()..];
+            app.emit("setting-toggled", key).ok();
+        }
+        _ if id.starts_with(ids::FAVOURITE_PREFIX) => {
+            let user_login = &id[ids::FAVOURITE_PREFIX.len()..];
+            app.emit("favourite-requested", (user_login, true)).ok();
+        }
+        _ if id.starts_with(ids::UNFAVOURITE_PREFIX) => {
+            let user_login = &id[ids::UNFAVOURITE_PREFIX.len()..];
+            app.emit("favourite-requested", (user_login, false)).ok();
+        }
+        _ if id.starts_with(ids::CATEGORY_PREFIX) => {
+            let name = &id[ids::CATEGORY_PREFIX.len()..];
+            open_in_browser(&category_url(name));
+        }
+        _ if id.starts_with(ids::CALENDAR_PREFIX) => {
+            let scheduled_id = &id[ids::CALENDAR_PREFIX.len()..];
+            app.emit("add-to-calendar-requested", scheduled_id).ok();
+        }
+        _ if id.starts_with(ids::CHAT_PREFIX) => {
+            let user_login = &id[ids::CHAT_PREFIX.len()..];
+            open_in_browser(&chat_url(user_login));
+        }
+        _ if id.starts_with(ids::VIDEOS_PREFIX) => {
+            let user_login = &id[ids::VIDEOS_PREFIX.len()..];
+            open_in_browser(&videos_url(user_login));
+        }
+        _ if id.starts_with(ids::MUTE_PREFIX) => {
+            let user_login = &id[ids::MUTE_PREFIX.len()..];
+            app.emit("mute-requested", user_login).ok();
+        }
+        _ if id.starts_with(ids::UNMUTE_PREFIX) => {
+            let user_login = &id[ids::UNMUTE_PREFIX.len()..];
+            app.emit("unmute-requested", user_login).ok();
+        }
+        _ if id.starts_with(ids::RECENT_NOTIFICATION_PREFIX) => {
+            let rest = &id[ids::RECENT_NOTIFICATION_PREFIX.len()..];
+            if let Some((_, user_login)) = rest.split_once('_') {
+                open_stream(user_login);
+            }
+        }
         _ => {}
     }
 }
-
-/// Opens a Twitch stream in the default browser
-fn open_stream(user_login: &str) {
-    let url = format!("https://twitch.tv/{user_login}");
-    if let Err(e) = open::that(&url) {
-        tracing::error!("Failed to open browser: {}", e);
-    }
-}
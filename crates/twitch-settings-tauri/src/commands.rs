@@ -74,6 +74,15 @@ pub async fn get_debug_schedule_data(
     Ok(app.get_debug_schedule_data(start, end).await)
 }
 
+/// Writes a debug snapshot of the backend state to disk and returns its path.
+#[tauri::command]
+pub async fn save_state_snapshot(app: State<'_, Arc<dyn AppServices>>) -> Result<String, String> {
+    app.save_state_snapshot()
+        .await
+        .map(|path| path.display().to_string())
+        .map_err(|e| e.to_string())
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -250,4 +259,16 @@ mod tests {
         services.get_debug_hotness_data().await;
         assert_eq!(services.hotness_call_count(), 2);
     }
+
+    // =========================================================
+    // save_state_snapshot
+    // =========================================================
+
+    #[tokio::test]
+    async fn save_state_snapshot_returns_written_path() {
+        let services = MockAppServices::new();
+        let path = services.save_state_snapshot().await.unwrap();
+        assert_eq!(path.file_name().unwrap(), "state-snapshot.json");
+        assert_eq!(services.snapshot_call_count(), 1);
+    }
 }
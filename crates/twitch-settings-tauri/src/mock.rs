@@ -1,10 +1,11 @@
 /// MockAppServices for commands unit tests.
 /// Lives here because #[cfg(test)] code cannot cross crate boundaries.
 use async_trait::async_trait;
+use std::path::PathBuf;
 use std::sync::atomic::{AtomicUsize, Ordering};
 use std::sync::Mutex;
 use twitch_backend::app_services::{AppServices, DebugHotnessEntry, DebugStreamEntry};
-use twitch_backend::config::{Config, FollowedCategory};
+use twitch_backend::config::{Config, FollowedCategory, MenuToggle};
 use twitch_backend::twitch::{ApiError, Category, FollowedChannel};
 
 pub struct MockAppServices {
@@ -18,6 +19,7 @@ pub struct MockAppServices {
     refresh_schedules_count: AtomicUsize,
     debug_call_count: AtomicUsize,
     hotness_call_count: AtomicUsize,
+    snapshot_call_count: AtomicUsize,
 }
 
 impl MockAppServices {
@@ -33,6 +35,7 @@ impl MockAppServices {
             refresh_schedules_count: AtomicUsize::new(0),
             debug_call_count: AtomicUsize::new(0),
             hotness_call_count: AtomicUsize::new(0),
+            snapshot_call_count: AtomicUsize::new(0),
         }
     }
 
@@ -71,6 +74,10 @@ impl MockAppServices {
     pub fn hotness_call_count(&self) -> usize {
         self.hotness_call_count.load(Ordering::SeqCst)
     }
+
+    pub fn snapshot_call_count(&self) -> usize {
+        self.snapshot_call_count.load(Ordering::SeqCst)
+    }
 }
 
 #[async_trait]
@@ -116,4 +123,56 @@ impl AppServices for MockAppServices {
         self.hotness_call_count.fetch_add(1, Ordering::SeqCst);
         self.hotness_entries.lock().unwrap().clone()
     }
+
+    async fn save_state_snapshot(&self) -> anyhow::Result<PathBuf> {
+        self.snapshot_call_count.fetch_add(1, Ordering::SeqCst);
+        Ok(PathBuf::from("state-snapshot.json"))
+    }
+
+    fn send_test_notification(&self) -> anyhow::Result<()> {
+        Ok(())
+    }
+
+    fn mute_today(&self, user_login: &str) {
+        if let Some(s) = self
+            .config
+            .lock()
+            .unwrap()
+            .streamer_settings
+            .get_mut(user_login)
+        {
+            s.muted_until = Some(twitch_backend::notify::end_of_local_day(chrono::Utc::now()));
+        }
+    }
+
+    fn clear_temporary_mute(&self, user_login: &str) {
+        if let Some(s) = self
+            .config
+            .lock()
+            .unwrap()
+            .streamer_settings
+            .get_mut(user_login)
+        {
+            s.muted_until = None;
+        }
+    }
+
+    fn toggle_setting(&self, toggle: MenuToggle) {
+        toggle.toggle(&mut self.config.lock().unwrap());
+    }
+
+    fn set_favourite(&self, user_login: &str, favourite: bool) {
+        twitch_backend::config::set_favourite(
+            &mut self.config.lock().unwrap().streamer_settings,
+            user_login,
+            user_login,
+            favourite,
+        );
+    }
+
+    fn shutdown(&self) {}
+
+    fn add_to_calendar(&self, _scheduled_id: &str) {}
+
+    fn open_in_player(&self, _user_login: &str) {}
 }
@@ -21,12 +21,18 @@ const notifyMaxGapInput = document.getElementById('notify_max_gap');
 const scheduleLookaheadInput = document.getElementById('schedule_lookahead');
 const notifyOnLiveInput = document.getElementById('notify_on_live');
 const notifyOnCategoryInput = document.getElementById('notify_on_category');
+const notifyOnTitleInput = document.getElementById('notify_on_title');
+const notifyOnScheduleChangeInput = document.getElementById('notify_on_schedule_change');
 const notifyOnHotInput = document.getElementById('notify_on_hot');
 const hotnessZThresholdInput = document.getElementById('hotness_z_threshold');
 const hotnessMinObservationsInput = document.getElementById('hotness_min_observations');
 const hotnessMinStreamsInput = document.getElementById('hotness_min_streams');
 const liveMenuLimitInput = document.getElementById('live_menu_limit');
 const scheduleMenuLimitInput = document.getElementById('schedule_menu_limit');
+const scheduleTimeFormatInput = document.getElementById('schedule_time_format');
+const groupLiveByGameInput = document.getElementById('group_live_by_game');
+const externalPlayerInput = document.getElementById('external_player');
+const showPlayedCategoriesInput = document.getElementById('show_played_categories');
 const categorySearchInput = document.getElementById('category_search');
 const searchResultsDiv = document.getElementById('search_results');
 const categoryListDiv = document.getElementById('category_list');
@@ -99,6 +105,8 @@ function populateForm() {
   notifyMaxGapInput.value = config.notify_max_gap_min;
   notifyOnLiveInput.checked = config.notify_on_live;
   notifyOnCategoryInput.checked = config.notify_on_category;
+  notifyOnTitleInput.checked = config.notify_on_title;
+  notifyOnScheduleChangeInput.checked = config.notify_on_schedule_change;
   notifyOnHotInput.checked = config.notify_on_hot;
   hotnessZThresholdInput.value = config.hotness_z_threshold;
   hotnessMinObservationsInput.value = config.hotness_min_observations;
@@ -106,6 +114,10 @@ function populateForm() {
   scheduleLookaheadInput.value = config.schedule_lookahead_hours;
   liveMenuLimitInput.value = config.live_menu_limit;
   scheduleMenuLimitInput.value = config.schedule_menu_limit;
+  scheduleTimeFormatInput.value = config.schedule_time_format || 'absolute';
+  groupLiveByGameInput.checked = config.group_live_by_game;
+  externalPlayerInput.value = config.external_player || '';
+  showPlayedCategoriesInput.checked = config.show_played_categories;
 
   renderCategoryList();
   renderStreamerList();
@@ -201,6 +213,14 @@ function renderStreamerDetailInto(container) {
         onchange="updateStreamerHotnessOverride(this.value)">
       <span class="help-text">Leave empty to use the global threshold. Lower = more sensitive.</span>
     </div>
+    <div class="form-group checkbox" style="margin-top: 16px;">
+      <label>
+        <input type="checkbox" id="streamer_notify_on_offline" ${s.notify_on_offline ? 'checked' : ''}
+          onchange="updateStreamerNotifyOnOffline(this.checked)">
+        Notify when stream ends
+      </label>
+      <span class="help-text">Shows how long the stream ran. Never sent for silent or ignored streamers.</span>
+    </div>
   `;
   return true;
 }
@@ -311,6 +331,12 @@ function updateStreamerHotnessOverride(value) {
   autoSave();
 }
 
+function updateStreamerNotifyOnOffline(checked) {
+  if (!selectedStreamer || !config.streamer_settings[selectedStreamer]) return;
+  config.streamer_settings[selectedStreamer].notify_on_offline = checked;
+  autoSave();
+}
+
 function searchStreamers(query) {
   const lowerQuery = query.toLowerCase();
   const configuredLogins = new Set(Object.keys(config?.streamer_settings || {}));
@@ -400,10 +426,10 @@ function setupEventListeners() {
   });
 
   // Auto-save on general settings changes
-  [pollIntervalInput, notifyMaxGapInput, scheduleLookaheadInput, liveMenuLimitInput, scheduleMenuLimitInput, hotnessZThresholdInput, hotnessMinObservationsInput, hotnessMinStreamsInput].forEach(input => {
+  [pollIntervalInput, notifyMaxGapInput, scheduleLookaheadInput, liveMenuLimitInput, scheduleMenuLimitInput, scheduleTimeFormatInput, externalPlayerInput, hotnessZThresholdInput, hotnessMinObservationsInput, hotnessMinStreamsInput].forEach(input => {
     input.addEventListener('change', () => autoSave());
   });
-  [notifyOnLiveInput, notifyOnCategoryInput, notifyOnHotInput].forEach(input => {
+  [notifyOnLiveInput, notifyOnCategoryInput, notifyOnTitleInput, notifyOnScheduleChangeInput, notifyOnHotInput, groupLiveByGameInput, showPlayedCategoriesInput].forEach(input => {
     input.addEventListener('change', () => autoSave());
   });
 }
@@ -483,11 +509,15 @@ async function autoSave() {
       await invoke('save_config', { config: currentConfig });
     } else {
       // Full settings mode
+      // Start from the loaded config so fields without a UI control are preserved
       const newConfig = {
+        ...config,
         poll_interval_sec: parseInt(pollIntervalInput.value, 10) || 60,
         notify_max_gap_min: parseInt(notifyMaxGapInput.value, 10) || 10,
         notify_on_live: notifyOnLiveInput.checked,
         notify_on_category: notifyOnCategoryInput.checked,
+        notify_on_title: notifyOnTitleInput.checked,
+        notify_on_schedule_change: notifyOnScheduleChangeInput.checked,
         notify_on_hot: notifyOnHotInput.checked,
         hotness_z_threshold: parseFloat(hotnessZThresholdInput.value) || 2.0,
         hotness_min_observations: parseInt(hotnessMinObservationsInput.value, 10) || 5,
@@ -495,6 +525,10 @@ async function autoSave() {
         schedule_lookahead_hours: parseInt(scheduleLookaheadInput.value, 10) || 6,
         live_menu_limit: parseInt(liveMenuLimitInput.value, 10) || 10,
         schedule_menu_limit: parseInt(scheduleMenuLimitInput.value, 10) || 5,
+        schedule_time_format: scheduleTimeFormatInput.value,
+        group_live_by_game: groupLiveByGameInput.checked,
+        external_player: externalPlayerInput.value.trim() || null,
+        show_played_categories: showPlayedCategoriesInput.checked,
         followed_categories: config.followed_categories || [],
         streamer_settings: config.streamer_settings || {}
       };
@@ -530,6 +564,7 @@ window.addStreamer = addStreamer;
 window.removeStreamer = removeStreamer;
 window.updateStreamerImportance = updateStreamerImportance;
 window.updateStreamerHotnessOverride = updateStreamerHotnessOverride;
+window.updateStreamerNotifyOnOffline = updateStreamerNotifyOnOffline;
 
 // === Debug tab functions ===
 
@@ -682,8 +717,24 @@ function renderDebugHotnessTable(entries) {
   }).join('');
 }
 
+async function saveStateSnapshot() {
+  const status = document.getElementById('debug-snapshot-status');
+  try {
+    const path = await invoke('save_state_snapshot');
+    if (status) status.textContent = `Saved to ${path}`;
+  } catch (e) {
+    console.error('Failed to save state snapshot:', e);
+    if (status) status.textContent = `Failed: ${e}`;
+  }
+}
+
 // Set up debug filter and scroll handlers once the DOM is ready
 document.addEventListener('DOMContentLoaded', () => {
+  const snapshotBtn = document.getElementById('debug-snapshot-btn');
+  if (snapshotBtn) {
+    snapshotBtn.addEventListener('click', saveStateSnapshot);
+  }
+
   const filterInput = document.getElementById('debug-filter');
   if (filterInput) {
     filterInput.addEventListener('input', e => {